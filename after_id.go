@@ -0,0 +1,102 @@
+package pagination
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"gorm.io/gorm"
+)
+
+// AfterIDPage is a slice of an id-ordered feed with the id to continue after and
+// whether more rows follow it
+type AfterIDPage[T any] struct {
+	Data    []T   `json:"data"`
+	LastID  int64 `json:"last_id"`
+	HasNext bool  `json:"has_next"`
+}
+
+// PaginateAfterID returns the next slice of an id-ordered feed for "load more" style
+// clients, e.g. ?after_id=123&limit=20 runs WHERE id > 123 ORDER BY id with the
+// builder's filters. The id is the model's single integer primary key; an afterID
+// of zero or less starts from the beginning. A limit of zero or less means
+// DefaultPerPage and larger limits are capped to MaxPerPage. The returned last id is
+// the afterID of the next call, unchanged once the feed is exhausted.
+func PaginateAfterID[T any](db *gorm.DB, builder QueryBuilder, afterID int64, limit int) ([]T, int64, error) {
+	page, err := PaginateAfterIDPage[T](db, builder, afterID, limit)
+	if err != nil {
+		return nil, afterID, err
+	}
+	return page.Data, page.LastID, nil
+}
+
+// PaginateAfterIDPage is PaginateAfterID reporting whether rows follow the slice. One
+// row past the limit is fetched and trimmed to tell, so clients know they reached
+// the end without a count or an extra empty request.
+func PaginateAfterIDPage[T any](db *gorm.DB, builder QueryBuilder, afterID int64, limit int) (AfterIDPage[T], error) {
+	page := AfterIDPage[T]{Data: []T{}, LastID: afterID}
+
+	modelSchema, err := parseModelSchema[T](db)
+	if err != nil {
+		return page, err
+	}
+	if len(modelSchema.PrimaryFields) != 1 {
+		return page, fmt.Errorf("%s must have a single primary key to paginate after an id", modelSchema.Name)
+	}
+	primaryKey := modelSchema.PrimaryFields[0]
+
+	column := primaryKey.DBName
+	if isValidIdentifier(builder.GetTableName()) {
+		column = builder.GetTableName() + "." + column
+	}
+	if !isValidIdentifier(column) {
+		return page, fmt.Errorf("invalid id column %q", column)
+	}
+
+	if limit <= 0 {
+		limit = DefaultPerPage
+	}
+	limit = min(limit, MaxPerPage)
+
+	queryDB, cancel := withQueryTimeout(db, builder)
+	defer cancel()
+
+	query := applyBaseQuery(queryDB.Table(builder.GetTableName()), builder, PaginationRequest{}, PaginatedQueryOptions{Dialect: MySQL}, allScopes)
+	if afterID > 0 {
+		query = query.Where(column+" > ?", afterID)
+	}
+
+	result, hasNext, err := findWithLookahead[T](query.Order(column+" asc"), limit)
+	if err != nil {
+		return page, queryError(queryDB, "failed to fetch records", err)
+	}
+	if len(result) == 0 {
+		return page, nil
+	}
+
+	value, _ := primaryKey.ValueOf(context.Background(), reflect.ValueOf(&result[len(result)-1]).Elem())
+	lastID := reflect.ValueOf(value)
+	switch {
+	case lastID.CanInt():
+		page.LastID = lastID.Int()
+	case lastID.CanUint():
+		page.LastID = int64(lastID.Uint())
+	default:
+		return page, fmt.Errorf("primary key of %s is not an integer", modelSchema.Name)
+	}
+	page.Data, page.HasNext = result, hasNext
+	return page, nil
+}
+
+// findWithLookahead fetches up to limit rows plus one and trims the extra row, whose
+// presence reports whether more rows follow, so keyset pages need no count
+func findWithLookahead[T any](query *gorm.DB, limit int) ([]T, bool, error) {
+	var result []T
+	if err := query.Limit(limit + 1).Find(&result).Error; err != nil {
+		return nil, false, err
+	}
+	if len(result) > limit {
+		return result[:limit], true, nil
+	}
+	return result, false, nil
+}
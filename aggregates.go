@@ -0,0 +1,81 @@
+package pagination
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// AggregatesProvider interface for query builders that summarize the filtered rows with
+// aggregate expressions, keyed by alias
+type AggregatesProvider interface {
+	GetAggregates() map[string]string
+}
+
+// Aggregates computes the builder's aggregate expressions, such as SUM(height), over
+// every row matching the request's filters and search, regardless of the page. Values
+// are keyed by alias and are nil over no rows. Filters that provably match nothing or
+// a missing required search skip the query.
+func Aggregates(db *gorm.DB, builder QueryBuilder, pagination PaginationRequest) (map[string]interface{}, error) {
+	provider, ok := builder.(AggregatesProvider)
+	if !ok || len(provider.GetAggregates()) == 0 {
+		return nil, nil
+	}
+
+	aliases := make([]string, 0, len(provider.GetAggregates()))
+	for alias := range provider.GetAggregates() {
+		if !isValidIdentifier(alias) {
+			return nil, fmt.Errorf("invalid aggregate alias %q", alias)
+		}
+		aliases = append(aliases, alias)
+	}
+	sort.Strings(aliases)
+
+	aggregates := make(map[string]interface{}, len(aliases))
+	if hasImpossibleConditions(builder) || missingRequiredSearch(builder, pagination) {
+		for _, alias := range aliases {
+			aggregates[alias] = nil
+		}
+		return aggregates, nil
+	}
+
+	selects := make([]string, len(aliases))
+	for i, alias := range aliases {
+		selects[i] = "(" + provider.GetAggregates()[alias] + ") AS " + alias
+	}
+
+	queryDB, cancel := withQueryTimeout(db, builder)
+	defer cancel()
+
+	query := applyBaseQuery(queryDB.Table(builder.GetTableName()), builder, pagination, PaginatedQueryOptions{Dialect: MySQL}, allScopes)
+	rows, err := query.Select(strings.Join(selects, ", ")).Rows()
+	if err != nil {
+		return nil, queryError(queryDB, "failed to compute aggregates", err)
+	}
+	defer rows.Close()
+
+	values := make([]interface{}, len(aliases))
+	pointers := make([]interface{}, len(aliases))
+	for i := range values {
+		pointers[i] = &values[i]
+	}
+	if rows.Next() {
+		if err := rows.Scan(pointers...); err != nil {
+			return nil, fmt.Errorf("failed to compute aggregates: %w", err)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to compute aggregates: %w", err)
+	}
+
+	for i, alias := range aliases {
+		// Drivers may return text and decimals as bytes, which would be encoded as base64
+		if value, ok := values[i].([]byte); ok {
+			values[i] = string(value)
+		}
+		aggregates[alias] = values[i]
+	}
+	return aggregates, nil
+}
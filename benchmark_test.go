@@ -162,3 +162,35 @@ func BenchmarkPaginatedQuery_PageSize100(b *testing.B) {
 		_, _, _ = PaginatedQuery[TestUser](db, builder, pagination, []string{})
 	}
 }
+
+// benchmarkFirstPage runs the first page's data query of a 10000 record table, optionally
+// with the explicit OFFSET 0 the data query leaves out
+func benchmarkFirstPage(b *testing.B, explicitOffset bool) {
+	db := setupBenchmarkDB(10000)
+	builder := NewSimpleQueryBuilder("test_users").WithDefaultSort("id asc")
+	pagination := PaginationRequest{Page: 1, PerPage: 20}
+
+	dataQuery, err := buildDataQuery[TestUser](db.Session(&gorm.Session{DryRun: true}), builder, pagination, PaginatedQueryOptions{Dialect: SQLite})
+	if err != nil {
+		b.Fatal(err)
+	}
+	stmt := dataQuery.Find(&[]TestUser{}).Statement
+	sql := stmt.SQL.String()
+	if explicitOffset {
+		sql += " OFFSET 0"
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var users []TestUser
+		_ = db.Raw(sql, stmt.Vars...).Scan(&users).Error
+	}
+}
+
+func BenchmarkFirstPage_WithoutOffset(b *testing.B) {
+	benchmarkFirstPage(b, false)
+}
+
+func BenchmarkFirstPage_ExplicitOffsetZero(b *testing.B) {
+	benchmarkFirstPage(b, true)
+}
@@ -0,0 +1,44 @@
+package pagination
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	// TotalCountHeader carries the number of matching records in count-only responses
+	TotalCountHeader = "X-Total-Count"
+	// TotalPagesHeader carries the number of pages in count-only responses
+	TotalPagesHeader = "X-Total-Pages"
+)
+
+// RespondCountHeaders answers HEAD requests, or any request when countOnly is set, by
+// running only the count query and setting the X-Total-Count and X-Total-Pages headers
+// with no body, as many data grids expect. It reports whether it answered the request,
+// so handlers go on to list the page otherwise; on error nothing is written.
+func RespondCountHeaders(
+	ctx *gin.Context,
+	db *gorm.DB,
+	builder QueryBuilder,
+	pagination PaginationRequest,
+	countOnly bool,
+) (bool, error) {
+	if ctx.Request.Method != http.MethodHead && !countOnly {
+		return false, nil
+	}
+
+	total, err := CountOnly(db, builder, pagination)
+	if err != nil {
+		return false, err
+	}
+
+	paginationResponse := CalculatePagination(pagination, total)
+	ctx.Header(TotalCountHeader, strconv.FormatInt(total, 10))
+	ctx.Header(TotalPagesHeader, strconv.FormatInt(paginationResponse.MaxPage, 10))
+	ctx.Status(http.StatusOK)
+	ctx.Writer.WriteHeaderNow()
+	return true, nil
+}
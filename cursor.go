@@ -0,0 +1,288 @@
+package pagination
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	// ErrInvalidCursor is returned when a cursor can't be decoded
+	ErrInvalidCursor = errors.New("invalid cursor")
+	// ErrCursorSortMismatch is returned when a cursor was issued for a different sort than the request's
+	ErrCursorSortMismatch = errors.New("cursor does not match the requested sort")
+)
+
+// cursorTypeTime marks cursor values holding a full-precision RFC 3339 timestamp
+const cursorTypeTime = "time"
+
+// CursorField is the boundary value of a single sort column stored in a cursor.
+// Type is "time" for timestamps, decoded back to a time.Time with their full
+// precision. Tiebreaker marks the primary key appended after the sort so rows sharing
+// the sort values are never skipped or repeated.
+type CursorField struct {
+	Field      string      `json:"field"`
+	Direction  string      `json:"direction"`
+	Value      interface{} `json:"value"`
+	Type       string      `json:"type,omitempty"`
+	Tiebreaker bool        `json:"tiebreaker,omitempty"`
+}
+
+// CursorTimeLayoutProvider interface for query builders that store cursor timestamps as
+// text in the layout the database stores them, compared as strings rather than bound
+// as driver time values that may be rounded
+type CursorTimeLayoutProvider interface {
+	GetCursorTimeLayout() string
+}
+
+// EncodeCursor serializes the ordered sort boundary values as URL-safe base64 JSON
+func EncodeCursor(fields []CursorField) (string, error) {
+	payload, err := json.Marshal(fields)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode cursor: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(payload), nil
+}
+
+// DecodeCursor decodes a cursor and verifies it was issued for exactly the given
+// sort, so a client changing the sort mid-scroll gets an error instead of an
+// incoherent page. The trailing primary key tiebreaker, one field per column of a
+// composite key, is returned along with the sort's fields.
+func DecodeCursor(cursor string, sort []SortField) ([]CursorField, error) {
+	fields, err := decodeCursorFields(cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	sortFields := fields
+	for len(sortFields) > 0 && sortFields[len(sortFields)-1].Tiebreaker {
+		sortFields = sortFields[:len(sortFields)-1]
+	}
+	if len(sortFields) != len(sort) {
+		return nil, ErrCursorSortMismatch
+	}
+
+	for i, field := range fields {
+		if i < len(sort) && (field.Field != sort[i].Field || !strings.EqualFold(field.Direction, sort[i].Direction)) {
+			return nil, ErrCursorSortMismatch
+		}
+
+		value, err := decodeCursorValue(field)
+		if err != nil {
+			return nil, err
+		}
+		fields[i].Value = value
+	}
+
+	return fields, nil
+}
+
+// ValidateCursor checks that a stored cursor is intact and was issued for the request's
+// sort, without querying, so clients can detect stale cursors, e.g. after a deploy
+// changed the sort, and start over. A request without a sort column is checked for
+// integrity only, as its sort is the builder's default.
+func ValidateCursor(cursor string, request PaginationRequest) error {
+	sort := request.Normalized().SortFields()
+	if len(sort) == 0 {
+		fields, err := decodeCursorFields(cursor)
+		if err != nil {
+			return err
+		}
+		for _, field := range fields {
+			if _, err := decodeCursorValue(field); err != nil {
+				return err
+			}
+		}
+		_, _, err = CursorCondition(fields)
+		return err
+	}
+
+	fields, err := DecodeCursor(cursor, sort)
+	if err != nil {
+		return err
+	}
+	_, _, err = CursorCondition(fields)
+	return err
+}
+
+// decodeCursorFields decodes a cursor's fields without converting their values
+func decodeCursorFields(cursor string) ([]CursorField, error) {
+	payload, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCursor, err)
+	}
+
+	var fields []CursorField
+	decoder := json.NewDecoder(bytes.NewReader(payload))
+	decoder.UseNumber()
+	if err := decoder.Decode(&fields); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCursor, err)
+	}
+
+	if len(fields) == 0 {
+		return nil, ErrInvalidCursor
+	}
+	return fields, nil
+}
+
+// decodeCursorValue converts a decoded JSON value back into a query argument
+func decodeCursorValue(field CursorField) (interface{}, error) {
+	if field.Type != cursorTypeTime {
+		return normalizeCursorValue(field.Value), nil
+	}
+
+	text, ok := field.Value.(string)
+	if !ok {
+		return nil, fmt.Errorf("%w: timestamp of %s is not a string", ErrInvalidCursor, field.Field)
+	}
+	value, err := time.Parse(time.RFC3339Nano, text)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCursor, err)
+	}
+	return value, nil
+}
+
+// CursorCondition builds the keyset condition selecting the rows after a decoded
+// cursor, e.g. (created_at > ?) OR (created_at = ? AND id > ?), comparing each column
+// in its sort direction
+func CursorCondition(fields []CursorField) (string, []interface{}, error) {
+	var conditions []string
+	var args []interface{}
+	for i, field := range fields {
+		if !isValidIdentifier(field.Field) {
+			return "", nil, fmt.Errorf("%w: invalid field %q", ErrInvalidCursor, field.Field)
+		}
+
+		operator := ">"
+		if strings.EqualFold(field.Direction, "desc") {
+			operator = "<"
+		}
+
+		parts := make([]string, 0, i+1)
+		for _, previous := range fields[:i] {
+			parts = append(parts, previous.Field+" = ?")
+			args = append(args, previous.Value)
+		}
+		parts = append(parts, field.Field+" "+operator+" ?")
+		args = append(args, field.Value)
+
+		conditions = append(conditions, "("+strings.Join(parts, " AND ")+")")
+	}
+
+	return "(" + strings.Join(conditions, " OR ") + ")", args, nil
+}
+
+// CursorOrder returns the ORDER BY clause matching a cursor's fields, including its
+// tiebreaker
+func CursorOrder(fields []CursorField) string {
+	parts := make([]string, len(fields))
+	for i, field := range fields {
+		direction := "asc"
+		if strings.EqualFold(field.Direction, "desc") {
+			direction = "desc"
+		}
+		parts[i] = field.Field + " " + direction
+	}
+	return strings.Join(parts, ", ")
+}
+
+// normalizeCursorValue converts decoded JSON numbers into int64 or float64 query arguments
+func normalizeCursorValue(value interface{}) interface{} {
+	number, ok := value.(json.Number)
+	if !ok {
+		return value
+	}
+
+	if i, err := number.Int64(); err == nil {
+		return i
+	}
+	if f, err := number.Float64(); err == nil {
+		return f
+	}
+	return number.String()
+}
+
+// EffectiveSort returns the sort an offset page is ordered by: the requested sort
+// columns that are valid and allowed, otherwise the builder's default sort with its
+// columns in the direction of an order sent without a sort, or no sort in natural
+// order or when ordered by IDs. A forced sort always wins. Relevance ranking of
+// weighted searches is not included.
+func EffectiveSort(builder QueryBuilder, pagination PaginationRequest) []SortField {
+	if clause, forced := forcedSort(builder); forced {
+		return ParseSortClause(clause)
+	}
+
+	if _, _, byIDs := orderByIDs(builder); byIDs {
+		return nil
+	}
+
+	pagination = pagination.Normalized()
+
+	if valid, _ := partitionSorts(builder, pagination); len(valid) > 0 {
+		return valid
+	}
+	if usesNaturalOrder(builder) {
+		return nil
+	}
+	if pagination.Sort != "" {
+		return ParseSortClause(builder.GetDefaultSort())
+	}
+
+	if order, ok := pagination.explicitOrder(); ok {
+		if directed, ok := sortClauseWithDirection(builder.GetDefaultSort(), order); ok {
+			return ParseSortClause(directed)
+		}
+	}
+	return ParseSortClause(builder.GetDefaultSort())
+}
+
+// CursorFromPage returns the cursor positioned after the last item of an offset page,
+// so clients can continue from it with cursor navigation. The cursor holds the last
+// item's values for the page's effective sort, followed by the columns of its primary
+// key the sort doesn't include as a tiebreaker; an empty or unsorted page has no
+// cursor.
+func CursorFromPage[T any](db *gorm.DB, builder QueryBuilder, pagination PaginationRequest, data []T) (string, error) {
+	sort := EffectiveSort(builder, pagination)
+	if len(data) == 0 || len(sort) == 0 {
+		return "", nil
+	}
+
+	modelSchema, err := parseModelSchema[T](db)
+	if err != nil {
+		return "", err
+	}
+
+	timeLayout := ""
+	if provider, ok := builder.(CursorTimeLayoutProvider); ok {
+		timeLayout = provider.GetCursorTimeLayout()
+	}
+
+	last := reflect.ValueOf(&data[len(data)-1]).Elem()
+	return encodeRowCursor(cursorKeys(sort, modelSchema), modelSchema, last, timeLayout)
+}
+
+// cursorField stores an item's value for a sort column, keeping timestamps at their
+// full precision, or as text in timeLayout when set
+func cursorField(sortField SortField, value interface{}, timeLayout string) CursorField {
+	field := CursorField{Field: sortField.Field, Direction: sortField.Direction, Value: value}
+
+	if pointer, ok := value.(*time.Time); ok && pointer != nil {
+		value = *pointer
+	}
+	if timestamp, ok := value.(time.Time); ok {
+		if timeLayout != "" {
+			field.Value = timestamp.Format(timeLayout)
+		} else {
+			field.Value = timestamp.Format(time.RFC3339Nano)
+			field.Type = cursorTypeTime
+		}
+	}
+	return field
+}
@@ -0,0 +1,56 @@
+package pagination
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// DatasetVersionProvider interface for query builders that version the filtered rows by
+// the latest value of a modification time column
+type DatasetVersionProvider interface {
+	GetDatasetVersionColumn() string
+}
+
+// DatasetVersion returns an opaque version of every row matching the request's filters
+// and search, derived from MAX of the builder's time column and the row count, so
+// polling clients can tell whether anything changed without diffing rows. Updates that
+// touch the time column, inserts and deletes change it. It is "" unless the builder
+// provides a time column.
+func DatasetVersion(db *gorm.DB, builder QueryBuilder, pagination PaginationRequest) (string, error) {
+	provider, ok := builder.(DatasetVersionProvider)
+	if !ok || provider.GetDatasetVersionColumn() == "" {
+		return "", nil
+	}
+	column := provider.GetDatasetVersionColumn()
+	if !isValidIdentifier(column) {
+		return "", fmt.Errorf("invalid dataset version column %q", column)
+	}
+
+	var latest interface{}
+	var count int64
+	if !hasImpossibleConditions(builder) && !missingRequiredSearch(builder, pagination) {
+		queryDB, cancel := withQueryTimeout(db, builder)
+		defer cancel()
+
+		query := applyBaseQuery(queryDB.Table(builder.GetTableName()), builder, pagination, PaginatedQueryOptions{Dialect: MySQL}, allScopes)
+		if err := query.Select("MAX("+column+"), COUNT(*)").Row().Scan(&latest, &count); err != nil {
+			return "", queryError(queryDB, "failed to compute dataset version", err)
+		}
+	}
+
+	switch value := latest.(type) {
+	case []byte:
+		latest = string(value)
+	case time.Time:
+		latest = value.UTC().Format(time.RFC3339Nano)
+	case nil:
+		latest = ""
+	}
+
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%v|%d", latest, count)))
+	return hex.EncodeToString(sum[:16]), nil
+}
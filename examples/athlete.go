@@ -29,22 +29,17 @@ type PlayersEvents struct {
 
 type AthleteFilter struct {
 	pagination.BaseFilter
-	ID         int `json:"id" form:"id"`
-	ProvinceID int `json:"province_id" form:"province_id"`
-	SportID    int `json:"sport_id" form:"sport_id"`
-	EventID    int `json:"event_id" form:"event_id"`
+	ID            int   `json:"id" form:"id" filter:"id"`
+	ProvinceID    int   `json:"province_id" form:"province_id" uri:"province_id" filter:"province_id"`
+	SportID       *int  `json:"sport_id" form:"sport_id" uri:"sport_id"`
+	SportIDIsNull *bool `json:"sport_id_is_null" form:"sport_id_is_null"`
+	EventID       int   `json:"event_id" form:"event_id" uri:"event_id"`
 }
 
 func (f *AthleteFilter) ApplyFilters(query *gorm.DB) *gorm.DB {
-	if f.ID > 0 {
-		query = query.Where("id = ?", f.ID)
-	}
-	if f.ProvinceID > 0 {
-		query = query.Where("province_id = ?", f.ProvinceID)
-	}
-	if f.SportID > 0 {
-		query = query.Where("sport_id = ?", f.SportID)
-	}
+	query = pagination.ApplyTaggedFilters(query, f)
+	query = pagination.ApplyNullableIntFilter(query, "sport_id", f.SportID, f.SportIDIsNull)
+
 	if f.EventID > 0 {
 		// You can add joins or subqueries here for EventID filtering
 		// Example: query = query.Joins("JOIN players_events pe ON pe.player_id = athletes.id AND pe.player_type = 'athlete'").Where("pe.event_id = ?", f.EventID)
@@ -60,10 +55,6 @@ func (f *AthleteFilter) GetSearchFields() []string {
 	return []string{"name"}
 }
 
-func (f *AthleteFilter) GetDefaultSort() string {
-	return "id asc"
-}
-
 func (f *AthleteFilter) GetIncludes() []string {
 	return f.Includes
 }
@@ -20,35 +20,30 @@ type Event struct {
 }
 type EventFilter struct {
 	pagination.BaseFilter
-	ID        int       `json:"id" form:"id"`
-	Name      string    `json:"name" form:"name"`
+	ID        int       `json:"id" form:"id" filter:"id"`
+	Name      string    `json:"name" form:"name" filter:"name,op=like"`
 	Location  string    `json:"location" form:"location"`
 	IsActive  bool      `json:"is_active" form:"is_active"`
 	Year      int       `json:"year" form:"year"`
-	SportID   int       `json:"sport_id" form:"sport_id"`
-	StartDate time.Time `json:"start_date" form:"start_date"`
-	EndDate   time.Time `json:"end_date" form:"end_date"`
+	SportID   int       `json:"sport_id" form:"sport_id" filter:"sport_id"`
+	StartDate time.Time `json:"start_date" form:"start_date" filter:"start_date,op=gte"`
+	EndDate   time.Time `json:"end_date" form:"end_date" filter:"end_date,op=lte"`
+
+	// StartFrom and StartTo select events starting in a range, StartBounds gives
+	// its inclusivity, e.g. "[)" for reports bucketed by day
+	StartFrom   time.Time `json:"start_from" form:"start_from"`
+	StartTo     time.Time `json:"start_to" form:"start_to"`
+	StartBounds string    `json:"start_bounds" form:"start_bounds"`
 }
 
 func (f *EventFilter) ApplyFilters(query *gorm.DB) *gorm.DB {
-	if f.ID > 0 {
-		query = query.Where("id = ?", f.ID)
-	}
-	if f.Name != "" {
-		query = query.Where("name LIKE ?", "%"+f.Name+"%")
-	}
+	query = pagination.ApplyTaggedFilters(query, f)
+	query = pagination.ApplyDateRange(query, "start_date", f.StartFrom, f.StartTo, f.StartBounds)
+
+	// Expressions on a column still need a hand-written condition
 	if f.Year > 0 {
 		query = query.Where("YEAR(start_date) = ?", f.Year)
 	}
-	if f.SportID > 0 {
-		query = query.Where("sport_id = ?", f.SportID)
-	}
-	if !f.StartDate.IsZero() {
-		query = query.Where("start_date >= ?", f.StartDate)
-	}
-	if !f.EndDate.IsZero() {
-		query = query.Where("end_date <= ?", f.EndDate)
-	}
 
 	return query
 }
@@ -61,10 +56,6 @@ func (f *EventFilter) GetSearchFields() []string {
 	return []string{"name", "description", "location"}
 }
 
-func (f *EventFilter) GetDefaultSort() string {
-	return "id asc"
-}
-
 func (f *EventFilter) GetIncludes() []string {
 	return f.Includes
 }
@@ -2,7 +2,6 @@ package main
 
 import (
 	"log"
-	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -28,11 +27,7 @@ func main() {
 	r := gin.Default()
 
 	r.GET("/provinces", func(c *gin.Context) {
-		filter := &ProvinceFilter{}
-		response := pagination.PaginatedAPIResponseWithCustomFilter[Province](
-			db, c, filter, "Provinces retrieved successfully",
-		)
-		c.JSON(response.Code, response)
+		pagination.RespondPaginated[Province](c, db, &ProvinceFilter{}, "Provinces retrieved successfully")
 	})
 
 	r.GET("/provinces/with-athletes", func(c *gin.Context) {
@@ -54,11 +49,7 @@ func main() {
 	})
 
 	r.GET("/sports", func(c *gin.Context) {
-		filter := &SportFilter{}
-		response := pagination.PaginatedAPIResponseWithCustomFilter[Sport](
-			db, c, filter, "Sports retrieved successfully",
-		)
-		c.JSON(response.Code, response)
+		pagination.RespondPaginated[Sport](c, db, &SportFilter{}, "Sports retrieved successfully")
 	})
 
 	r.GET("/sports/with-relations", func(c *gin.Context) {
@@ -80,11 +71,7 @@ func main() {
 	})
 
 	r.GET("/events", func(c *gin.Context) {
-		filter := &EventFilter{}
-		response := pagination.PaginatedAPIResponseWithCustomFilter[Event](
-			db, c, filter, "Events retrieved successfully",
-		)
-		c.JSON(response.Code, response)
+		pagination.RespondPaginated[Event](c, db, &EventFilter{}, "Events retrieved successfully")
 	})
 
 	r.GET("/events/with-sport", func(c *gin.Context) {
@@ -106,11 +93,7 @@ func main() {
 	})
 
 	r.GET("/athletes", func(c *gin.Context) {
-		filter := &AthleteFilter{}
-		response := pagination.PaginatedAPIResponseWithCustomFilter[Athlete](
-			db, c, filter, "Athletes retrieved successfully",
-		)
-		c.JSON(response.Code, response)
+		pagination.RespondPaginated[Athlete](c, db, &AthleteFilter{}, "Athletes retrieved successfully")
 	})
 
 	r.GET("/athletes/with-includes", func(c *gin.Context) {
@@ -153,55 +136,17 @@ func main() {
 		c.JSON(200, response)
 	})
 
-	r.GET("/provinces/:id/athletes", func(c *gin.Context) {
-		provinceID, err := strconv.Atoi(c.Param("id"))
-		if err != nil {
-			c.JSON(400, gin.H{"error": "Invalid province ID"})
-			return
-		}
-
-		filter := &AthleteFilter{
-			ProvinceID: provinceID,
-		}
-
-		response := pagination.PaginatedAPIResponseWithCustomFilter[Athlete](
-			db, c, filter, "Athletes from province retrieved successfully",
-		)
-		c.JSON(response.Code, response)
+	// Path parameters are bound into the filter's uri-tagged fields; a non-numeric ID is answered with 400
+	r.GET("/provinces/:province_id/athletes", func(c *gin.Context) {
+		pagination.RespondPaginated[Athlete](c, db, &AthleteFilter{}, "Athletes from province retrieved successfully")
 	})
 
-	r.GET("/sports/:id/athletes", func(c *gin.Context) {
-		sportID, err := strconv.Atoi(c.Param("id"))
-		if err != nil {
-			c.JSON(400, gin.H{"error": "Invalid sport ID"})
-			return
-		}
-
-		filter := &AthleteFilter{
-			SportID: sportID,
-		}
-
-		response := pagination.PaginatedAPIResponseWithCustomFilter[Athlete](
-			db, c, filter, "Athletes from sport retrieved successfully",
-		)
-		c.JSON(response.Code, response)
+	r.GET("/sports/:sport_id/athletes", func(c *gin.Context) {
+		pagination.RespondPaginated[Athlete](c, db, &AthleteFilter{}, "Athletes from sport retrieved successfully")
 	})
 
-	r.GET("/events/:id/athletes", func(c *gin.Context) {
-		eventID, err := strconv.Atoi(c.Param("id"))
-		if err != nil {
-			c.JSON(400, gin.H{"error": "Invalid event ID"})
-			return
-		}
-
-		filter := &AthleteFilter{
-			EventID: eventID,
-		}
-
-		response := pagination.PaginatedAPIResponseWithCustomFilter[Athlete](
-			db, c, filter, "Athletes from event retrieved successfully",
-		)
-		c.JSON(response.Code, response)
+	r.GET("/events/:event_id/athletes", func(c *gin.Context) {
+		pagination.RespondPaginated[Athlete](c, db, &AthleteFilter{}, "Athletes from event retrieved successfully")
 	})
 
 	log.Println("Server starting on :8080")
@@ -210,14 +155,14 @@ func main() {
 	log.Println("GET /provinces/with-athletes - Same as above but with ?includes=Athletes")
 	log.Println("GET /sports - Filter: ?id=1&name=sepak&category=team&search=sepak&page=1&per_page=10")
 	log.Println("GET /sports/with-relations - Same as above but with ?includes=Athletes,Events")
-	log.Println("GET /events - Filter: ?id=1&name=pon&location=jakarta&start_year=2024&search=pon&page=1&per_page=10")
+	log.Println("GET /events - Filter: ?id=1&name=pon&location=jakarta&start_year=2024&start_from=2024-01-01T00:00:00Z&start_to=2025-01-01T00:00:00Z&start_bounds=[)&search=pon&page=1&per_page=10")
 	log.Println("GET /events/with-sport - Same as above but with ?includes=Sport")
-	log.Println("GET /athletes - Filter: ?id=1&province_id=1&sport_id=1&event_id=1&min_age=18&max_age=30&search=name&page=1&per_page=10")
+	log.Println("GET /athletes - Filter: ?id=1&province_id=1&sport_id=1&sport_id_is_null=true&event_id=1&min_age=18&max_age=30&search=name&page=1&per_page=10")
 	log.Println("GET /athletes/with-includes - Same as above but with ?includes=Province,Sport,PlayersEvents")
 	log.Println("GET /athletes/detailed - Same as athletes but with relationships loaded")
-	log.Println("GET /provinces/:id/athletes - Athletes from specific province")
-	log.Println("GET /sports/:id/athletes - Athletes from specific sport")
-	log.Println("GET /events/:id/athletes - Athletes from specific event")
+	log.Println("GET /provinces/:province_id/athletes - Athletes from specific province")
+	log.Println("GET /sports/:sport_id/athletes - Athletes from specific sport")
+	log.Println("GET /events/:event_id/athletes - Athletes from specific event")
 
 	r.Run(":8080")
 }
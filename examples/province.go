@@ -14,23 +14,13 @@ type Province struct {
 
 type ProvinceFilter struct {
 	pagination.BaseFilter
-	ID   int    `json:"id" form:"id"`
-	Name string `json:"name" form:"name"`
-	Code string `json:"code" form:"code"`
+	ID   int    `json:"id" form:"id" filter:"id"`
+	Name string `json:"name" form:"name" filter:"name,op=like"`
+	Code string `json:"code" form:"code" filter:"code"`
 }
 
 func (f *ProvinceFilter) ApplyFilters(query *gorm.DB) *gorm.DB {
-	if f.ID > 0 {
-		query = query.Where("id = ?", f.ID)
-	}
-	if f.Name != "" {
-		query = query.Where("name LIKE ?", "%"+f.Name+"%")
-	}
-	if f.Code != "" {
-		query = query.Where("code = ?", f.Code)
-	}
-
-	return query
+	return pagination.ApplyTaggedFilters(query, f)
 }
 
 func (f *ProvinceFilter) GetTableName() string {
@@ -41,10 +31,6 @@ func (f *ProvinceFilter) GetSearchFields() []string {
 	return []string{"name", "code"}
 }
 
-func (f *ProvinceFilter) GetDefaultSort() string {
-	return "id asc"
-}
-
 func (f *ProvinceFilter) GetIncludes() []string {
 	return f.Includes
 }
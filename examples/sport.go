@@ -17,24 +17,14 @@ type Sport struct {
 
 type SportFilter struct {
 	pagination.BaseFilter
-	ID       int    `json:"id" form:"id"`
-	Name     string `json:"name" form:"name"`
-	Category string `json:"category" form:"category"`
+	ID       int    `json:"id" form:"id" filter:"id"`
+	Name     string `json:"name" form:"name" filter:"name,op=like"`
+	Category string `json:"category" form:"category" filter:"category,op=ieq"`
 	IsActive bool   `json:"is_active" form:"is_active"`
 }
 
 func (f *SportFilter) ApplyFilters(query *gorm.DB) *gorm.DB {
-	if f.ID > 0 {
-		query = query.Where("id = ?", f.ID)
-	}
-	if f.Name != "" {
-		query = query.Where("name LIKE ?", "%"+f.Name+"%")
-	}
-	if f.Category != "" {
-		query = query.Where("category = ?", f.Category)
-	}
-
-	return query
+	return pagination.ApplyTaggedFilters(query, f)
 }
 
 func (f *SportFilter) GetTableName() string {
@@ -45,10 +35,6 @@ func (f *SportFilter) GetSearchFields() []string {
 	return []string{"name", "category", "description"}
 }
 
-func (f *SportFilter) GetDefaultSort() string {
-	return "id asc"
-}
-
 func (f *SportFilter) GetIncludes() []string {
 	return f.Includes
 }
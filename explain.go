@@ -0,0 +1,158 @@
+package pagination
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+
+	"gorm.io/gorm"
+)
+
+var (
+	explainMu             sync.RWMutex
+	explainEnabled        bool
+	explainAnalyzeAllowed bool
+)
+
+// ExplainProvider interface for query builders that return the data query's plan in the
+// response for debugging, when SetExplainEnabled allows it. Analyze asks for EXPLAIN
+// ANALYZE, which runs the query, and is only honored under SetExplainAnalyzeAllowed.
+type ExplainProvider interface {
+	GetExplain() (enabled bool, analyze bool)
+}
+
+// SetExplainEnabled allows builders created with WithExplain to return the data query's
+// plan. It is off by default; keep it off in production, where plans leak schema
+// details and cost an extra query per request.
+func SetExplainEnabled(enabled bool) {
+	explainMu.Lock()
+	defer explainMu.Unlock()
+
+	explainEnabled = enabled
+}
+
+// SetExplainAnalyzeAllowed allows EXPLAIN ANALYZE, which executes the data query a second
+// time, for builders asking for it. Otherwise they get a plain EXPLAIN.
+func SetExplainAnalyzeAllowed(allowed bool) {
+	explainMu.Lock()
+	defer explainMu.Unlock()
+
+	explainAnalyzeAllowed = allowed
+}
+
+// explainSettings returns whether plans are enabled and whether ANALYZE is allowed
+func explainSettings() (bool, bool) {
+	explainMu.RLock()
+	defer explainMu.RUnlock()
+
+	return explainEnabled, explainAnalyzeAllowed
+}
+
+// explainPrefix returns the statement prefix explaining a query on the dialect, or false
+// when the dialect has no such statement. SQLite has no ANALYZE variant.
+func explainPrefix(dialect DatabaseDialect, analyze bool) (string, bool) {
+	switch dialect {
+	case SQLite:
+		return "EXPLAIN QUERY PLAN ", true
+	case PostgreSQL, MySQL:
+		if analyze {
+			return "EXPLAIN ANALYZE ", true
+		}
+		return "EXPLAIN ", true
+	}
+	return "", false
+}
+
+// queryPlan returns the plan of the page's data query, one line per row of the EXPLAIN
+// output, or nil unless the builder asks for it and SetExplainEnabled allows it. Plans
+// that can't be produced are logged rather than failing the request.
+func queryPlan[T any](db *gorm.DB, builder QueryBuilder, pagination PaginationRequest, options PaginatedQueryOptions) []string {
+	provider, ok := builder.(ExplainProvider)
+	if !ok {
+		return nil
+	}
+	requested, analyze := provider.GetExplain()
+	enabled, analyzeAllowed := explainSettings()
+	if !requested || !enabled {
+		return nil
+	}
+
+	plan, err := explainDataQuery[T](db, builder, pagination, options, analyze && analyzeAllowed)
+	if err != nil {
+		log.Printf("pagination: query plan for %s unavailable: %v", builder.GetTableName(), err)
+		return nil
+	}
+	return plan
+}
+
+// explainDataQuery runs EXPLAIN on the data query built for the page
+func explainDataQuery[T any](db *gorm.DB, builder QueryBuilder, pagination PaginationRequest, options PaginatedQueryOptions, analyze bool) ([]string, error) {
+	prefix, ok := explainPrefix(driverDialect(db), analyze)
+	if !ok {
+		return nil, fmt.Errorf("EXPLAIN is not supported on %s", db.Dialector.Name())
+	}
+
+	dataQuery, err := buildDataQuery[T](db.Session(&gorm.Session{DryRun: true}), builder, pagination, options)
+	if err != nil {
+		return nil, err
+	}
+	statement := dataQuery.Find(&[]T{}).Statement
+
+	rows, err := db.Raw(prefix+statement.SQL.String(), statement.Vars...).Rows()
+	if err != nil {
+		return nil, fmt.Errorf("failed to explain query: %w", err)
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, fmt.Errorf("failed to explain query: %w", err)
+	}
+
+	plan := []string{}
+	for rows.Next() {
+		values := make([]interface{}, len(columns))
+		pointers := make([]interface{}, len(columns))
+		for i := range values {
+			pointers[i] = &values[i]
+		}
+		if err := rows.Scan(pointers...); err != nil {
+			return nil, fmt.Errorf("failed to explain query: %w", err)
+		}
+		plan = append(plan, planLine(columns, values))
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to explain query: %w", err)
+	}
+	return plan, nil
+}
+
+// planLine formats a row of EXPLAIN output: the detail column of SQLite's query plan, the
+// single text column of PostgreSQL's, or column=value pairs of MySQL's tabular rows
+func planLine(columns []string, values []interface{}) string {
+	text := func(value interface{}) string {
+		if bytes, ok := value.([]byte); ok {
+			return string(bytes)
+		}
+		if value == nil {
+			return "NULL"
+		}
+		return fmt.Sprint(value)
+	}
+
+	for i, column := range columns {
+		if column == "detail" {
+			return text(values[i])
+		}
+	}
+	if len(columns) == 1 {
+		return text(values[0])
+	}
+
+	parts := make([]string, len(columns))
+	for i, column := range columns {
+		parts[i] = column + "=" + text(values[i])
+	}
+	return strings.Join(parts, " ")
+}
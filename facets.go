@@ -0,0 +1,99 @@
+package pagination
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"gorm.io/gorm"
+)
+
+// ErrFacetNotAllowed is returned when distinct values are requested for a column that
+// isn't registered as facetable
+var ErrFacetNotAllowed = errors.New("column is not facetable")
+
+// FacetValue is a distinct value of a column with the number of rows holding it
+type FacetValue struct {
+	Value interface{} `json:"value"`
+	Count int64       `json:"count"`
+}
+
+var (
+	facetColumnsMu sync.RWMutex
+	facetColumns   = map[string]map[string]bool{}
+)
+
+// RegisterFacetColumns allows DistinctValues to list the values of the table's columns.
+// Only registered columns are ever queried.
+func RegisterFacetColumns(table string, columns ...string) {
+	facetColumnsMu.Lock()
+	defer facetColumnsMu.Unlock()
+
+	if facetColumns[table] == nil {
+		facetColumns[table] = map[string]bool{}
+	}
+	for _, column := range columns {
+		facetColumns[table][column] = true
+	}
+}
+
+// isFacetColumn reports whether the column is registered as facetable on the table
+func isFacetColumn(table, column string) bool {
+	facetColumnsMu.RLock()
+	defer facetColumnsMu.RUnlock()
+
+	return facetColumns[table][column] && isValidIdentifier(table) && isValidIdentifier(column)
+}
+
+// DistinctValues returns a page of the distinct values of a column with their row
+// counts, such as the categories populating a filter dropdown. The column must be
+// registered with RegisterFacetColumns; the client only chooses the column. Values are
+// sorted by value, or by count with sort=count, in the request's order.
+func DistinctValues(db *gorm.DB, table, column string, pagination PaginationRequest) ([]FacetValue, PaginationResponse, error) {
+	if !isFacetColumn(table, column) {
+		return nil, PaginationResponse{}, fmt.Errorf("%w: %s.%s", ErrFacetNotAllowed, table, column)
+	}
+
+	pagination = pagination.Normalized()
+	values := db.Table(table).
+		Select(column + " AS value, COUNT(*) AS count").
+		Group(column)
+
+	var totalCount int64
+	if err := db.Session(&gorm.Session{NewDB: true}).Table("(?) AS facet_values", values).Count(&totalCount).Error; err != nil {
+		return nil, PaginationResponse{}, fmt.Errorf("failed to count distinct values: %w", err)
+	}
+
+	order := "value " + pagination.Order
+	if pagination.Sort == "count" {
+		order = "count " + pagination.Order + ", value asc"
+	}
+
+	facets := []FacetValue{}
+	query := values.Order(order)
+	if !pagination.IsDisabled {
+		query = query.Offset((pagination.Page - 1) * pagination.PerPage).Limit(pagination.PerPage)
+	}
+	rows, err := query.Rows()
+	if err != nil {
+		return nil, PaginationResponse{}, fmt.Errorf("failed to fetch distinct values: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var facet FacetValue
+		if err := rows.Scan(&facet.Value, &facet.Count); err != nil {
+			return nil, PaginationResponse{}, fmt.Errorf("failed to fetch distinct values: %w", err)
+		}
+		// Drivers may return text as bytes, which would be encoded as base64
+		if value, ok := facet.Value.([]byte); ok {
+			facet.Value = string(value)
+		}
+		facets = append(facets, facet)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, PaginationResponse{}, fmt.Errorf("failed to fetch distinct values: %w", err)
+	}
+
+	return facets, CalculatePagination(pagination, totalCount), nil
+}
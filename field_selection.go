@@ -0,0 +1,230 @@
+package pagination
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// ErrInvalidFieldSelection is returned when a fields parameter is malformed
+var ErrInvalidFieldSelection = errors.New("invalid field selection")
+
+// FieldSelection is the projection requested by a fields parameter such as
+// id,name,province{name,code}: the fields of the base table and, by relation name, the
+// selection within each relation, which may nest further
+type FieldSelection struct {
+	Fields    []string
+	Relations map[string]*FieldSelection
+}
+
+// FieldSelectionProvider interface for query builders that let clients choose the
+// fields returned, of the base table and of its relations. Selectable fields are keyed
+// by relation path, such as "Province" or "Province.Region", with "" for the base table.
+type FieldSelectionProvider interface {
+	GetFieldSelection() *FieldSelection
+	GetSelectableFields() map[string]map[string]bool
+}
+
+// ParseFieldSelection parses a GraphQL-like fields parameter, e.g.
+// ?fields=id,name,province{name,code}, into a field selection. Names are validated
+// later against the builder's selectable fields, where unlisted fields and relations
+// are ignored; only unbalanced braces or unnamed relations are errors.
+func ParseFieldSelection(fields string) (*FieldSelection, error) {
+	root := &FieldSelection{}
+	stack := []*FieldSelection{root}
+
+	var name strings.Builder
+	flush := func() {
+		if field := strings.TrimSpace(name.String()); field != "" {
+			top := stack[len(stack)-1]
+			top.Fields = append(top.Fields, field)
+		}
+		name.Reset()
+	}
+
+	for _, r := range fields {
+		switch r {
+		case ',':
+			flush()
+		case '{':
+			relation := strings.TrimSpace(name.String())
+			name.Reset()
+			if relation == "" {
+				return nil, fmt.Errorf("%w: relation without a name in %q", ErrInvalidFieldSelection, fields)
+			}
+			top := stack[len(stack)-1]
+			if top.Relations == nil {
+				top.Relations = map[string]*FieldSelection{}
+			}
+			child, ok := top.Relations[relation]
+			if !ok {
+				child = &FieldSelection{}
+				top.Relations[relation] = child
+			}
+			stack = append(stack, child)
+		case '}':
+			flush()
+			if len(stack) == 1 {
+				return nil, fmt.Errorf("%w: unbalanced braces in %q", ErrInvalidFieldSelection, fields)
+			}
+			stack = stack[:len(stack)-1]
+		default:
+			name.WriteRune(r)
+		}
+	}
+	flush()
+
+	if len(stack) != 1 {
+		return nil, fmt.Errorf("%w: unbalanced braces in %q", ErrInvalidFieldSelection, fields)
+	}
+	return root, nil
+}
+
+// selectedPreload is a relation loaded with only the selected columns
+type selectedPreload struct {
+	Path    string
+	Columns []string
+}
+
+// fieldSelectionPlan resolves the builder's field selection against its selectable
+// fields and the model T: the base table's columns, nil to select them all, and the
+// relations to preload with their columns. Key columns needed to match relations to
+// their parents are always selected.
+func fieldSelectionPlan[T any](db *gorm.DB, builder QueryBuilder) ([]string, []selectedPreload) {
+	provider, ok := builder.(FieldSelectionProvider)
+	if !ok || provider.GetFieldSelection() == nil {
+		return nil, nil
+	}
+
+	modelSchema, err := parseModelSchema[T](db)
+	if err != nil {
+		return nil, nil
+	}
+
+	allowRelation := func(path string) bool {
+		return len(validateIncludes(builder, []string{path})) == 1
+	}
+	columns, preloads := resolveFieldSelection(modelSchema, provider.GetFieldSelection(), provider.GetSelectableFields(), "", nil, allowRelation)
+
+	if len(columns) > 0 && isValidIdentifier(builder.GetTableName()) {
+		for i, column := range columns {
+			columns[i] = builder.GetTableName() + "." + column
+		}
+	}
+	return columns, preloads
+}
+
+// resolveFieldSelection resolves the selection of the relation at path, whose schema is
+// modelSchema, selecting the required columns along with the allowed fields
+func resolveFieldSelection(
+	modelSchema *schema.Schema,
+	selection *FieldSelection,
+	selectable map[string]map[string]bool,
+	path string,
+	required []string,
+	allowRelation func(path string) bool,
+) ([]string, []selectedPreload) {
+	var columns []string
+	for _, name := range selection.Fields {
+		if !selectable[path][name] || !isValidIdentifier(name) {
+			continue
+		}
+		if field := modelSchema.LookUpField(name); field != nil && field.DBName != "" {
+			columns = append(columns, field.DBName)
+		}
+	}
+
+	names := make([]string, 0, len(selection.Relations))
+	for name := range selection.Relations {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var keys []string
+	var preloads []selectedPreload
+	for _, name := range names {
+		relation := lookUpRelation(modelSchema, name)
+		if relation == nil {
+			continue
+		}
+		relationPath := relation.Name
+		if path != "" {
+			relationPath = path + "." + relation.Name
+		}
+		if selectable[relationPath] == nil || !allowRelation(relationPath) {
+			continue
+		}
+
+		// Each side selects its columns of the references joining them
+		var relatedKeys []string
+		for _, reference := range relation.References {
+			for _, field := range []*schema.Field{reference.PrimaryKey, reference.ForeignKey} {
+				switch {
+				case field == nil:
+				case field.Schema == modelSchema:
+					keys = append(keys, field.DBName)
+				case field.Schema == relation.FieldSchema:
+					relatedKeys = append(relatedKeys, field.DBName)
+				}
+			}
+		}
+
+		relationColumns, nested := resolveFieldSelection(relation.FieldSchema, selection.Relations[name], selectable, relationPath, relatedKeys, allowRelation)
+		preloads = append(preloads, selectedPreload{Path: relationPath, Columns: relationColumns})
+		preloads = append(preloads, nested...)
+	}
+
+	// Without any selected field every column is loaded
+	if len(columns) == 0 {
+		return nil, preloads
+	}
+
+	columns = append(columns, primaryKeyColumns(modelSchema)...)
+	columns = append(columns, required...)
+	columns = append(columns, keys...)
+	return uniqueStrings(columns), preloads
+}
+
+// selectedPreloadConditions returns the preload function selecting the columns, when
+// restricted, and applying the include's filters
+func selectedPreloadConditions(columns []string, filters []IncludeFilter) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if len(columns) > 0 {
+			db = db.Select(columns)
+		}
+		if len(filters) > 0 {
+			db = preloadConditions(filters)(db)
+		}
+		return db
+	}
+}
+
+// lookUpRelation finds a relation by name, ignoring case, so clients can write
+// province for the Province relation
+func lookUpRelation(modelSchema *schema.Schema, name string) *schema.Relationship {
+	if relation, ok := modelSchema.Relationships.Relations[name]; ok {
+		return relation
+	}
+	for relationName, relation := range modelSchema.Relationships.Relations {
+		if strings.EqualFold(relationName, name) {
+			return relation
+		}
+	}
+	return nil
+}
+
+// uniqueStrings returns the values without duplicates, keeping their first occurrence
+func uniqueStrings(values []string) []string {
+	unique := make([]string, 0, len(values))
+	for _, value := range values {
+		if !slices.Contains(unique, value) {
+			unique = append(unique, value)
+		}
+	}
+	return unique
+}
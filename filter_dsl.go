@@ -0,0 +1,220 @@
+package pagination
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DefaultMaxFilterConditions is the default cap on the filter conditions a request
+// can submit through field[operator] parameters
+const DefaultMaxFilterConditions = 50
+
+// ErrTooManyFilterConditions is returned when a request submits more filter conditions
+// than the configured maximum
+var ErrTooManyFilterConditions = errors.New("too many filter conditions")
+
+var (
+	maxFilterConditionsMu sync.RWMutex
+	maxFilterConditions   = DefaultMaxFilterConditions
+)
+
+// SetMaxFilterConditions sets the cap on the filter conditions a request can submit
+// through field[operator] parameters. Zero or less restores DefaultMaxFilterConditions.
+func SetMaxFilterConditions(n int) {
+	maxFilterConditionsMu.Lock()
+	defer maxFilterConditionsMu.Unlock()
+
+	if n <= 0 {
+		n = DefaultMaxFilterConditions
+	}
+	maxFilterConditions = n
+}
+
+// getMaxFilterConditions returns the cap on submitted filter conditions
+func getMaxFilterConditions() int {
+	maxFilterConditionsMu.RLock()
+	defer maxFilterConditionsMu.RUnlock()
+
+	return maxFilterConditions
+}
+
+// filterParamPattern matches query parameters of the form field[operator]
+var filterParamPattern = regexp.MustCompile(`^([A-Za-z0-9_.]+)\[([A-Za-z_]+)\]$`)
+
+// ParseFilterConditions parses query parameters of the form field[operator]=value,
+// such as ?name[startswith]=bud or ?age[gte]=18, into filter conditions. Repeated
+// parameters produce one condition each, except repeated ranges such as
+// ?age[range]=18-25&age[range]=30-40, which match any of the ranges; in and not_in
+// take comma-separated values. Fields are validated later against the filter's model.
+// The number of conditions is not capped; see ParseFilterConditionsLimited.
+func ParseFilterConditions(values url.Values) []FilterCondition {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var conditions []FilterCondition
+	for _, key := range keys {
+		matches := filterParamPattern.FindStringSubmatch(key)
+		if matches == nil {
+			continue
+		}
+
+		field, operator := matches[1], strings.ToUpper(matches[2])
+
+		// Repeated ranges of a field match any of them
+		if operator == "RANGE" {
+			conditions = append(conditions, FilterCondition{Field: field, Operator: operator, Value: values[key], Logic: "AND"})
+			continue
+		}
+
+		for _, value := range values[key] {
+			condition := FilterCondition{Field: field, Operator: operator, Value: value, Logic: "AND"}
+			if operator == "IN" || operator == "NOT_IN" {
+				condition.Value = strings.Split(value, ",")
+			}
+			conditions = append(conditions, condition)
+		}
+	}
+
+	return conditions
+}
+
+// NewDynamicFilterFromMap builds a filter on table from runtime parameters, such as
+// the selections of an admin UI, without a model struct. allowed maps each filterable
+// field to its operator; parameters for other fields are dropped. Values are always
+// bound as query arguments, and string values of in and not_in are split on commas.
+func NewDynamicFilterFromMap(table string, allowed map[string]string, params map[string]interface{}) *DynamicFilter {
+	fields := make([]string, 0, len(params))
+	for field := range params {
+		if _, ok := allowed[field]; ok {
+			fields = append(fields, field)
+		}
+	}
+	sort.Strings(fields)
+
+	conditions := make([]FilterCondition, 0, len(fields))
+	for _, field := range fields {
+		operator := strings.ToUpper(allowed[field])
+		value := params[field]
+		if text, ok := value.(string); ok && (operator == "IN" || operator == "NOT_IN") {
+			value = strings.Split(text, ",")
+		}
+		conditions = append(conditions, FilterCondition{Field: field, Operator: operator, Value: value, Logic: "AND"})
+	}
+
+	allowedFields := make(map[string]string, len(allowed))
+	for field, operator := range allowed {
+		allowedFields[field] = operator
+	}
+
+	return &DynamicFilter{
+		Filters:       conditions,
+		TableName:     table,
+		allowedFields: allowedFields,
+	}
+}
+
+// ParseFilterConditionsLimited parses filter conditions like ParseFilterConditions,
+// failing with ErrTooManyFilterConditions when they exceed SetMaxFilterConditions
+func ParseFilterConditionsLimited(values url.Values) ([]FilterCondition, error) {
+	conditions := ParseFilterConditions(values)
+	if limit := getMaxFilterConditions(); len(conditions) > limit {
+		return nil, fmt.Errorf("%w: %d submitted, at most %d allowed", ErrTooManyFilterConditions, len(conditions), limit)
+	}
+	return conditions, nil
+}
+
+// BindPagination binds pagination and includes, then appends the field[operator]
+// filter parameters of the request to the filter's conditions. Requests with more
+// conditions than SetMaxFilterConditions allows keep none of them and fail binding
+// instead, with a QueryParamsError, even when an embedding filter overrides
+// ValidateFilters or ApplyFilters.
+func (d *DynamicFilter) BindPagination(ctx *gin.Context) {
+	d.BaseFilter.BindPagination(ctx)
+
+	conditions, err := ParseFilterConditionsLimited(ctx.Request.URL.Query())
+	d.bindErr = err
+	d.Filters = append(d.Filters, conditions...)
+}
+
+// bindError reports the error of binding the filter's query parameters, so response
+// helpers answer it with 400
+func (d *DynamicFilter) bindError() error {
+	return d.bindErr
+}
+
+// FilterHash returns a stable hash of the filter's conditions, preset, search and sort,
+// leaving out the page, for client cache keys that change whenever the query does.
+// Conditions are compared in their normalized SQL form, so equivalent filters hash
+// identically whatever their parameter order or operator alias; the order of
+// conditions only counts when they are combined with OR.
+func (d *DynamicFilter) FilterHash() string {
+	var conditions []string
+	ordered := false
+	for i, filter := range d.Filters {
+		if filter.Field == "" || (filter.Value == nil && filter.CompareField == "") {
+			continue
+		}
+		logic := "AND"
+		if i > 0 && strings.ToUpper(filter.Logic) == "OR" {
+			logic, ordered = "OR", true
+		}
+
+		if filter.CompareField != "" {
+			if condition, err := d.buildColumnComparison(filter); err == nil {
+				conditions = append(conditions, logic+" "+condition)
+			}
+			continue
+		}
+
+		condition := d.buildCondition(filter)
+		if strings.ToUpper(filter.Operator) == "RANGE" {
+			condition = filter.Field + " RANGE ?"
+		}
+		value := hashValue(d.conditionValue(filter))
+		conditions = append(conditions, logic+" "+condition+" "+value)
+	}
+	if !ordered {
+		sort.Strings(conditions)
+	}
+
+	pagination := d.Pagination.Normalized()
+	payload, _ := json.Marshal(struct {
+		Conditions []string `json:"conditions"`
+		Preset     string   `json:"preset"`
+		Search     string   `json:"search"`
+		Sort       string   `json:"sort"`
+		Order      string   `json:"order"`
+	}{conditions, d.Preset, strings.TrimSpace(pagination.Search), pagination.Sort, pagination.Order})
+
+	sum := sha256.Sum256(payload)
+	return hex.EncodeToString(sum[:])
+}
+
+// hashValue formats a condition value for FilterHash, sorting the values of a list
+func hashValue(value interface{}) string {
+	reflected := reflect.ValueOf(value)
+	if reflected.Kind() != reflect.Slice || reflected.Type().Elem().Kind() == reflect.Uint8 {
+		return fmt.Sprintf("%T:%v", value, value)
+	}
+
+	values := make([]string, reflected.Len())
+	for i := range values {
+		values[i] = fmt.Sprint(reflected.Index(i).Interface())
+	}
+	sort.Strings(values)
+	return fmt.Sprintf("%T:%v", value, values)
+}
@@ -1,12 +1,151 @@
 package pagination
 
 import (
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
 	"gorm.io/gorm"
 )
 
+// QueryParamsError reports query parameters that could not be bound to a filter, that
+// failed its ValidateFilters or that request a sort a strict-sort builder rejects.
+// Response helpers answer it with 400 rather than 500.
+type QueryParamsError struct {
+	Err error
+}
+
+func (e *QueryParamsError) Error() string {
+	return "invalid query parameters: " + e.Err.Error()
+}
+
+func (e *QueryParamsError) Unwrap() error {
+	return e.Err
+}
+
+// FilterValidator is implemented by filters that enforce invariants across their
+// fields, such as min_age <= max_age. Helpers call ValidateFilters after binding and
+// before querying.
+type FilterValidator interface {
+	ValidateFilters() error
+}
+
+// bindErrorProvider is implemented by filters that record binding errors of their own,
+// such as DynamicFilter exceeding SetMaxFilterConditions. It is unexported, so
+// embedding filters can't shadow it the way they override ValidateFilters.
+type bindErrorProvider interface {
+	bindError() error
+}
+
+// bindFilterQuery binds the query parameters, then the URI path parameters of fields
+// tagged uri:"name", to the filter and validates them, reporting failures as a
+// QueryParamsError. Path parameters are bound last so the query can't override them.
+func bindFilterQuery(ctx *gin.Context, filter interface{}) error {
+	if err := binding.Query.Bind(withBoolTokens(ctx.Request, filter), filter); err != nil {
+		return &QueryParamsError{Err: err}
+	}
+
+	if len(ctx.Params) > 0 {
+		if err := ctx.ShouldBindUri(filter); err != nil {
+			return &QueryParamsError{Err: err}
+		}
+	}
+
+	if provider, ok := filter.(bindErrorProvider); ok {
+		if err := provider.bindError(); err != nil {
+			return &QueryParamsError{Err: err}
+		}
+	}
+
+	if validator, ok := filter.(FilterValidator); ok {
+		if err := validator.ValidateFilters(); err != nil {
+			return &QueryParamsError{Err: err}
+		}
+	}
+	return nil
+}
+
+// withBoolTokens returns the request with the boolean tokens in the query parameters
+// of the filter's bool fields rewritten to true or false, so they bind whatever
+// tokens are registered
+func withBoolTokens(request *http.Request, filter interface{}) *http.Request {
+	query := request.URL.Query()
+	rewritten := false
+	for _, key := range boolFormKeys(reflect.TypeOf(filter)) {
+		for i, value := range query[key] {
+			if parsed, ok := ParseBoolToken(value); ok {
+				query[key][i] = strconv.FormatBool(parsed)
+				rewritten = true
+			}
+		}
+	}
+	if !rewritten {
+		return request
+	}
+
+	request = request.Clone(request.Context())
+	request.URL.RawQuery = query.Encode()
+	return request
+}
+
+// boolFormKeys returns the form keys of a struct's bool and *bool fields, including
+// those of its embedded structs
+func boolFormKeys(structType reflect.Type) []string {
+	for structType.Kind() == reflect.Ptr {
+		structType = structType.Elem()
+	}
+	if structType.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var keys []string
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		tag := field.Tag.Get("form")
+		if tag == "-" {
+			continue
+		}
+		if field.Anonymous && tag == "" {
+			keys = append(keys, boolFormKeys(field.Type)...)
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+
+		fieldType := field.Type
+		if fieldType.Kind() == reflect.Ptr {
+			fieldType = fieldType.Elem()
+		}
+		if fieldType.Kind() != reflect.Bool {
+			continue
+		}
+		if name, _, _ := strings.Cut(tag, ","); name != "" {
+			keys = append(keys, name)
+		} else {
+			keys = append(keys, field.Name)
+		}
+	}
+	return keys
+}
+
+// errorResponse builds the response for a failed pagination: 400 for invalid query
+// parameters, 500 for everything else
+func errorResponse(err error) PaginatedResponse {
+	var paramsErr *QueryParamsError
+	if errors.As(err, &paramsErr) {
+		return NewPaginatedResponse(400, "Invalid query parameters: "+paramsErr.Err.Error(), nil, PaginationResponse{})
+	}
+	return NewPaginatedResponse(500, "Internal Server Error: "+err.Error(), nil, PaginationResponse{})
+}
+
 // PaginateWithCustomFilter provides pagination using custom filter that implements Filterable interface
 func PaginateWithCustomFilter[T any](
 	db *gorm.DB,
@@ -19,17 +158,11 @@ func PaginateWithCustomFilter[T any](
 	}
 
 	// Bind custom filter parameters
-	if err := ctx.ShouldBindQuery(filter); err != nil {
-		return nil, PaginationResponse{}, err
-	}
-
-	data, total, err := PaginatedQuery[T](db, filter, filter.GetPagination(), filter.GetIncludes())
-	if err != nil {
+	if err := bindFilterQuery(ctx, filter); err != nil {
 		return nil, PaginationResponse{}, err
 	}
 
-	paginationResponse := CalculatePagination(filter.GetPagination(), total)
-	return data, paginationResponse, nil
+	return PaginateCore[T](db, filter, filter.GetPagination())
 }
 
 // PaginatedAPIResponseWithCustomFilter creates a complete API response using custom filter
@@ -42,10 +175,26 @@ func PaginatedAPIResponseWithCustomFilter[T any](
 	data, paginationResponse, err := PaginateWithCustomFilter[T](db, ctx, filter)
 
 	if err != nil {
-		return NewPaginatedResponse(500, "Internal Server Error: "+err.Error(), nil, PaginationResponse{})
+		return errorResponse(err)
 	}
 
-	return NewPaginatedResponse(200, message, data, paginationResponse)
+	return NewPaginatedResponse(200, message, emptyIfNil(data), paginationResponse)
+}
+
+// RespondPaginated paginates with a custom filter and writes the response to ctx with
+// its status code, reducing a handler to a single call: 400 for invalid query
+// parameters, 500 for query errors
+func RespondPaginated[T any](ctx *gin.Context, db *gorm.DB, filter Filterable, message string) {
+	response := PaginatedAPIResponseWithCustomFilter[T](db, ctx, filter, message)
+	ctx.JSON(response.Code, response)
+}
+
+// emptyIfNil ensures an empty page serializes as [] rather than null
+func emptyIfNil[T any](data []T) []T {
+	if data == nil {
+		return []T{}
+	}
+	return data
 }
 
 // CreateSearchableFilter creates a default search implementation for custom filters
@@ -78,6 +227,152 @@ func CreateSearchableFilter(searchFields []string, dialect DatabaseDialect) func
 	}
 }
 
+// CaseInsensitiveEq adds a WHERE condition matching column to value regardless of case:
+// LOWER(column) = LOWER(?), or on PostgreSQL an ILIKE with the value's wildcards escaped.
+// Invalid column names leave the query unchanged.
+func CaseInsensitiveEq(query *gorm.DB, column string, value string, dialect DatabaseDialect) *gorm.DB {
+	if !isValidIdentifier(column) {
+		return query
+	}
+
+	if dialect == PostgreSQL {
+		return query.Where(column+" ILIKE ? ESCAPE '"+likeEscapeChar+"'", escapeLike(value))
+	}
+	return query.Where("LOWER("+column+") = LOWER(?)", value)
+}
+
+// ApplyNullableIntFilter filters a nullable integer column, typically an optional
+// foreign key: a non-nil value matches column = value, otherwise isNull selects
+// column IS NULL (true) or column IS NOT NULL (false). With both nil the query is
+// unchanged, as it is for invalid column names.
+func ApplyNullableIntFilter(query *gorm.DB, column string, value *int, isNull *bool) *gorm.DB {
+	if !isValidIdentifier(column) {
+		return query
+	}
+
+	switch {
+	case value != nil:
+		return query.Where(column+" = ?", *value)
+	case isNull == nil:
+		return query
+	case *isNull:
+		return query.Where(column + " IS NULL")
+	default:
+		return query.Where(column + " IS NOT NULL")
+	}
+}
+
+// ApplyDateRange filters column to the range between from and to, with bounds giving
+// their inclusivity in interval notation: "[]" (the default when empty), "[)", "(]"
+// or "()". Use "[)" to bucket by day without counting boundary days twice. A zero
+// from or to leaves that side open; invalid bounds fail the query with ErrInvalidRange,
+// in a QueryParamsError as bounds typically come from the request.
+func ApplyDateRange(query *gorm.DB, column string, from time.Time, to time.Time, bounds string) *gorm.DB {
+	if !isValidIdentifier(column) {
+		return query
+	}
+
+	lowerInclusive, upperInclusive, err := parseRangeBounds(bounds)
+	if err != nil {
+		query.AddError(&QueryParamsError{Err: err})
+		return query
+	}
+
+	if !from.IsZero() {
+		operator := " > ?"
+		if lowerInclusive {
+			operator = " >= ?"
+		}
+		query = query.Where(column+operator, from)
+	}
+	if !to.IsZero() {
+		operator := " < ?"
+		if upperInclusive {
+			operator = " <= ?"
+		}
+		query = query.Where(column+operator, to)
+	}
+	return query
+}
+
+// parseRangeBounds parses interval notation bounds such as "[)" into the inclusivity of each end
+func parseRangeBounds(bounds string) (bool, bool, error) {
+	if bounds == "" {
+		return true, true, nil
+	}
+	if len(bounds) != 2 || !strings.ContainsRune("[(", rune(bounds[0])) || !strings.ContainsRune("])", rune(bounds[1])) {
+		return false, false, fmt.Errorf("%w: bounds %q", ErrInvalidRange, bounds)
+	}
+	return bounds[0] == '[', bounds[1] == ']', nil
+}
+
+// PaginateCore runs the paginated query and computes its pagination metadata without
+// any Gin binding or response envelope. Includes are taken from the builder when it
+// provides GetIncludes.
+func PaginateCore[T any](
+	db *gorm.DB,
+	builder QueryBuilder,
+	pagination PaginationRequest,
+) ([]T, PaginationResponse, error) {
+	includes := []string{}
+	if includable, ok := builder.(interface{ GetIncludes() []string }); ok {
+		includes = includable.GetIncludes()
+	}
+
+	page, err := paginatedQuery[T](db, builder, pagination, includes, PaginatedQueryOptions{
+		Dialect: MySQL, // Default to MySQL for backward compatibility
+	})
+	if err != nil {
+		return nil, PaginationResponse{}, err
+	}
+	data := page.Data
+
+	paginationResponse := servedPagination(pagination, page.Page, page.Total)
+	pagination.Page = page.Page
+	paginationResponse.SkippedIncludes = SkippedIncludes(builder, pagination, includes)
+	paginationResponse.Warnings = page.Warnings
+	paginationResponse.ApproximateTotal = page.CountSource == CountSourceEstimated
+	if provider, ok := builder.(CountSourceProvider); ok && provider.GetCountSourceEnabled() {
+		paginationResponse.CountSource = page.CountSource
+	}
+	if provider, ok := builder.(EffectiveSearchProvider); ok && provider.GetEffectiveSearchEnabled() {
+		effectiveSearch := EffectiveSearch(builder, pagination)
+		paginationResponse.EffectiveSearch = &effectiveSearch
+	}
+	if provider, ok := builder.(OffsetLimitProvider); ok && provider.GetOffsetLimitEnabled() && !pagination.IsDisabled {
+		offset, limit := pagination.GetOffset(), pagination.GetLimit()
+		paginationResponse.Offset, paginationResponse.Limit = &offset, &limit
+	}
+
+	paginationResponse.QueryPlan = queryPlan[T](db, builder, pagination, PaginatedQueryOptions{Dialect: MySQL})
+	if paginationResponse.Aggregates, err = Aggregates(db, builder, pagination); err != nil {
+		return nil, PaginationResponse{}, err
+	}
+	if paginationResponse.DatasetVersion, err = DatasetVersion(db, builder, pagination); err != nil {
+		return nil, PaginationResponse{}, err
+	}
+
+	if provider, ok := builder.(NextCursorProvider); ok && provider.GetNextCursor() && paginationResponse.Remaining > 0 {
+		if paginationResponse.NextCursor, err = CursorFromPage(db, builder, pagination, data); err != nil {
+			return nil, PaginationResponse{}, err
+		}
+	}
+	return data, paginationResponse, nil
+}
+
+// servedPagination calculates the pagination metadata of the page served out of
+// totalCount records, carrying the requested page when it was clamped
+func servedPagination(pagination PaginationRequest, servedPage int, totalCount int64) PaginationResponse {
+	requestedPage := pagination.Normalized().Page
+	pagination.Page = servedPage
+
+	paginationResponse := CalculatePagination(pagination, totalCount)
+	if servedPage != requestedPage {
+		paginationResponse.RequestedPage = requestedPage
+	}
+	return paginationResponse
+}
+
 // PaginateModel provides a simple way to paginate any GORM model
 func PaginateModel[T any](
 	db *gorm.DB,
@@ -90,13 +385,7 @@ func PaginateModel[T any](
 	builder := NewSimpleQueryBuilder(tableName).
 		WithSearchFields(searchFields...)
 
-	data, total, err := PaginatedQuery[T](db, builder, pagination, []string{})
-	if err != nil {
-		return nil, PaginationResponse{}, err
-	}
-
-	paginationResponse := CalculatePagination(pagination, total)
-	return data, paginationResponse, nil
+	return PaginateCore[T](db, builder, pagination)
 }
 
 // PaginateWithIncludes provides pagination with preloaded relationships
@@ -117,7 +406,7 @@ func PaginateWithIncludes[T any](
 		return nil, PaginationResponse{}, err
 	}
 
-	paginationResponse := CalculatePagination(pagination, total)
+	paginationResponse := servedPagination(pagination, EffectivePage(builder, pagination, total), total)
 	return data, paginationResponse, nil
 }
 
@@ -135,13 +424,7 @@ func PaginateWithFilter[T any](
 		WithSearchFields(searchFields...).
 		WithFilters(filterFunc)
 
-	data, total, err := PaginatedQuery[T](db, builder, pagination, []string{})
-	if err != nil {
-		return nil, PaginationResponse{}, err
-	}
-
-	paginationResponse := CalculatePagination(pagination, total)
-	return data, paginationResponse, nil
+	return PaginateCore[T](db, builder, pagination)
 }
 
 // QuickPaginate provides the simplest way to paginate with minimal configuration
@@ -154,13 +437,7 @@ func QuickPaginate[T any](
 
 	builder := NewSimpleQueryBuilder(tableName)
 
-	data, total, err := PaginatedQuery[T](db, builder, pagination, []string{})
-	if err != nil {
-		return nil, PaginationResponse{}, err
-	}
-
-	paginationResponse := CalculatePagination(pagination, total)
-	return data, paginationResponse, nil
+	return PaginateCore[T](db, builder, pagination)
 }
 
 // PaginatedAPIResponse creates a complete API response with pagination
@@ -174,10 +451,10 @@ func PaginatedAPIResponse[T any](
 	data, paginationResponse, err := PaginateModel[T](db, ctx, tableName, searchFields)
 
 	if err != nil {
-		return NewPaginatedResponse(500, "Internal Server Error: "+err.Error(), nil, PaginationResponse{})
+		return errorResponse(err)
 	}
 
-	return NewPaginatedResponse(200, message, data, paginationResponse)
+	return NewPaginatedResponse(200, message, emptyIfNil(data), paginationResponse)
 }
 
 // PaginatedAPIResponseWithIncludes creates a complete API response with pagination and includes
@@ -192,10 +469,10 @@ func PaginatedAPIResponseWithIncludes[T any](
 	data, paginationResponse, err := PaginateWithIncludes[T](db, ctx, tableName, searchFields, includes)
 
 	if err != nil {
-		return NewPaginatedResponse(500, "Internal Server Error: "+err.Error(), nil, PaginationResponse{})
+		return errorResponse(err)
 	}
 
-	return NewPaginatedResponse(200, message, data, paginationResponse)
+	return NewPaginatedResponse(200, message, emptyIfNil(data), paginationResponse)
 }
 
 // PaginatedQueryWithQueryLayer provides pagination using query layer pattern
@@ -226,21 +503,22 @@ func PaginatedAPIResponseWithQueryLayer[T any](
 	}
 
 	// Bind custom filter parameters
-	if err := ctx.ShouldBindQuery(filter); err != nil {
-		return NewPaginatedResponse(400, "Invalid query parameters: "+err.Error(), nil, PaginationResponse{})
+	if err := bindFilterQuery(ctx, filter); err != nil {
+		return errorResponse(err)
 	}
 
 	// Execute query through query layer
 	data, total, err := PaginatedQueryWithQueryLayer(filter, queryFunc)
 	if err != nil {
-		return NewPaginatedResponse(500, "Internal Server Error: "+err.Error(), nil, PaginationResponse{})
+		return errorResponse(err)
 	}
 
-	paginationResponse := CalculatePagination(filter.GetPagination(), total)
-	return NewPaginatedResponse(200, message, data, paginationResponse)
+	pagination := filter.GetPagination()
+	paginationResponse := servedPagination(pagination, EffectivePage(filter, pagination, total), total)
+	return NewPaginatedResponse(200, message, emptyIfNil(data), paginationResponse)
 }
 
-// BindAndValidateFilter binds pagination and query parameters, then validates the filter
+// BindAndValidateFilter binds pagination, query and URI path parameters, then validates the filter
 func BindAndValidateFilter(ctx *gin.Context, filter IncludableQueryBuilder) error {
 	// Bind pagination from context
 	if baseFilter, ok := filter.(interface{ BindPagination(*gin.Context) }); ok {
@@ -248,7 +526,7 @@ func BindAndValidateFilter(ctx *gin.Context, filter IncludableQueryBuilder) erro
 	}
 
 	// Bind custom filter parameters
-	if err := ctx.ShouldBindQuery(filter); err != nil {
+	if err := bindFilterQuery(ctx, filter); err != nil {
 		return err
 	}
 
@@ -259,3 +537,55 @@ func BindAndValidateFilter(ctx *gin.Context, filter IncludableQueryBuilder) erro
 
 	return nil
 }
+
+// FilterSchema is the normalized filter a request would be paginated with
+type FilterSchema struct {
+	Pagination PaginationRequest `json:"pagination"`
+	Sort       []SortField       `json:"sort"`
+	Includes   []string          `json:"includes"`
+}
+
+// ValidateOnly binds and validates the filter like BindAndValidateFilter without
+// querying, so clients can check their parameters before an expensive export. Unlike
+// pagination, which drops disallowed includes and falls back from invalid sorts, every
+// disallowed include, invalid sort and failed cross-field check is reported, joined in a
+// QueryParamsError. Otherwise the normalized pagination, effective sort and includes are
+// returned.
+func ValidateOnly(ctx *gin.Context, filter IncludableQueryBuilder) (FilterSchema, error) {
+	if baseFilter, ok := filter.(interface{ BindPagination(*gin.Context) }); ok {
+		baseFilter.BindPagination(ctx)
+	}
+
+	var problems []error
+	if err := bindFilterQuery(ctx, filter); err != nil {
+		var paramsErr *QueryParamsError
+		if errors.As(err, &paramsErr) {
+			err = paramsErr.Err
+		}
+		problems = append(problems, err)
+	}
+
+	requested := filter.GetIncludes()
+	allowed := validateIncludes(filter, requested)
+	for _, include := range requested {
+		if !slices.Contains(allowed, include) {
+			problems = append(problems, fmt.Errorf("include %q is not allowed", include))
+		}
+	}
+
+	pagination := filter.GetPagination().Normalized()
+	_, invalidSorts := partitionSorts(filter, pagination)
+	for _, field := range invalidSorts {
+		problems = append(problems, fmt.Errorf("sort %q is not allowed", field.Field))
+	}
+
+	if len(problems) > 0 {
+		return FilterSchema{}, &QueryParamsError{Err: errors.Join(problems...)}
+	}
+
+	return FilterSchema{
+		Pagination: pagination,
+		Sort:       EffectiveSort(filter, pagination),
+		Includes:   emptyIfNil(allowed),
+	}, nil
+}
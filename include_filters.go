@@ -0,0 +1,86 @@
+package pagination
+
+import (
+	"net/url"
+	"sort"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// IncludeFilter restricts the rows loaded for an include to those whose field equals
+// the value, or any of the values of a []string
+type IncludeFilter struct {
+	Include string
+	Field   string
+	Value   interface{}
+}
+
+// IncludeFiltersProvider interface for query builders that filter the rows of their includes.
+// Filters only apply to requested includes, on fields allowed for that include.
+type IncludeFiltersProvider interface {
+	GetIncludeFilters() []IncludeFilter
+	GetIncludeFilterFields() map[string]map[string]bool
+}
+
+// ParseIncludeFilters parses query parameters of the form Include.field=value, such as
+// ?includes=Athletes&Athletes.gender=Male, into include filters. The include is the part
+// before the last dot, so nested includes like Athletes.Province.code work as well, and
+// repeated parameters match any of their values. Filters are validated later against
+// the builder's allowed include filter fields.
+func ParseIncludeFilters(values url.Values) []IncludeFilter {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var filters []IncludeFilter
+	for _, key := range keys {
+		dot := strings.LastIndex(key, ".")
+		if dot <= 0 || dot == len(key)-1 || len(values[key]) == 0 {
+			continue
+		}
+
+		filter := IncludeFilter{Include: key[:dot], Field: key[dot+1:], Value: values[key][0]}
+		if len(values[key]) > 1 {
+			filter.Value = values[key]
+		}
+		filters = append(filters, filter)
+	}
+
+	return filters
+}
+
+// includeFilterConditions returns the builder's valid include filters by include
+func includeFilterConditions(builder interface{}) map[string][]IncludeFilter {
+	provider, ok := builder.(IncludeFiltersProvider)
+	if !ok {
+		return nil
+	}
+
+	allowed := provider.GetIncludeFilterFields()
+	conditions := map[string][]IncludeFilter{}
+	for _, filter := range provider.GetIncludeFilters() {
+		// The field is interpolated into the preload query, so it must be allowlisted
+		if !allowed[filter.Include][filter.Field] || !isValidInclude(filter.Include) || !isValidIdentifier(filter.Field) {
+			continue
+		}
+		conditions[filter.Include] = append(conditions[filter.Include], filter)
+	}
+	return conditions
+}
+
+// preloadConditions returns the preload function applying the include's filters
+func preloadConditions(filters []IncludeFilter) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		for _, filter := range filters {
+			if values, ok := filter.Value.([]string); ok {
+				db = db.Where(filter.Field+" IN ?", values)
+			} else {
+				db = db.Where(filter.Field+" = ?", filter.Value)
+			}
+		}
+		return db
+	}
+}
@@ -0,0 +1,253 @@
+package pagination
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// CursorPaginationRequest requests the page after, or with a previous cursor before, a
+// cursor. An empty cursor starts from the first page. An empty Order keeps the
+// directions of the builder's default sort.
+type CursorPaginationRequest struct {
+	Cursor  string `json:"cursor" form:"cursor"`
+	PerPage int    `json:"per_page" form:"per_page"`
+	Order   string `json:"order" form:"order"`
+}
+
+// CursorPaginationResponse is the metadata of a cursor page. The cursors are empty when
+// there is no page in their direction.
+type CursorPaginationResponse struct {
+	PerPage    int    `json:"per_page"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	PrevCursor string `json:"prev_cursor,omitempty"`
+	HasNext    bool   `json:"has_next"`
+	HasPrev    bool   `json:"has_prev"`
+}
+
+// Normalized returns the request with a valid page size and order, clearing an order
+// other than "asc" or "desc" rather than defaulting it, so no order stays distinct
+func (p CursorPaginationRequest) Normalized() CursorPaginationRequest {
+	if p.PerPage <= 0 {
+		p.PerPage = DefaultPerPage
+	}
+	p.PerPage = min(p.PerPage, MaxPerPage)
+
+	if p.Order != "asc" && p.Order != "desc" {
+		p.Order = ""
+	}
+	return p
+}
+
+// BindCursorPagination binds the cursor, per_page and order query parameters
+func BindCursorPagination(ctx *gin.Context) CursorPaginationRequest {
+	request := CursorPaginationRequest{Cursor: ctx.Query("cursor"), Order: strings.ToLower(ctx.Query("order"))}
+	request.PerPage, _ = strconv.Atoi(ctx.Query("per_page"))
+	return request.Normalized()
+}
+
+// PaginatedQueryByCursor paginates with a keyset condition such as WHERE id > ? instead
+// of an OFFSET, so deep pages cost as little as the first. Rows are ordered by the
+// builder's default sort, with its columns in the direction of the request's order
+// when one is given, followed by the columns of the primary key it doesn't include;
+// without a default sort they are ordered by the primary key. The next cursor holds the sort values of the page's last row and the
+// previous cursor those of its first. No total is counted: one row past the page is
+// fetched to tell whether another page follows.
+func PaginatedQueryByCursor[T any](db *gorm.DB, builder QueryBuilder, request CursorPaginationRequest) ([]T, CursorPaginationResponse, error) {
+	request = request.Normalized()
+
+	modelSchema, err := parseModelSchema[T](db)
+	if err != nil {
+		return nil, CursorPaginationResponse{}, err
+	}
+
+	// Like an offset page's order parameter, the order sets the default sort's direction
+	sort := EffectiveSort(builder, PaginationRequest{})
+	if request.Order != "" {
+		sort = sortWithDirection(sort, request.Order)
+	}
+	if len(sort) == 0 {
+		direction := request.Order
+		if direction == "" {
+			direction = "asc"
+		}
+		for _, primaryKey := range modelSchema.PrimaryFields {
+			sort = append(sort, SortField{Field: primaryKey.DBName, Direction: direction})
+		}
+	}
+	if len(sort) == 0 {
+		return nil, CursorPaginationResponse{}, fmt.Errorf("%s has no sort or primary key to paginate by cursor", modelSchema.Name)
+	}
+	keys := cursorKeys(sort, modelSchema)
+
+	// A previous cursor is a cursor for the reversed sort, positioned at a page's first row
+	var boundary []CursorField
+	backward := false
+	if request.Cursor != "" {
+		boundary, err = DecodeCursor(request.Cursor, sort)
+		if errors.Is(err, ErrCursorSortMismatch) {
+			boundary, err = DecodeCursor(request.Cursor, reverseSort(sort))
+			backward = err == nil
+		}
+		if err != nil {
+			return nil, CursorPaginationResponse{}, err
+		}
+	}
+	queryKeys := keys
+	if backward {
+		queryKeys = reverseCursorKeys(keys)
+	}
+	if boundary != nil && !matchesCursorKeys(boundary, queryKeys) {
+		return nil, CursorPaginationResponse{}, ErrCursorSortMismatch
+	}
+
+	queryDB, cancel := withQueryTimeout(db, builder)
+	defer cancel()
+
+	query := applyBaseQuery(queryDB.Table(builder.GetTableName()), builder, PaginationRequest{}, PaginatedQueryOptions{Dialect: MySQL}, allScopes)
+	if boundary != nil {
+		condition, args, err := CursorCondition(boundary)
+		if err != nil {
+			return nil, CursorPaginationResponse{}, err
+		}
+		query = query.Where(condition, args...)
+	}
+	if includable, ok := builder.(interface{ GetIncludes() []string }); ok {
+		for _, include := range validateIncludes(builder, includable.GetIncludes()) {
+			query = query.Preload(include)
+		}
+	}
+
+	result, more, err := findWithLookahead[T](query.Order(CursorOrder(queryKeys)), request.PerPage)
+	if err != nil {
+		return nil, CursorPaginationResponse{}, queryError(queryDB, "failed to fetch records", err)
+	}
+	if backward {
+		slices.Reverse(result)
+	}
+
+	response := CursorPaginationResponse{PerPage: request.PerPage}
+	if len(result) == 0 {
+		return []T{}, response, nil
+	}
+
+	timeLayout := ""
+	if provider, ok := builder.(CursorTimeLayoutProvider); ok {
+		timeLayout = provider.GetCursorTimeLayout()
+	}
+
+	// Backward, the page was reached from the one after it; forward, from the one before
+	if more || backward {
+		last := reflect.ValueOf(&result[len(result)-1]).Elem()
+		if response.NextCursor, err = encodeRowCursor(keys, modelSchema, last, timeLayout); err != nil {
+			return nil, CursorPaginationResponse{}, err
+		}
+	}
+	if (more && backward) || (!backward && boundary != nil) {
+		first := reflect.ValueOf(&result[0]).Elem()
+		if response.PrevCursor, err = encodeRowCursor(reverseCursorKeys(keys), modelSchema, first, timeLayout); err != nil {
+			return nil, CursorPaginationResponse{}, err
+		}
+	}
+	response.HasNext, response.HasPrev = response.NextCursor != "", response.PrevCursor != ""
+
+	return result, response, nil
+}
+
+// cursorKeys returns the cursor fields of a sort without their values: the sort's
+// columns followed by those of the primary key it doesn't include, as a tiebreaker in
+// the direction of its last column
+func cursorKeys(sort []SortField, modelSchema *schema.Schema) []CursorField {
+	keys := make([]CursorField, 0, len(sort)+len(modelSchema.PrimaryFields))
+	for _, field := range sort {
+		keys = append(keys, CursorField{Field: field.Field, Direction: field.Direction})
+	}
+
+	sorted := sortColumns(sort)
+	for _, primaryKey := range modelSchema.PrimaryFields {
+		if !sorted[primaryKey.DBName] {
+			keys = append(keys, CursorField{Field: primaryKey.DBName, Direction: sort[len(sort)-1].Direction, Tiebreaker: true})
+		}
+	}
+	return keys
+}
+
+// encodeRowCursor encodes the cursor positioned at a row for the given keys
+func encodeRowCursor(keys []CursorField, modelSchema *schema.Schema, row reflect.Value, timeLayout string) (string, error) {
+	fields := make([]CursorField, len(keys))
+	for i, key := range keys {
+		segments, _ := splitIdentifier(key.Field)
+		column, _ := unquoteIdentifier(segments[len(segments)-1])
+
+		field := modelSchema.LookUpField(column)
+		if field == nil {
+			return "", fmt.Errorf("sort column %q not found on %s", key.Field, modelSchema.Name)
+		}
+
+		value, _ := field.ValueOf(context.Background(), row)
+		fields[i] = cursorField(SortField{Field: key.Field, Direction: key.Direction}, value, timeLayout)
+		fields[i].Tiebreaker = key.Tiebreaker
+	}
+	return EncodeCursor(fields)
+}
+
+// matchesCursorKeys reports whether a decoded cursor holds exactly the given keys
+func matchesCursorKeys(fields []CursorField, keys []CursorField) bool {
+	if len(fields) != len(keys) {
+		return false
+	}
+	for i, field := range fields {
+		if field.Field != keys[i].Field || !strings.EqualFold(field.Direction, keys[i].Direction) || field.Tiebreaker != keys[i].Tiebreaker {
+			return false
+		}
+	}
+	return true
+}
+
+// sortWithDirection returns the sort with every column in direction, or the sort as is
+// when it isn't a plain column list
+func sortWithDirection(sort []SortField, direction string) []SortField {
+	directed := make([]SortField, len(sort))
+	for i, field := range sort {
+		if !isValidIdentifier(field.Field) {
+			return sort
+		}
+		directed[i] = SortField{Field: field.Field, Direction: direction}
+	}
+	return directed
+}
+
+// reverseSort returns the sort with every direction flipped
+func reverseSort(sort []SortField) []SortField {
+	reversed := make([]SortField, len(sort))
+	for i, field := range sort {
+		reversed[i] = SortField{Field: field.Field, Direction: oppositeDirection(field.Direction)}
+	}
+	return reversed
+}
+
+// reverseCursorKeys returns the cursor keys with every direction flipped
+func reverseCursorKeys(keys []CursorField) []CursorField {
+	reversed := make([]CursorField, len(keys))
+	for i, key := range keys {
+		key.Direction = oppositeDirection(key.Direction)
+		reversed[i] = key
+	}
+	return reversed
+}
+
+// oppositeDirection returns "desc" for "asc" and "asc" for "desc"
+func oppositeDirection(direction string) string {
+	if strings.EqualFold(direction, "desc") {
+		return "asc"
+	}
+	return "desc"
+}
@@ -0,0 +1,72 @@
+package pagination
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// QueryMetrics describes a completed paginated query, as reported to the metrics hook
+type QueryMetrics struct {
+	Table       string
+	Duration    time.Duration
+	Total       int64
+	Rows        int
+	CountSource CountSource
+	Err         error
+	// TraceID is the trace of the query's context, as returned by the trace ID
+	// extractor, so a metric exemplar can link to the trace of a slow query
+	TraceID string
+}
+
+var (
+	metricsMu        sync.RWMutex
+	metricsHook      func(QueryMetrics)
+	traceIDExtractor func(ctx context.Context) string
+)
+
+// SetMetricsHook sets the function receiving the metrics of every paginated query, such
+// as one observing a Prometheus histogram. It runs synchronously after the query, so it
+// should be quick; nil removes it.
+func SetMetricsHook(hook func(QueryMetrics)) {
+	metricsMu.Lock()
+	defer metricsMu.Unlock()
+
+	metricsHook = hook
+}
+
+// SetTraceIDExtractor sets the function returning the trace ID of a query's context,
+// e.g. from the OpenTelemetry span in it, reported in QueryMetrics.TraceID. Pass the
+// context with db.WithContext; nil removes it.
+func SetTraceIDExtractor(extract func(ctx context.Context) string) {
+	metricsMu.Lock()
+	defer metricsMu.Unlock()
+
+	traceIDExtractor = extract
+}
+
+// reportQueryMetrics passes the metrics of a paginated query to the metrics hook, if any
+func reportQueryMetrics(db *gorm.DB, builder QueryBuilder, start time.Time, total int64, rows int, source CountSource, err error) {
+	metricsMu.RLock()
+	hook, extract := metricsHook, traceIDExtractor
+	metricsMu.RUnlock()
+
+	if hook == nil {
+		return
+	}
+
+	metrics := QueryMetrics{
+		Table:       builder.GetTableName(),
+		Duration:    time.Since(start),
+		Total:       total,
+		Rows:        rows,
+		CountSource: source,
+		Err:         err,
+	}
+	if ctx := db.Statement.Context; extract != nil && ctx != nil {
+		metrics.TraceID = extract(ctx)
+	}
+	hook(metrics)
+}
@@ -0,0 +1,34 @@
+package pagination
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// NDJSONContentType is the content type of newline-delimited JSON responses
+const NDJSONContentType = "application/x-ndjson"
+
+// WritePaginatedNDJSON writes a page as newline-delimited JSON for clients parsing it
+// incrementally: each item as a JSON object on its own line, followed by a final
+// {"pagination": ...} line carrying the metadata.
+func WritePaginatedNDJSON[T any](ctx *gin.Context, items []T, pagination PaginationResponse) error {
+	ctx.Header("Content-Type", NDJSONContentType)
+	ctx.Status(http.StatusOK)
+
+	encoder := json.NewEncoder(ctx.Writer)
+	for _, item := range items {
+		if err := encoder.Encode(item); err != nil {
+			return fmt.Errorf("failed to write item: %w", err)
+		}
+	}
+
+	if err := encoder.Encode(struct {
+		Pagination PaginationResponse `json:"pagination"`
+	}{pagination}); err != nil {
+		return fmt.Errorf("failed to write pagination: %w", err)
+	}
+	return nil
+}
@@ -2,112 +2,394 @@ package pagination
 
 import (
 	"math"
+	"reflect"
 	"strconv"
 	"strings"
+	"sync"
 
 	"github.com/gin-gonic/gin"
 )
 
+const (
+	// DefaultPerPage is the page size used when per_page is missing, zero, negative or invalid
+	DefaultPerPage = 10
+	// MaxPerPage is the largest per_page accepted by BindPagination
+	MaxPerPage = 100
+)
+
+// PaginationRequest holds the requested page. A PerPage of zero or less always means
+// DefaultPerPage, whether it comes from BindPagination, Validate, Normalized or GetLimit;
+// use MetaOnly to request metadata without items. A multi-column sort such as
+// sort=age:desc,name:asc is kept as given in Sort and parsed into Sorts.
 type PaginationRequest struct {
-	Page       int    `json:"page" form:"page"`
-	PerPage    int    `json:"per_page" form:"per_page"`
-	Search     string `json:"search" form:"search"`
-	Sort       string `json:"sort" form:"sort"`
-	Order      string `json:"order" form:"order"`
-	IsDisabled bool   `json:"is_disabled,omitempty" form:"is_disabled"`
+	Page       int         `json:"page" form:"page"`
+	PerPage    int         `json:"per_page" form:"per_page"`
+	Search     string      `json:"search" form:"search"`
+	Sort       string      `json:"sort" form:"sort"`
+	Order      string      `json:"order" form:"order"`
+	Sorts      []SortField `json:"sorts,omitempty" form:"-"`
+	IsDisabled bool        `json:"is_disabled,omitempty" form:"is_disabled"`
+	MetaOnly   bool        `json:"meta_only,omitempty" form:"meta_only"`
+
+	// orderSent is set when Order was bound from an order parameter the client sent,
+	// rather than defaulted or set in code
+	orderSent bool
+}
+
+// SortField is a single column of a sort, with its "asc" or "desc" direction
+type SortField struct {
+	Field     string `json:"field"`
+	Direction string `json:"direction"`
+}
+
+// ParseSortClause parses an ORDER BY style clause such as "score desc, id asc" into
+// sort fields, defaulting a missing direction to "asc"
+func ParseSortClause(clause string) []SortField {
+	var fields []SortField
+	for _, part := range strings.Split(clause, ",") {
+		tokens := strings.Fields(part)
+		if len(tokens) == 0 {
+			continue
+		}
+
+		direction := "asc"
+		if len(tokens) > 1 && strings.EqualFold(tokens[len(tokens)-1], "desc") {
+			direction = "desc"
+		}
+		fields = append(fields, SortField{Field: tokens[0], Direction: direction})
+	}
+	return fields
 }
 
+// ParseSortParam parses a sort query parameter such as "age:desc,name:asc" into sort
+// fields. A bare direction after a column, as in "name,desc", applies to that column.
+// Columns without a valid direction take defaultDirection, "asc" unless it is "desc";
+// repeated columns keep their first direction.
+func ParseSortParam(value string, defaultDirection string) []SortField {
+	if !strings.EqualFold(defaultDirection, "desc") {
+		defaultDirection = "asc"
+	}
+	defaultDirection = strings.ToLower(defaultDirection)
+
+	var fields []SortField
+	seen := map[string]bool{}
+	explicit := false
+	for _, part := range strings.Split(value, ",") {
+		field, direction, hasDirection := strings.Cut(strings.TrimSpace(part), ":")
+		field = strings.TrimSpace(field)
+		direction = strings.ToLower(strings.TrimSpace(direction))
+
+		if bare := strings.ToLower(field); !hasDirection && (bare == "asc" || bare == "desc") {
+			if len(fields) > 0 && !explicit {
+				fields[len(fields)-1].Direction, explicit = bare, true
+			}
+			continue
+		}
+		if field == "" || seen[field] {
+			// A bare direction after a skipped column is skipped with it
+			explicit = true
+			continue
+		}
+		seen[field] = true
+
+		explicit = direction == "asc" || direction == "desc"
+		if !explicit {
+			direction = defaultDirection
+		}
+		fields = append(fields, SortField{Field: field, Direction: direction})
+	}
+	return fields
+}
+
+// isMultiSortParam reports whether a sort parameter uses the field:direction syntax or
+// lists several columns
+func isMultiSortParam(value string) bool {
+	return strings.ContainsAny(value, ",:")
+}
+
+// SortFields returns the requested sort columns in order: Sorts when set, otherwise
+// Sort parsed with Order as the default direction, or nil without a sort
+func (p PaginationRequest) SortFields() []SortField {
+	if len(p.Sorts) > 0 {
+		fields := make([]SortField, len(p.Sorts))
+		for i, field := range p.Sorts {
+			fields[i] = SortField{Field: field.Field, Direction: "asc"}
+			if strings.EqualFold(field.Direction, "desc") {
+				fields[i].Direction = "desc"
+			}
+		}
+		return fields
+	}
+	if p.Sort == "" {
+		return nil
+	}
+	if isMultiSortParam(p.Sort) {
+		return ParseSortParam(p.Sort, p.Order)
+	}
+
+	direction := "asc"
+	if strings.EqualFold(p.Order, "desc") {
+		direction = "desc"
+	}
+	return []SortField{{Field: p.Sort, Direction: direction}}
+}
+
+// withSortFields returns the request sorted by fields, clearing the sort when empty
+func (p PaginationRequest) withSortFields(fields []SortField) PaginationRequest {
+	p.Sorts = nil
+	switch len(fields) {
+	case 0:
+		p.Sort, p.Order = "", ""
+	case 1:
+		p.Sort, p.Order = fields[0].Field, fields[0].Direction
+	default:
+		parts := make([]string, len(fields))
+		for i, field := range fields {
+			parts[i] = field.Field + ":" + field.Direction
+		}
+		p.Sort, p.Sorts = strings.Join(parts, ","), fields
+	}
+	return p
+}
+
+// CountSource describes how a response's total was obtained
+type CountSource string
+
+const (
+	// CountSourceExact is a total counted by the database for the request
+	CountSourceExact CountSource = "exact"
+	// CountSourceCached is a total read from precomputed counts, which may be stale
+	CountSourceCached CountSource = "cached"
+	// CountSourceEstimated is an approximate total from a count estimator
+	CountSourceEstimated CountSource = "estimated"
+)
+
 type PaginationResponse struct {
-	Page       int   `json:"page"`
-	PerPage    int   `json:"per_page"`
-	MaxPage    int64 `json:"max_page"`
-	Total      int64 `json:"total"`
-	IsDisabled bool  `json:"is_disabled,omitempty"`
+	Page             int                    `json:"page"`
+	RequestedPage    int                    `json:"requested_page,omitempty"`
+	PerPage          int                    `json:"per_page"`
+	MaxPage          int64                  `json:"max_page"`
+	Total            int64                  `json:"total"`
+	ApproximateTotal bool                   `json:"approximate_total,omitempty"`
+	Remaining        int64                  `json:"remaining"`
+	IsDisabled       bool                   `json:"is_disabled,omitempty"`
+	SkippedIncludes  []string               `json:"skipped_includes,omitempty"`
+	Warnings         []string               `json:"warnings,omitempty"`
+	NextCursor       string                 `json:"next_cursor,omitempty"`
+	CountSource      CountSource            `json:"count_source,omitempty"`
+	From             int64                  `json:"from,omitempty"`
+	To               int64                  `json:"to,omitempty"`
+	Offset           *int                   `json:"offset,omitempty"`
+	Limit            *int                   `json:"limit,omitempty"`
+	EffectiveSearch  *string                `json:"effective_search,omitempty"`
+	Aggregates       map[string]interface{} `json:"aggregates,omitempty"`
+	QueryPlan        []string               `json:"query_plan,omitempty"`
+	DatasetVersion   string                 `json:"dataset_version,omitempty"`
 }
 
+// PaginatedResponse is the response envelope. Its JSON is byte-stable for identical
+// inputs, so it can back ETags and snapshot tests: fields are encoded in declaration
+// order and encoding/json sorts the keys of maps, such as the pagination's aggregates
+// and any in Data.
 type PaginatedResponse struct {
 	Code       int                `json:"code"`
 	Status     string             `json:"status"`
 	Message    string             `json:"message"`
 	Data       interface{}        `json:"data"`
 	Pagination PaginationResponse `json:"pagination"`
+	Groups     interface{}        `json:"groups,omitempty"`
+	Positions  []int64            `json:"positions,omitempty"`
 }
 
-func (p *PaginationRequest) GetOffset() int {
+// Normalized returns a validated copy of the request with defaults applied,
+// leaving the receiver untouched
+func (p PaginationRequest) Normalized() PaginationRequest {
 	if p.Page <= 0 {
 		p.Page = 1
 	}
-	return (p.Page - 1) * p.PerPage
-}
 
-func (p *PaginationRequest) GetLimit() int {
 	if p.PerPage <= 0 {
-		p.PerPage = 10
+		p.PerPage = DefaultPerPage
 	}
-	return p.PerPage
-}
 
-func (p *PaginationRequest) Validate() {
-	if p.Page <= 0 {
-		p.Page = 1
+	if p.Order != "asc" && p.Order != "desc" {
+		p.Order = "asc"
+		p.orderSent = false
 	}
 
-	if p.PerPage <= 0 {
-		p.PerPage = 10
-	}
+	return p
+}
 
-	if p.Order == "" {
-		p.Order = "asc"
+// explicitOrder returns the direction of the order parameter the client sent, if any.
+// Orders set in code, decoded from JSON or defaulted when binding don't count, so
+// they leave the builder's default sort as configured.
+func (p PaginationRequest) explicitOrder() (string, bool) {
+	if !p.orderSent || (p.Order != "asc" && p.Order != "desc") {
+		return "", false
 	}
+	return p.Order, true
+}
 
-	if p.Order != "asc" && p.Order != "desc" {
-		p.Order = "asc"
-	}
+func (p *PaginationRequest) GetOffset() int {
+	normalized := p.Normalized()
+	p.Page = normalized.Page
+	return (normalized.Page - 1) * normalized.PerPage
+}
+
+func (p *PaginationRequest) GetLimit() int {
+	normalized := p.Normalized()
+	p.PerPage = normalized.PerPage
+	return normalized.PerPage
+}
+
+func (p *PaginationRequest) Validate() {
+	*p = p.Normalized()
 }
 
 func BindPagination(ctx *gin.Context) PaginationRequest {
+	return bindPaginationParams(ctx.Query, PaginationConfig{})
+}
+
+// PaginationConfig holds the page size defaults applied when binding pagination. Zero
+// fields fall back to DefaultPerPage and MaxPerPage.
+type PaginationConfig struct {
+	DefaultPerPage int
+	MaxPerPage     int
+}
+
+// withDefaults returns the config with zero fields set to the package defaults and the
+// default page size capped to the maximum
+func (c PaginationConfig) withDefaults() PaginationConfig {
+	if c.MaxPerPage <= 0 {
+		c.MaxPerPage = MaxPerPage
+	}
+	if c.DefaultPerPage <= 0 {
+		c.DefaultPerPage = DefaultPerPage
+	}
+	if c.DefaultPerPage > c.MaxPerPage {
+		c.DefaultPerPage = c.MaxPerPage
+	}
+	return c
+}
+
+// BindPaginationWithConfig binds pagination like BindPagination, with the page size
+// defaults resolve computes for the request, e.g. from the authenticated tenant's
+// settings. resolve runs once per bind.
+func BindPaginationWithConfig(ctx *gin.Context, resolve func(ctx *gin.Context) PaginationConfig) PaginationRequest {
+	return bindPaginationParams(ctx.Query, resolve(ctx))
+}
+
+// BindPaginationFromMap binds pagination from plain parameters, such as the fields of
+// a gRPC or Connect request message, with the same defaults and validation as
+// BindPagination
+func BindPaginationFromMap(params map[string]string) PaginationRequest {
+	return bindPaginationParams(func(key string) string {
+		return params[key]
+	}, PaginationConfig{})
+}
+
+// bindPaginationParams binds pagination from the parameters returned by param,
+// which returns "" for missing parameters, with the page sizes of config
+func bindPaginationParams(param func(key string) string, config PaginationConfig) PaginationRequest {
+	config = config.withDefaults()
 	pagination := PaginationRequest{
 		Page:       1,
-		PerPage:    10,
+		PerPage:    config.DefaultPerPage,
 		Search:     "",
 		Sort:       "",
 		Order:      "asc",
 		IsDisabled: false,
 	}
 
-	if pageStr := ctx.Query("page"); pageStr != "" {
+	if pageStr := param("page"); pageStr != "" {
 		if page, err := strconv.Atoi(pageStr); err == nil && page > 0 {
 			pagination.Page = page
 		}
 	}
 
-	if perPageStr := ctx.Query("per_page"); perPageStr != "" {
-		if perPage, err := strconv.Atoi(perPageStr); err == nil && perPage > 0 && perPage <= 100 {
+	if perPageStr := param("per_page"); perPageStr != "" {
+		if perPage, err := strconv.Atoi(perPageStr); err == nil && perPage > 0 && perPage <= config.MaxPerPage {
 			pagination.PerPage = perPage
 		}
 	}
 
-	pagination.Search = ctx.Query("search")
+	pagination.Search = param("search")
 
-	pagination.Sort = ctx.Query("sort")
-
-	if order := ctx.Query("order"); order == "desc" || order == "asc" {
+	if order := param("order"); order == "desc" || order == "asc" {
 		pagination.Order = order
+		pagination.orderSent = true
 	}
 
-	if isDisabled := ctx.Query("is_disabled"); isDisabled != "" {
-		switch strings.ToLower(isDisabled) {
-		case "1", "true", "yes", "y", "on":
-			pagination.IsDisabled = true
-		default:
-			pagination.IsDisabled = false
-		}
+	// sort=age:desc,name:asc sorts by several columns; sort=name&order=desc still works
+	pagination.Sort = param("sort")
+	if isMultiSortParam(pagination.Sort) {
+		pagination.Sorts = ParseSortParam(pagination.Sort, pagination.Order)
+	}
+
+	if isDisabled := param("is_disabled"); isDisabled != "" {
+		pagination.IsDisabled = parseTruthy(isDisabled)
+	}
+
+	if metaOnly := param("meta_only"); metaOnly != "" {
+		pagination.MetaOnly = parseTruthy(metaOnly)
 	}
 
 	pagination.Validate()
 	return pagination
 }
 
+// defaultBooleanTokens are the boolean query parameter values recognized without
+// registration
+var defaultBooleanTokens = map[string]bool{
+	"1": true, "true": true, "yes": true, "y": true, "on": true,
+	"0": false, "false": false, "no": false, "n": false, "off": false,
+}
+
+var (
+	booleanTokensMu sync.RWMutex
+	booleanTokens   map[string]bool
+)
+
+// RegisterBooleanTokens adds values that query parameters may use for true and false,
+// such as "ya" and "tidak" for Indonesian clients, matched regardless of case. They
+// apply to is_disabled and meta_only, and to the bool fields of filters.
+func RegisterBooleanTokens(truthy []string, falsy []string) {
+	booleanTokensMu.Lock()
+	defer booleanTokensMu.Unlock()
+
+	if booleanTokens == nil {
+		booleanTokens = map[string]bool{}
+	}
+	for _, token := range truthy {
+		booleanTokens[strings.ToLower(token)] = true
+	}
+	for _, token := range falsy {
+		booleanTokens[strings.ToLower(token)] = false
+	}
+}
+
+// ParseBoolToken parses a boolean query parameter value, reporting whether it is a
+// default or registered token
+func ParseBoolToken(value string) (bool, bool) {
+	token := strings.ToLower(strings.TrimSpace(value))
+
+	booleanTokensMu.RLock()
+	parsed, ok := booleanTokens[token]
+	booleanTokensMu.RUnlock()
+	if ok {
+		return parsed, true
+	}
+
+	parsed, ok = defaultBooleanTokens[token]
+	return parsed, ok
+}
+
+// parseTruthy reports whether a query parameter value means true
+func parseTruthy(value string) bool {
+	parsed, _ := ParseBoolToken(value)
+	return parsed
+}
+
 func CalculatePagination(pagination PaginationRequest, totalCount int64) PaginationResponse {
 	// When pagination disabled, return minimal metadata
 	if pagination.IsDisabled {
@@ -126,20 +408,69 @@ func CalculatePagination(pagination PaginationRequest, totalCount int64) Paginat
 		maxPage = 1
 	}
 
+	// Items left after the current page, never negative past the last page
+	remaining := totalCount - int64(pagination.Page)*int64(pagination.PerPage)
+	if remaining < 0 {
+		remaining = 0
+	}
+
 	return PaginationResponse{
 		Page:       pagination.Page,
 		PerPage:    pagination.PerPage,
 		MaxPage:    maxPage,
 		Total:      totalCount,
+		Remaining:  remaining,
 		IsDisabled: false,
 	}
 }
 
-func NewPaginatedResponse(code int, message string, data interface{}, pagination PaginationResponse) PaginatedResponse {
-	status := "success"
+// StatusRange is an inclusive range of HTTP status codes, e.g. StatusRange{300, 399}
+type StatusRange struct {
+	Min int
+	Max int
+}
+
+// statusMapping labels the status codes of a range
+type statusMapping struct {
+	Range StatusRange
+	Label string
+}
+
+var (
+	statusMappingsMu sync.RWMutex
+	statusMappings   []statusMapping
+)
+
+// RegisterStatusMapping sets the status string NewPaginatedResponse uses for the codes
+// of a range, e.g. RegisterStatusMapping(StatusRange{206, 206}, "partial"). Where ranges
+// overlap the latest registration wins; codes outside every range are "error" from 400
+// and "success" below.
+func RegisterStatusMapping(codeRange StatusRange, label string) {
+	statusMappingsMu.Lock()
+	defer statusMappingsMu.Unlock()
+
+	statusMappings = append(statusMappings, statusMapping{Range: codeRange, Label: label})
+}
+
+// responseStatus returns the status string of an HTTP status code
+func responseStatus(code int) string {
+	statusMappingsMu.RLock()
+	defer statusMappingsMu.RUnlock()
+
+	for i := len(statusMappings) - 1; i >= 0; i-- {
+		if mapping := statusMappings[i]; code >= mapping.Range.Min && code <= mapping.Range.Max {
+			return mapping.Label
+		}
+	}
+
 	if code >= 400 {
-		status = "error"
+		return "error"
 	}
+	return "success"
+}
+
+func NewPaginatedResponse(code int, message string, data interface{}, pagination PaginationResponse) PaginatedResponse {
+	status := responseStatus(code)
 
 	return PaginatedResponse{
 		Code:       code,
@@ -149,3 +480,112 @@ func NewPaginatedResponse(code int, message string, data interface{}, pagination
 		Pagination: pagination,
 	}
 }
+
+// PagePositions returns the 1-based positions across all pages of the n items of the
+// requested page, i.e. offset + index + 1
+func PagePositions(pagination PaginationRequest, n int) []int64 {
+	offset := 0
+	if !pagination.IsDisabled {
+		offset = pagination.GetOffset()
+	}
+
+	positions := make([]int64, n)
+	for i := range positions {
+		positions[i] = int64(offset + i + 1)
+	}
+	return positions
+}
+
+// AssignPositions sets each item's 1-based position across all pages through set,
+// for models with a Position field
+func AssignPositions[T any](data []T, pagination PaginationRequest, set func(item *T, position int64)) {
+	for i, position := range PagePositions(pagination, len(data)) {
+		set(&data[i], position)
+	}
+}
+
+// WithPositions adds the 1-based position across all pages of each item in the
+// response's data, parallel to the data. Responses whose data is not a slice are
+// returned unchanged.
+func WithPositions(response PaginatedResponse) PaginatedResponse {
+	data := reflect.ValueOf(response.Data)
+	if data.Kind() != reflect.Slice {
+		return response
+	}
+
+	pagination := PaginationRequest{
+		Page:       response.Pagination.Page,
+		PerPage:    response.Pagination.PerPage,
+		IsDisabled: response.Pagination.IsDisabled,
+	}
+	response.Positions = PagePositions(pagination, data.Len())
+	return response
+}
+
+// PageBounds returns the 1-based positions across all pages of the first and last of
+// the n items of the requested page, or 0 and 0 for an empty page
+func PageBounds(pagination PaginationRequest, n int) (from int64, to int64) {
+	if n <= 0 {
+		return 0, 0
+	}
+
+	offset := 0
+	if !pagination.IsDisabled {
+		offset = pagination.GetOffset()
+	}
+	return int64(offset + 1), int64(offset + n)
+}
+
+// WithPageBounds sets the response's from and to, the positions of the first and last
+// item of the page as in "Showing 21-30 of 340". Responses whose data is not a slice
+// are returned unchanged.
+func WithPageBounds(response PaginatedResponse) PaginatedResponse {
+	data := reflect.ValueOf(response.Data)
+	if data.Kind() != reflect.Slice {
+		return response
+	}
+
+	pagination := PaginationRequest{
+		Page:       response.Pagination.Page,
+		PerPage:    response.Pagination.PerPage,
+		IsDisabled: response.Pagination.IsDisabled,
+	}
+	response.Pagination.From, response.Pagination.To = PageBounds(pagination, data.Len())
+	return response
+}
+
+// PageGroup is the items of a page sharing a key
+type PageGroup[T any] struct {
+	Key   string `json:"key"`
+	Items []T    `json:"items"`
+}
+
+// GroupPage buckets the items of a page by key, ordering groups by first appearance
+// so the page's sort order is kept both across and within groups
+func GroupPage[T any](data []T, keyFn func(T) string) []PageGroup[T] {
+	groups := []PageGroup[T]{}
+	positions := map[string]int{}
+
+	for _, item := range data {
+		key := keyFn(item)
+		position, ok := positions[key]
+		if !ok {
+			position = len(groups)
+			positions[key] = position
+			groups = append(groups, PageGroup[T]{Key: key})
+		}
+		groups[position].Items = append(groups[position].Items, item)
+	}
+
+	return groups
+}
+
+// WithGroupedResponse adds the page's data grouped by keyFn to the response's groups,
+// keeping the flat data. This groups the fetched page in Go, not with SQL GROUP BY.
+// Responses whose data is not a []T are returned unchanged.
+func WithGroupedResponse[T any](response PaginatedResponse, keyFn func(T) string) PaginatedResponse {
+	if data, ok := response.Data.([]T); ok {
+		response.Groups = GroupPage(data, keyFn)
+	}
+	return response
+}
@@ -1,16 +1,53 @@
 package pagination
 
 import (
+	"errors"
+	"fmt"
 	"reflect"
+	"regexp"
+	"strconv"
 	"strings"
+	"sync"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
 
 type BaseFilter struct {
-	Pagination PaginationRequest `json:"pagination"`
-	Includes   []string          `json:"includes"`
+	Pagination  PaginationRequest `json:"pagination"`
+	Includes    []string          `json:"includes"`
+	DefaultSort string            `json:"-" form:"-"`
+}
+
+var (
+	defaultSortMu sync.RWMutex
+	defaultSort   = "id asc"
+)
+
+// SetDefaultSort sets the package-wide default sort of filters embedding BaseFilter,
+// e.g. SetDefaultSort("created_at desc") for newest-first lists. An empty sort
+// restores "id asc".
+func SetDefaultSort(sort string) {
+	defaultSortMu.Lock()
+	defer defaultSortMu.Unlock()
+
+	if sort == "" {
+		sort = "id asc"
+	}
+	defaultSort = sort
+}
+
+// GetDefaultSort returns the filter's DefaultSort, falling back to the package-wide
+// default. Concrete filters can still override it.
+func (f *BaseFilter) GetDefaultSort() string {
+	if f.DefaultSort != "" {
+		return f.DefaultSort
+	}
+
+	defaultSortMu.RLock()
+	defer defaultSortMu.RUnlock()
+
+	return defaultSort
 }
 
 func (f *BaseFilter) BindPagination(ctx *gin.Context) {
@@ -55,6 +92,115 @@ type Filterable interface {
 	GetPagination() PaginationRequest
 }
 
+// ApplyTaggedFilters applies a WHERE condition for every non-zero field of filter
+// carrying a `filter` struct tag, so concrete filters don't need hand-written
+// ApplyFilters logic. The tag grammar is
+//
+//	filter:"<column>[,op=<operator>]"
+//
+// where operator is one of eq (default), ieq (case-insensitive eq), ne, ne_or_null (ne
+// also matching NULL), gt, gte, lt, lte, like (which matches %value%) or range. A range
+// field is a "min-max" string or a []string, so repeated parameters such as
+// ?age=18-25&age=30-40 match any of their ranges; malformed ranges fail the query with
+// a QueryParamsError. Zero values and empty slices are skipped and filter:"-" ignores
+// a field. A tag with an unknown operator fails the query with ErrInvalidFilterTag,
+// whether or not its field is set, so typos surface on the first request.
+func ApplyTaggedFilters(query *gorm.DB, filter interface{}) *gorm.DB {
+	value := reflect.ValueOf(filter)
+	for value.Kind() == reflect.Ptr {
+		if value.IsNil() {
+			return query
+		}
+		value = value.Elem()
+	}
+	if value.Kind() != reflect.Struct {
+		return query
+	}
+
+	valueType := value.Type()
+	for i := 0; i < valueType.NumField(); i++ {
+		tag, ok := valueType.Field(i).Tag.Lookup("filter")
+		if !ok || tag == "-" {
+			continue
+		}
+
+		column, operator, err := parseFilterTag(tag)
+		if err != nil {
+			query.AddError(fmt.Errorf("%w: field %s: %v", ErrInvalidFilterTag, valueType.Field(i).Name, err))
+			return query
+		}
+
+		fieldValue := value.Field(i)
+		if !valueType.Field(i).IsExported() || fieldValue.IsZero() ||
+			(fieldValue.Kind() == reflect.Slice && fieldValue.Len() == 0) {
+			continue
+		}
+
+		if !isValidIdentifier(column) {
+			continue
+		}
+
+		switch operator {
+		case "ne":
+			query = query.Where(column+" != ?", fieldValue.Interface())
+		case "ne_or_null":
+			query = query.Where("("+column+" <> ? OR "+column+" IS NULL)", fieldValue.Interface())
+		case "gt":
+			query = query.Where(column+" > ?", fieldValue.Interface())
+		case "gte":
+			query = query.Where(column+" >= ?", fieldValue.Interface())
+		case "lt":
+			query = query.Where(column+" < ?", fieldValue.Interface())
+		case "lte":
+			query = query.Where(column+" <= ?", fieldValue.Interface())
+		case "ieq":
+			query = query.Where("LOWER("+column+") = LOWER(?)", fieldValue.Interface())
+		case "like":
+			query = query.Where(column+" LIKE ?", "%"+fmt.Sprint(fieldValue.Interface())+"%")
+		case "range":
+			condition, args, err := buildRangeCondition(column, fieldValue.Interface())
+			if err != nil {
+				// Range values come from the client, so a malformed one is answered with 400
+				query.AddError(&QueryParamsError{Err: err})
+				return query
+			}
+			query = query.Where(condition, args...)
+		default:
+			query = query.Where(column+" = ?", fieldValue.Interface())
+		}
+	}
+
+	return query
+}
+
+// ErrInvalidFilterTag is returned by ApplyTaggedFilters for a filter tag with an
+// unknown operator
+var ErrInvalidFilterTag = errors.New("invalid filter tag")
+
+// taggedFilterOperators holds the operators of the filter tag grammar
+var taggedFilterOperators = map[string]bool{
+	"eq": true, "ieq": true, "ne": true, "ne_or_null": true,
+	"gt": true, "gte": true, "lt": true, "lte": true, "like": true, "range": true,
+}
+
+// parseFilterTag splits a filter tag into its column and operator, failing on an
+// operator outside the tag grammar
+func parseFilterTag(tag string) (string, string, error) {
+	parts := strings.Split(tag, ",")
+	column := strings.TrimSpace(parts[0])
+	operator := "eq"
+
+	for _, option := range parts[1:] {
+		if key, value, found := strings.Cut(strings.TrimSpace(option), "="); found && key == "op" {
+			operator = strings.ToLower(value)
+		}
+	}
+	if !taggedFilterOperators[operator] {
+		return "", "", fmt.Errorf("unknown operator %q", operator)
+	}
+	return column, operator, nil
+}
+
 // AdvancedQueryBuilder provides more sophisticated query building capabilities
 type AdvancedQueryBuilder struct {
 	SimpleQueryBuilder
@@ -92,27 +238,167 @@ func (a *AdvancedQueryBuilder) ApplySelect(query *gorm.DB) *gorm.DB {
 	return query
 }
 
-// FilterCondition represents a single filter condition
+// FilterCondition represents a single filter condition. With CompareField set, Field
+// is compared to that column instead of Value, e.g. end_date < start_date. CompareField
+// must name a filterable column; expressions such as age*5 aren't supported.
 type FilterCondition struct {
-	Field    string
-	Operator string
-	Value    interface{}
-	Logic    string // AND, OR
+	Field        string
+	Operator     string
+	Value        interface{}
+	Logic        string // AND, OR
+	CompareField string
+}
+
+// ErrInvalidColumnComparison is returned when a column comparison uses an operator
+// other than =, !=, >, >=, < or <=, or compares to a column that isn't filterable
+var ErrInvalidColumnComparison = errors.New("invalid column comparison")
+
+// columnComparisonOperators maps the operators a column comparison accepts to SQL
+var columnComparisonOperators = map[string]string{
+	"=": "=", "EQ": "=", "EQUALS": "=",
+	"!=": "<>", "<>": "<>", "NE": "<>", "NOT_EQUALS": "<>",
+	">": ">", "GT": ">", "GREATER_THAN": ">",
+	">=": ">=", "GTE": ">=", "GREATER_THAN_EQUALS": ">=",
+	"<": "<", "LT": "<", "LESS_THAN": "<",
+	"<=": "<=", "LTE": "<=", "LESS_THAN_EQUALS": "<=",
+}
+
+var (
+	presetsMu sync.RWMutex
+	presets   = map[string][]FilterCondition{}
+)
+
+// RegisterPreset registers a named, server-defined set of filter conditions that
+// clients can apply with ?preset=name. Only registered presets are ever applied.
+func RegisterPreset(name string, conditions []FilterCondition) {
+	presetsMu.Lock()
+	defer presetsMu.Unlock()
+
+	presets[name] = append([]FilterCondition(nil), conditions...)
+}
+
+// GetPreset returns the conditions of a registered preset
+func GetPreset(name string) ([]FilterCondition, bool) {
+	presetsMu.RLock()
+	defer presetsMu.RUnlock()
+
+	conditions, ok := presets[name]
+	return conditions, ok
+}
+
+// ErrUnknownPreset is returned, as a QueryParamsError, when a filter names a preset
+// that isn't registered
+var ErrUnknownPreset = errors.New("unknown preset")
+
+// presetConditions returns the conditions of the filter's preset, none when it has
+// no preset, and a QueryParamsError when the preset isn't registered
+func (d *DynamicFilter) presetConditions() ([]FilterCondition, error) {
+	if d.Preset == "" {
+		return nil, nil
+	}
+	conditions, ok := GetPreset(d.Preset)
+	if !ok {
+		return nil, &QueryParamsError{Err: fmt.Errorf("%w: %q", ErrUnknownPreset, d.Preset)}
+	}
+	return conditions, nil
 }
 
 // DynamicFilter allows for dynamic filtering based on struct tags
 type DynamicFilter struct {
 	BaseFilter
 	Filters      []FilterCondition `json:"filters"`
+	Preset       string            `json:"preset" form:"preset"`
 	TableName    string            `json:"-"`
 	Model        interface{}       `json:"-"`
 	SearchFields []string          `json:"-"`
 	DefaultSort  string            `json:"-"`
+
+	// allowedFields, when set, replaces the model as the source of filterable fields
+	allowedFields map[string]string
+	// bindErr is the error of binding the request's filter parameters, if any
+	bindErr error
 }
 
 func (d *DynamicFilter) ApplyFilters(query *gorm.DB) *gorm.DB {
-	for i, filter := range d.Filters {
-		if filter.Field == "" || filter.Value == nil {
+	// Never run a request whose conditions failed to bind unfiltered
+	if d.bindErr != nil {
+		query.AddError(&QueryParamsError{Err: d.bindErr})
+		return query
+	}
+
+	presetConditions, err := d.presetConditions()
+	if err != nil {
+		query.AddError(err)
+		return query
+	}
+	if d.Preset == "" {
+		return d.applyConditions(query, d.Filters)
+	}
+
+	query = d.applyConditions(query, presetConditions)
+
+	// Group the ad-hoc filters so an OR among them can't escape the preset
+	if len(d.Filters) > 0 {
+		query = query.Where(d.applyConditions(query.Session(&gorm.Session{NewDB: true}), d.Filters))
+	}
+	return query
+}
+
+func (d *DynamicFilter) applyConditions(query *gorm.DB, conditions []FilterCondition) *gorm.DB {
+	applied, err := d.buildConditions(conditions)
+	if err != nil {
+		query.AddError(err)
+		return query
+	}
+
+	for _, condition := range applied {
+		if condition.Logic == "OR" {
+			query = query.Or(condition.SQL, condition.Args...)
+		} else {
+			query = query.Where(condition.SQL, condition.Args...)
+		}
+	}
+	return query
+}
+
+// AppliedCondition is a WHERE condition applied by a filter, with its bound arguments
+// and how it combines with the conditions before it
+type AppliedCondition struct {
+	SQL   string        `json:"sql"`
+	Args  []interface{} `json:"args"`
+	Logic string        `json:"logic"`
+}
+
+// AppliedConditions returns the WHERE conditions ApplyFilters adds, in order, without
+// executing anything. Conditions on invalid fields are left out, as they are when
+// applied. With a preset, its conditions come first and the ad-hoc conditions that
+// follow are grouped in parentheses; an unregistered preset is an error.
+func (d *DynamicFilter) AppliedConditions() ([]AppliedCondition, error) {
+	applied := []AppliedCondition{}
+	presetConditions, err := d.presetConditions()
+	if err != nil {
+		return nil, err
+	}
+	if d.Preset != "" {
+		presetApplied, err := d.buildConditions(presetConditions)
+		if err != nil {
+			return nil, err
+		}
+		applied = append(applied, presetApplied...)
+	}
+
+	filterApplied, err := d.buildConditions(d.Filters)
+	if err != nil {
+		return nil, err
+	}
+	return append(applied, filterApplied...), nil
+}
+
+// buildConditions turns filter conditions into the WHERE conditions they apply
+func (d *DynamicFilter) buildConditions(conditions []FilterCondition) ([]AppliedCondition, error) {
+	var applied []AppliedCondition
+	for i, filter := range conditions {
+		if filter.Field == "" || (filter.Value == nil && filter.CompareField == "") {
 			continue
 		}
 
@@ -121,29 +407,116 @@ func (d *DynamicFilter) ApplyFilters(query *gorm.DB) *gorm.DB {
 			continue
 		}
 
+		logic := "AND"
+		if i > 0 && strings.ToUpper(filter.Logic) == "OR" {
+			logic = "OR"
+		}
+
+		if filter.CompareField != "" {
+			condition, err := d.buildColumnComparison(filter)
+			if err != nil {
+				return nil, err
+			}
+			applied = append(applied, AppliedCondition{SQL: condition, Logic: logic})
+			continue
+		}
+
+		if strings.ToUpper(filter.Operator) == "RANGE" {
+			condition, args, err := buildRangeCondition(filter.Field, filter.Value)
+			if err != nil {
+				// Range values come from the client, so a malformed one is answered with 400
+				return nil, &QueryParamsError{Err: err}
+			}
+			applied = append(applied, AppliedCondition{SQL: condition, Args: args, Logic: logic})
+			continue
+		}
+
 		condition := d.buildCondition(filter)
 		if condition == "" {
 			continue
 		}
 
-		if i == 0 {
-			query = query.Where(condition, filter.Value)
-		} else {
-			logic := strings.ToUpper(filter.Logic)
-			if logic == "OR" {
-				query = query.Or(condition, filter.Value)
-			} else {
-				query = query.Where(condition, filter.Value)
-			}
+		var args []interface{}
+		if strings.Contains(condition, "?") {
+			args = []interface{}{d.conditionValue(filter)}
 		}
+		applied = append(applied, AppliedCondition{SQL: condition, Args: args, Logic: logic})
 	}
-	return query
+	return applied, nil
+}
+
+// ErrInvalidRange is returned when a range filter value is not of the form "min-max"
+var ErrInvalidRange = errors.New("invalid range")
+
+// rangePattern matches a numeric range such as "18-25" or "-10-0.5"
+var rangePattern = regexp.MustCompile(`^\s*(-?\d+(?:\.\d+)?)\s*-\s*(-?\d+(?:\.\d+)?)\s*$`)
+
+// ParseRange parses a numeric range of the form "min-max" with min <= max
+func ParseRange(value string) (float64, float64, error) {
+	matches := rangePattern.FindStringSubmatch(value)
+	if matches == nil {
+		return 0, 0, fmt.Errorf("%w: %q", ErrInvalidRange, value)
+	}
+
+	min, _ := strconv.ParseFloat(matches[1], 64)
+	max, _ := strconv.ParseFloat(matches[2], 64)
+	if min > max {
+		return 0, 0, fmt.Errorf("%w: %q has min above max", ErrInvalidRange, value)
+	}
+	return min, max, nil
+}
+
+// buildRangeCondition builds the OR of BETWEENs matching any of the ranges of a range
+// filter, whose value is a "min-max" string or a slice of them
+func buildRangeCondition(field string, value interface{}) (string, []interface{}, error) {
+	var ranges []string
+	switch v := value.(type) {
+	case string:
+		ranges = []string{v}
+	case []string:
+		ranges = v
+	default:
+		return "", nil, fmt.Errorf("%w: unsupported value %v", ErrInvalidRange, value)
+	}
+	if len(ranges) == 0 {
+		return "", nil, fmt.Errorf("%w: no ranges", ErrInvalidRange)
+	}
+
+	conditions := make([]string, len(ranges))
+	args := make([]interface{}, 0, 2*len(ranges))
+	for i, r := range ranges {
+		min, max, err := ParseRange(r)
+		if err != nil {
+			return "", nil, err
+		}
+		conditions[i] = "(" + field + " BETWEEN ? AND ?)"
+		args = append(args, min, max)
+	}
+	return "(" + strings.Join(conditions, " OR ") + ")", args, nil
 }
 
 func (d *DynamicFilter) isValidField(fieldName string) bool {
+	if d.allowedFields != nil {
+		_, ok := d.allowedFields[fieldName]
+		return ok && isValidIdentifier(fieldName)
+	}
+
+	_, ok := d.modelField(fieldName)
+	return ok
+}
+
+// modelField returns the model's struct field a filter field refers to
+func (d *DynamicFilter) modelField(fieldName string) (reflect.StructField, bool) {
 	if d.Model == nil {
-		return false
+		return reflect.StructField{}, false
+	}
+
+	// Qualified or quoted references are matched on their final column segment
+	if !isValidIdentifier(fieldName) {
+		return reflect.StructField{}, false
 	}
+	segments, _ := splitIdentifier(fieldName)
+	fieldName, _ = unquoteIdentifier(segments[len(segments)-1])
 
 	modelType := reflect.TypeOf(d.Model)
 	if modelType.Kind() == reflect.Ptr {
@@ -160,11 +533,11 @@ func (d *DynamicFilter) isValidField(fieldName string) bool {
 			strings.EqualFold(field.Name, fieldName) ||
 			d.extractColumnName(dbTag) == fieldName ||
 			d.extractJSONName(jsonTag) == fieldName {
-			return true
+			return field, true
 		}
 	}
 
-	return false
+	return reflect.StructField{}, false
 }
 
 func (d *DynamicFilter) extractColumnName(gormTag string) string {
@@ -185,12 +558,34 @@ func (d *DynamicFilter) extractJSONName(jsonTag string) string {
 	return ""
 }
 
+// filterOperators holds the operators buildCondition knows, upper-cased; any other
+// falls back to equality
+var filterOperators = map[string]bool{
+	"=": true, "EQ": true, "EQUALS": true,
+	"!=": true, "<>": true, "NE": true, "NOT_EQUALS": true, "NE_OR_NULL": true,
+	">": true, "GT": true, "GREATER_THAN": true,
+	">=": true, "GTE": true, "GREATER_THAN_EQUALS": true,
+	"<": true, "LT": true, "LESS_THAN": true,
+	"<=": true, "LTE": true, "LESS_THAN_EQUALS": true,
+	"LIKE": true, "CONTAINS": true, "SUBSTRING": true, "STARTSWITH": true, "ENDSWITH": true,
+	"ILIKE": true, "ICONTAINS": true,
+	"IN": true, "NOT_IN": true, "IS_NULL": true, "IS_NOT_NULL": true,
+}
+
+// isFilterOperator reports whether buildCondition knows the operator, in any case
+func isFilterOperator(operator string) bool {
+	return filterOperators[strings.ToUpper(operator)]
+}
+
 func (d *DynamicFilter) buildCondition(filter FilterCondition) string {
 	switch strings.ToUpper(filter.Operator) {
 	case "=", "EQ", "EQUALS":
 		return filter.Field + " = ?"
-	case "!=", "NE", "NOT_EQUALS":
+	case "!=", "<>", "NE", "NOT_EQUALS":
 		return filter.Field + " != ?"
+	case "NE_OR_NULL":
+		// NULL is not equal to the value either, though != alone excludes it
+		return "(" + filter.Field + " <> ? OR " + filter.Field + " IS NULL)"
 	case ">", "GT", "GREATER_THAN":
 		return filter.Field + " > ?"
 	case ">=", "GTE", "GREATER_THAN_EQUALS":
@@ -201,6 +596,8 @@ func (d *DynamicFilter) buildCondition(filter FilterCondition) string {
 		return filter.Field + " <= ?"
 	case "LIKE", "CONTAINS":
 		return filter.Field + " LIKE ?"
+	case "SUBSTRING", "STARTSWITH", "ENDSWITH":
+		return filter.Field + " LIKE ? ESCAPE '" + likeEscapeChar + "'"
 	case "ILIKE", "ICONTAINS":
 		return filter.Field + " ILIKE ?"
 	case "IN":
@@ -216,6 +613,58 @@ func (d *DynamicFilter) buildCondition(filter FilterCondition) string {
 	}
 }
 
+// buildColumnComparison builds the condition comparing a filter's field to its
+// CompareField. The compared field must be a filterable column, so an expression or
+// unknown column fails rather than dropping the condition. Rows where either column
+// is NULL never match.
+func (d *DynamicFilter) buildColumnComparison(filter FilterCondition) (string, error) {
+	operator, ok := columnComparisonOperators[strings.ToUpper(filter.Operator)]
+	if !ok {
+		return "", fmt.Errorf("%w: operator %q", ErrInvalidColumnComparison, filter.Operator)
+	}
+	if !d.isValidField(filter.CompareField) {
+		return "", fmt.Errorf("%w: compared field %q", ErrInvalidColumnComparison, filter.CompareField)
+	}
+	return filter.Field + " " + operator + " " + filter.CompareField, nil
+}
+
+// conditionValue returns the query argument for a condition, turning the value of
+// substring/startswith/endswith into an escaped LIKE pattern and boolean tokens
+// compared to a bool model field into bools. The value of contains, like that of like,
+// is the caller's own LIKE pattern.
+func (d *DynamicFilter) conditionValue(filter FilterCondition) interface{} {
+	switch strings.ToUpper(filter.Operator) {
+	case "SUBSTRING":
+		return "%" + escapeLike(fmt.Sprint(filter.Value)) + "%"
+	case "STARTSWITH":
+		return escapeLike(fmt.Sprint(filter.Value)) + "%"
+	case "ENDSWITH":
+		return "%" + escapeLike(fmt.Sprint(filter.Value))
+	default:
+		if text, ok := filter.Value.(string); ok {
+			if field, ok := d.modelField(filter.Field); ok && isBoolKind(field.Type) {
+				if parsed, ok := ParseBoolToken(text); ok {
+					return parsed
+				}
+			}
+		}
+		return filter.Value
+	}
+}
+
+// likeEscapeChar is the LIKE escape character; unlike a backslash it needs no
+// escaping inside string literals on any dialect
+const likeEscapeChar = "!"
+
+// escapeLike escapes the LIKE wildcards in a term so they match literally
+func escapeLike(term string) string {
+	return strings.NewReplacer(
+		likeEscapeChar, likeEscapeChar+likeEscapeChar,
+		"%", likeEscapeChar+"%",
+		"_", likeEscapeChar+"_",
+	).Replace(term)
+}
+
 func (d *DynamicFilter) GetTableName() string {
 	return d.TableName
 }
@@ -226,7 +675,7 @@ func (d *DynamicFilter) GetSearchFields() []string {
 
 func (d *DynamicFilter) GetDefaultSort() string {
 	if d.DefaultSort == "" {
-		return "id asc"
+		return d.BaseFilter.GetDefaultSort()
 	}
 	return d.DefaultSort
 }
@@ -1,15 +1,26 @@
 package pagination
 
 import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"log"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
 )
 
 type TestUser struct {
@@ -38,6 +49,50 @@ func setupTestDB() *gorm.DB {
 	return db
 }
 
+type TestProvince struct {
+	ID            uint          `json:"id" gorm:"primaryKey"`
+	Name          string        `json:"name"`
+	Code          string        `json:"code"`
+	Athletes      []TestAthlete `json:"athletes,omitempty" gorm:"foreignKey:ProvinceID"`
+	AthletesCount int64         `json:"athletes_count" gorm:"->;-:migration"`
+}
+
+type TestAthlete struct {
+	ID         uint   `json:"id" gorm:"primaryKey"`
+	Name       string `json:"name"`
+	ProvinceID uint   `json:"province_id"`
+	Gender     string `json:"gender"`
+	Age        int    `json:"age"`
+	Height     int    `json:"height"`
+}
+
+func setupRelationTestDB() *gorm.DB {
+	db, _ := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	db.AutoMigrate(&TestProvince{}, &TestAthlete{})
+
+	provinces := []TestProvince{
+		{Name: "DKI Jakarta", Code: "JKT"},
+		{Name: "Jawa Barat", Code: "JBR"},
+		{Name: "Bali", Code: "BAL"},
+	}
+	for _, province := range provinces {
+		db.Create(&province)
+	}
+
+	athletes := []TestAthlete{
+		{Name: "Budi Santoso", ProvinceID: 1, Gender: "Male", Age: 25, Height: 175},
+		{Name: "Siti Nurhaliza", ProvinceID: 1, Gender: "Female", Age: 23, Height: 165},
+		{Name: "Liliyana Natsir", ProvinceID: 1, Gender: "Female", Age: 31, Height: 162},
+		{Name: "Ahmad Subandrio", ProvinceID: 2, Gender: "Male", Age: 27, Height: 180},
+		{Name: "Dewi Sartika", ProvinceID: 2, Gender: "Female", Age: 24, Height: 168},
+	}
+	for _, athlete := range athletes {
+		db.Create(&athlete)
+	}
+
+	return db
+}
+
 func TestPaginationRequest_GetOffset(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -92,6 +147,41 @@ func TestPaginationRequest_Validate(t *testing.T) {
 	assert.Equal(t, "asc", p.Order)
 }
 
+func TestPaginationRequest_Normalized(t *testing.T) {
+	original := PaginationRequest{Page: 0, PerPage: -5, Order: "invalid", Search: "john"}
+
+	normalized := original.Normalized()
+
+	assert.Equal(t, 1, normalized.Page)
+	assert.Equal(t, 10, normalized.PerPage)
+	assert.Equal(t, "asc", normalized.Order)
+	assert.Equal(t, "john", normalized.Search)
+
+	assert.Equal(t, PaginationRequest{Page: 0, PerPage: -5, Order: "invalid", Search: "john"}, original)
+	assert.Equal(t, normalized, normalized.Normalized())
+}
+
+func TestZeroPerPage(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request, _ = http.NewRequest("GET", "/?per_page=0", nil)
+	assert.Equal(t, DefaultPerPage, BindPagination(c).PerPage)
+
+	p := PaginationRequest{PerPage: 0}
+	assert.Equal(t, DefaultPerPage, p.GetLimit())
+
+	p = PaginationRequest{PerPage: 0}
+	p.Validate()
+	assert.Equal(t, DefaultPerPage, p.PerPage)
+
+	assert.Equal(t, DefaultPerPage, PaginationRequest{PerPage: 0}.Normalized().PerPage)
+
+	p = PaginationRequest{Page: 3, PerPage: 0}
+	assert.Equal(t, 2*DefaultPerPage, p.GetOffset())
+}
+
 func TestBindPagination(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
@@ -152,6 +242,28 @@ func TestCalculatePagination(t *testing.T) {
 	assert.Equal(t, int64(25), result.Total)
 }
 
+func TestCalculatePagination_Remaining(t *testing.T) {
+	tests := []struct {
+		name       string
+		pagination PaginationRequest
+		total      int64
+		expected   int64
+	}{
+		{"Mid list", PaginationRequest{Page: 1, PerPage: 10}, 25, 15},
+		{"Last page", PaginationRequest{Page: 3, PerPage: 10}, 25, 0},
+		{"Past last page", PaginationRequest{Page: 5, PerPage: 10}, 25, 0},
+		{"Empty result", PaginationRequest{Page: 1, PerPage: 10}, 0, 0},
+		{"Disabled", PaginationRequest{Page: 1, PerPage: 10, IsDisabled: true}, 25, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := CalculatePagination(tt.pagination, tt.total)
+			assert.Equal(t, tt.expected, result.Remaining)
+		})
+	}
+}
+
 func TestSimpleQueryBuilder(t *testing.T) {
 	db := setupTestDB()
 
@@ -300,3 +412,4124 @@ func TestSQLInjectionPrevention(t *testing.T) {
 	assert.False(t, isValidInclude("Posts; DROP TABLE"))
 	assert.False(t, isValidInclude(""))
 }
+
+func TestMaxQueryDuration(t *testing.T) {
+	db := setupTestDB()
+
+	slowFilter := func(query *gorm.DB) *gorm.DB {
+		return query.Where("(WITH RECURSIVE c(x) AS (SELECT 1 UNION ALL SELECT x + 1 FROM c WHERE x < 100000000) SELECT COUNT(*) FROM c) > 0")
+	}
+
+	builder := NewSimpleQueryBuilder("test_users").
+		WithFilters(slowFilter).
+		WithMaxQueryDuration(50 * time.Millisecond)
+
+	pagination := PaginationRequest{Page: 1, PerPage: 10}
+
+	_, _, err := PaginatedQuery[TestUser](db, builder, pagination, []string{})
+
+	assert.ErrorIs(t, err, ErrQueryTimeout)
+
+	fastBuilder := NewSimpleQueryBuilder("test_users").
+		WithMaxQueryDuration(5 * time.Second)
+
+	users, total, err := PaginatedQuery[TestUser](db, fastBuilder, pagination, []string{})
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(5), total)
+	assert.Len(t, users, 5)
+}
+
+func TestCustomSortFieldValidator(t *testing.T) {
+	db := setupTestDB()
+
+	quotedSort := `"test_users"."name"`
+	quotedIdentifier := regexp.MustCompile(`^"[A-Za-z_][A-Za-z0-9_]*"(\."[A-Za-z_][A-Za-z0-9_]*")*$`)
+	pagination := PaginationRequest{Page: 1, PerPage: 10, Sort: quotedSort, Order: "asc"}
+
+	// Per-builder override
+	builder := NewSimpleQueryBuilder("test_users").
+		WithSortFieldValidator(quotedIdentifier.MatchString)
+
+	users, _, err := PaginatedQuery[TestUser](db, builder, pagination, []string{})
+	assert.NoError(t, err)
+	assert.Equal(t, "Alice Brown", users[0].Name)
+
+	// Stricter per-builder validator rejects bare names and falls back to the default sort
+	pagination.Sort = "name"
+	users, _, err = PaginatedQuery[TestUser](db, builder, pagination, []string{})
+	assert.NoError(t, err)
+	assert.Equal(t, "John Doe", users[0].Name)
+
+	// Package-wide override
+	SetSortFieldValidator(quotedIdentifier.MatchString)
+	defer SetSortFieldValidator(nil)
+
+	pagination.Sort = quotedSort
+	users, _, err = PaginatedQuery[TestUser](db, NewSimpleQueryBuilder("test_users"), pagination, []string{})
+	assert.NoError(t, err)
+	assert.Equal(t, "Alice Brown", users[0].Name)
+	assert.False(t, validateSortField(nil, "name"))
+}
+
+func TestQualifiedAndQuotedIdentifiers(t *testing.T) {
+	assert.True(t, isValidSortField("analytics.users.created_at"))
+	assert.True(t, isValidSortField(`"Created At"`))
+	assert.True(t, isValidSortField(`"analytics"."users"."Created At"`))
+	assert.True(t, isValidSortField("`order`"))
+	assert.True(t, isValidSortField("[Created At]"))
+
+	assert.False(t, isValidSortField("analytics..created_at"))
+	assert.False(t, isValidSortField("users."))
+	assert.False(t, isValidSortField(`"Created At`))
+	assert.False(t, isValidSortField(`"a"b"`))
+	assert.False(t, isValidSortField(`"a"; DROP TABLE users; --"`))
+	assert.False(t, isValidSortField(`""`))
+
+	db := setupTestDB()
+
+	pagination := PaginationRequest{Page: 1, PerPage: 10, Sort: `"test_users"."name"`, Order: "asc"}
+	users, _, err := PaginatedQuery[TestUser](db, NewSimpleQueryBuilder("test_users"), pagination, []string{})
+	assert.NoError(t, err)
+	assert.Equal(t, "Alice Brown", users[0].Name)
+
+	filter := &DynamicFilter{
+		TableName: "test_users",
+		Model:     TestUser{},
+		Filters: []FilterCondition{
+			{Field: `"test_users"."age"`, Operator: ">", Value: 30},
+		},
+	}
+
+	users, total, err := PaginatedQuery[TestUser](db, filter, PaginationRequest{Page: 1, PerPage: 10}, []string{})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+	assert.Len(t, users, 2)
+}
+
+func TestCountOnly(t *testing.T) {
+	db := setupTestDB()
+
+	builder := NewSimpleQueryBuilder("test_users").
+		WithSearchFields("name", "email").
+		WithFilters(func(query *gorm.DB) *gorm.DB {
+			return query.Where("age >= ?", 25)
+		})
+
+	pagination := PaginationRequest{Page: 1, PerPage: 1, Search: "John"}
+
+	count, err := CountOnly(db, builder, pagination)
+	assert.NoError(t, err)
+
+	_, total, err := PaginatedQuery[TestUser](db, builder, pagination, []string{})
+	assert.NoError(t, err)
+
+	assert.Equal(t, total, count)
+	assert.Equal(t, int64(2), count)
+}
+
+type TestArticle struct {
+	ID        uint   `json:"id" gorm:"primaryKey"`
+	Title     string `json:"title"`
+	Status    string `json:"status"`
+	DeletedAt *time.Time
+}
+
+func TestCountWithOptions(t *testing.T) {
+	db, _ := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	db.AutoMigrate(&TestArticle{})
+
+	deletedAt := time.Now()
+	articles := []TestArticle{
+		{Title: "Go basics", Status: "published"},
+		{Title: "Go advanced", Status: "draft"},
+		{Title: "Rust intro", Status: "published"},
+		{Title: "Go deleted", Status: "published", DeletedAt: &deletedAt},
+		{Title: "Python", Status: "draft", DeletedAt: &deletedAt},
+		{Title: "Go tips", Status: "published"},
+	}
+	for _, article := range articles {
+		db.Create(&article)
+	}
+
+	builder := NewSimpleQueryBuilder("test_articles").
+		WithSearchFields("title").
+		WithFilters(func(query *gorm.DB) *gorm.DB {
+			return query.Where("status = ?", "published")
+		})
+
+	pagination := PaginationRequest{Page: 1, PerPage: 10, Search: "Go"}
+	options := PaginatedQueryOptions{Dialect: SQLite, EnableSoftDelete: true}
+
+	tests := []struct {
+		name     string
+		options  CountOptions
+		expected int64
+	}{
+		{"Search, filters and scopes", CountOptions{IncludeSearch: true, IncludeFilters: true, IncludeDefaultScopes: true}, 2},
+		{"Search and filters", CountOptions{IncludeSearch: true, IncludeFilters: true}, 3},
+		{"Search and scopes", CountOptions{IncludeSearch: true, IncludeDefaultScopes: true}, 3},
+		{"Search only", CountOptions{IncludeSearch: true}, 4},
+		{"Filters and scopes", CountOptions{IncludeFilters: true, IncludeDefaultScopes: true}, 3},
+		{"Filters only", CountOptions{IncludeFilters: true}, 4},
+		{"Scopes only", CountOptions{IncludeDefaultScopes: true}, 4},
+		{"Nothing", CountOptions{}, 6},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			count, err := CountWithOptions(db, builder, pagination, options, tt.options)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, count)
+		})
+	}
+}
+
+func TestSmartSearch(t *testing.T) {
+	db := setupTestDB()
+
+	builder := NewSimpleQueryBuilder("test_users").
+		WithSearchFields("name", "email").
+		WithSmartSearch(func(term string) (string, []interface{}, bool) {
+			id, err := strconv.Atoi(term)
+			if err != nil {
+				return "", nil, false
+			}
+			return "id = ?", []interface{}{id}, true
+		})
+
+	pagination := PaginationRequest{Page: 1, PerPage: 10, Search: "5"}
+
+	users, total, err := PaginatedQuery[TestUser](db, builder, pagination, []string{})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	assert.Len(t, users, 1)
+	assert.Equal(t, uint(5), users[0].ID)
+
+	pagination.Search = "Jane"
+	users, total, err = PaginatedQuery[TestUser](db, builder, pagination, []string{})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	assert.Equal(t, "Jane Smith", users[0].Name)
+}
+
+func TestRelationCount(t *testing.T) {
+	db := setupRelationTestDB()
+
+	builder := NewSimpleQueryBuilder("test_provinces").
+		WithRelationCount("Athletes", "athletes_count")
+
+	provinces, total, err := PaginatedQuery[TestProvince](db, builder, PaginationRequest{Page: 1, PerPage: 10}, []string{})
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), total)
+	assert.Len(t, provinces, 3)
+	assert.Equal(t, int64(3), provinces[0].AthletesCount)
+	assert.Equal(t, int64(2), provinces[1].AthletesCount)
+	assert.Equal(t, int64(0), provinces[2].AthletesCount)
+
+	builder = NewSimpleQueryBuilder("test_provinces").
+		WithRelationCount("Unknown", "unknown_count")
+
+	_, _, err = PaginatedQuery[TestProvince](db, builder, PaginationRequest{Page: 1, PerPage: 10}, []string{})
+	assert.Error(t, err)
+}
+
+func TestCountSort(t *testing.T) {
+	db := setupEventTestDB()
+
+	builder := NewSimpleQueryBuilder("test_sports").
+		WithSortableFields("name").
+		WithCountSort("events_count", "Events")
+
+	sports, _, err := PaginatedQuery[TestSport](db, builder, PaginationRequest{Page: 1, PerPage: 10, Sort: "events_count", Order: "desc"}, []string{})
+	assert.NoError(t, err)
+	assert.Equal(t, "Sepak Bola", sports[0].Name)
+
+	sports, _, err = PaginatedQuery[TestSport](db, builder, PaginationRequest{Page: 1, PerPage: 2, Sort: "events_count", Order: "asc"}, []string{})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Badminton", "Renang"}, []string{sports[0].Name, sports[1].Name})
+
+	// Composes with the selected relation count of the same alias
+	relationDB := setupRelationTestDB()
+	provinceBuilder := NewSimpleQueryBuilder("test_provinces").
+		WithRelationCount("Athletes", "athletes_count").
+		WithCountSort("athletes_count", "Athletes")
+
+	provinces, _, err := PaginatedQuery[TestProvince](relationDB, provinceBuilder, PaginationRequest{Page: 1, PerPage: 10, Sort: "athletes_count", Order: "asc"}, []string{})
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{0, 2, 3}, []int64{provinces[0].AthletesCount, provinces[1].AthletesCount, provinces[2].AthletesCount})
+
+	// Only registered aliases sort by a count
+	provinces, _, err = PaginatedQuery[TestProvince](relationDB, provinceBuilder.WithSortableFields("name"), PaginationRequest{Page: 1, PerPage: 10, Sort: "code_count", Order: "asc"}, []string{})
+	assert.NoError(t, err)
+	assert.Equal(t, "DKI Jakarta", provinces[0].Name)
+}
+
+func TestIncludeFilters(t *testing.T) {
+	db := setupRelationTestDB()
+
+	query, _ := url.ParseQuery("includes=Athletes&Athletes.gender=Male&Athletes.name=Siti Nurhaliza&search=x")
+	filters := ParseIncludeFilters(query)
+	assert.Equal(t, []IncludeFilter{
+		{Include: "Athletes", Field: "gender", Value: "Male"},
+		{Include: "Athletes", Field: "name", Value: "Siti Nurhaliza"},
+	}, filters)
+
+	// Only the allowlisted gender filter applies
+	builder := NewSimpleQueryBuilder("test_provinces").
+		WithIncludeFilterFields("Athletes", "gender").
+		WithIncludeFilters(filters...)
+
+	provinces, _, err := PaginatedQuery[TestProvince](db, builder, PaginationRequest{Page: 1, PerPage: 10}, []string{"Athletes"})
+	assert.NoError(t, err)
+	assert.Len(t, provinces[0].Athletes, 1)
+	assert.Equal(t, "Budi Santoso", provinces[0].Athletes[0].Name)
+	assert.Len(t, provinces[1].Athletes, 1)
+	assert.Equal(t, "Ahmad Subandrio", provinces[1].Athletes[0].Name)
+
+	// Repeated values match any of them
+	query, _ = url.ParseQuery("Athletes.gender=Male&Athletes.gender=Female")
+	builder.IncludeFilters = ParseIncludeFilters(query)
+	provinces, _, err = PaginatedQuery[TestProvince](db, builder, PaginationRequest{Page: 1, PerPage: 10}, []string{"Athletes"})
+	assert.NoError(t, err)
+	assert.Len(t, provinces[0].Athletes, 3)
+
+	// Filters never reach the query for disallowed fields or unrequested includes
+	builder.IncludeFilters = []IncludeFilter{{Include: "Athletes", Field: "gender = 'Male' OR 1", Value: "x"}}
+	builder.IncludeFilterFields["Athletes"]["gender = 'Male' OR 1"] = true
+	provinces, _, err = PaginatedQuery[TestProvince](db, builder, PaginationRequest{Page: 1, PerPage: 10}, []string{"Athletes"})
+	assert.NoError(t, err)
+	assert.Len(t, provinces[0].Athletes, 3)
+
+	provinces, _, err = PaginatedQuery[TestProvince](db, builder, PaginationRequest{Page: 1, PerPage: 10}, []string{})
+	assert.NoError(t, err)
+	assert.Empty(t, provinces[0].Athletes)
+}
+
+func TestEmptyPageSerializesAsArray(t *testing.T) {
+	db := setupTestDB()
+	gin.SetMode(gin.TestMode)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request, _ = http.NewRequest("GET", "/?search=nobody", nil)
+
+	response := PaginatedAPIResponse[TestUser](db, c, "test_users", []string{"name", "email"}, "Success")
+	body, err := json.Marshal(response)
+	assert.NoError(t, err)
+	assert.Contains(t, string(body), `"data":[]`)
+
+	c.Request, _ = http.NewRequest("GET", "/", nil)
+	filter := &DynamicFilter{TableName: "test_users", Model: TestUser{}}
+
+	response = PaginatedAPIResponseWithQueryLayer(c, &testIncludableFilter{DynamicFilter: filter}, "Success",
+		func(IncludableQueryBuilder) ([]TestUser, int64, error) {
+			return nil, 0, nil
+		})
+	body, err = json.Marshal(response)
+	assert.NoError(t, err)
+	assert.Contains(t, string(body), `"data":[]`)
+}
+
+type testIncludableFilter struct {
+	*DynamicFilter
+}
+
+func (f *testIncludableFilter) Validate() {}
+
+func TestWeightedSearch(t *testing.T) {
+	db := setupTestDB()
+	db.Create(&TestUser{Name: "Eve Adams", Email: "johnson.eve@example.com", Age: 27})
+
+	pagination := PaginationRequest{Page: 1, PerPage: 10, Search: "johnson"}
+
+	builder := NewSimpleQueryBuilder("test_users").
+		WithSearchFields("name", "email")
+
+	users, _, err := PaginatedQuery[TestUser](db, builder, pagination, []string{})
+	assert.NoError(t, err)
+	assert.Len(t, users, 2)
+	assert.Equal(t, "Bob Johnson", users[0].Name)
+
+	weighted := NewSimpleQueryBuilder("test_users").
+		WithWeightedSearch(map[string]int{"email": 5, "name": 1})
+
+	users, total, err := PaginatedQuery[TestUser](db, weighted, pagination, []string{})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+	assert.Equal(t, "Eve Adams", users[0].Name)
+	assert.Equal(t, "Bob Johnson", users[1].Name)
+
+	// An explicit sort takes precedence over relevance
+	pagination.Sort = "id"
+	users, _, err = PaginatedQuery[TestUser](db, weighted, pagination, []string{})
+	assert.NoError(t, err)
+	assert.Equal(t, "Bob Johnson", users[0].Name)
+}
+
+func TestWeightedSearchTiebreaker(t *testing.T) {
+	db := setupTestDB()
+	for i := 1; i <= 6; i++ {
+		db.Create(&TestUser{Name: "Tied " + strconv.Itoa(i), Email: "tied" + strconv.Itoa(i) + "@example.com", Age: 40})
+	}
+
+	var statements []string
+	db.Callback().Query().After("gorm:query").Register("test:record_sql", func(tx *gorm.DB) {
+		statements = append(statements, tx.Statement.SQL.String())
+	})
+
+	// Every row shares both the relevance score and the default sort value
+	builder := NewSimpleQueryBuilder("test_users").
+		WithWeightedSearch(map[string]int{"name": 1}).
+		WithDefaultSort("age asc")
+
+	var ids []uint
+	for page := 1; page <= 2; page++ {
+		users, total, err := PaginatedQuery[TestUser](db, builder, PaginationRequest{Page: page, PerPage: 3, Search: "tied"}, []string{})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(6), total)
+		for _, user := range users {
+			ids = append(ids, user.ID)
+		}
+	}
+	assert.Equal(t, []uint{6, 7, 8, 9, 10, 11}, ids)
+	assert.Contains(t, statements[len(statements)-1], "DESC, age asc, test_users.id asc LIMIT")
+
+	// A default sort ending with the primary key needs no tiebreaker
+	statements = nil
+	_, _, err := PaginatedQuery[TestUser](db, builder.WithDefaultSort("age asc, id desc"), PaginationRequest{Page: 1, PerPage: 3, Search: "tied"}, []string{})
+	assert.NoError(t, err)
+	assert.Contains(t, statements[len(statements)-1], "DESC, age asc, id desc LIMIT")
+}
+
+func TestPaginatedQueryInsideTransaction(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(t.TempDir()+"/tx.db"), &gorm.Config{})
+	assert.NoError(t, err)
+	db.AutoMigrate(&TestUser{})
+	db.Create(&TestUser{Name: "John Doe", Email: "john@example.com", Age: 25})
+
+	builder := NewSimpleQueryBuilder("test_users")
+	pagination := PaginationRequest{Page: 1, PerPage: 10}
+
+	tx := db.Begin()
+	defer tx.Rollback()
+	tx.Create(&TestUser{Name: "Uncommitted", Email: "tx@example.com", Age: 40})
+
+	// Both count and data see the uncommitted row, so they ran on the transaction
+	users, total, err := PaginatedQuery[TestUser](tx, builder, pagination, []string{})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+	assert.Len(t, users, 2)
+
+	// Outside the transaction the row is not visible yet
+	users, total, err = PaginatedQuery[TestUser](db, builder, pagination, []string{})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	assert.Len(t, users, 1)
+
+	// The timeout context is derived from the transaction handle as well
+	users, total, err = PaginatedQuery[TestUser](tx, NewSimpleQueryBuilder("test_users").WithMaxQueryDuration(time.Second), pagination, []string{})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+	assert.Len(t, users, 2)
+}
+
+func TestPaginateCore(t *testing.T) {
+	db := setupTestDB()
+
+	builder := NewSimpleQueryBuilder("test_users").
+		WithFilters(func(query *gorm.DB) *gorm.DB {
+			return query.Where("age >= ?", 28)
+		})
+
+	users, paginationResponse, err := PaginateCore[TestUser](db, builder, PaginationRequest{Page: 2, PerPage: 2})
+
+	assert.NoError(t, err)
+	assert.Len(t, users, 2)
+	assert.Equal(t, 2, paginationResponse.Page)
+	assert.Equal(t, 2, paginationResponse.PerPage)
+	assert.Equal(t, int64(2), paginationResponse.MaxPage)
+	assert.Equal(t, int64(4), paginationResponse.Total)
+	assert.Equal(t, int64(0), paginationResponse.Remaining)
+}
+
+func TestFilterPresets(t *testing.T) {
+	db := setupTestDB()
+
+	RegisterPreset("over_30", []FilterCondition{
+		{Field: "age", Operator: ">", Value: 30},
+	})
+
+	filter := &DynamicFilter{
+		TableName: "test_users",
+		Model:     TestUser{},
+		Preset:    "over_30",
+	}
+	pagination := PaginationRequest{Page: 1, PerPage: 10}
+
+	_, total, err := PaginatedQuery[TestUser](db, filter, pagination, []string{})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+
+	// Presets compose with ad-hoc filters
+	filter.Filters = []FilterCondition{
+		{Field: "name", Operator: "LIKE", Value: "%Bob%"},
+	}
+	users, total, err := PaginatedQuery[TestUser](db, filter, pagination, []string{})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	assert.Equal(t, "Bob Johnson", users[0].Name)
+
+	// An OR among the ad-hoc filters can't escape the preset
+	filter.Filters = []FilterCondition{
+		{Field: "name", Operator: "=", Value: "Bob Johnson"},
+		{Field: "name", Operator: "=", Value: "John Doe", Logic: "OR"},
+	}
+	users, total, err = PaginatedQuery[TestUser](db, filter, pagination, []string{})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	assert.Equal(t, "Bob Johnson", users[0].Name)
+
+	// Unregistered presets are rejected rather than ignored
+	filter.Preset = "unknown"
+	filter.Filters = nil
+	_, _, err = PaginatedQuery[TestUser](db, filter, pagination, []string{})
+	var paramsErr *QueryParamsError
+	assert.ErrorAs(t, err, &paramsErr)
+	assert.ErrorIs(t, err, ErrUnknownPreset)
+
+	_, err = filter.AppliedConditions()
+	assert.ErrorIs(t, err, ErrUnknownPreset)
+}
+
+func TestUnknownPresetResponse(t *testing.T) {
+	db := setupTestDB()
+	gin.SetMode(gin.TestMode)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request, _ = http.NewRequest("GET", "/?preset=no_such_preset", nil)
+
+	filter := &DynamicFilter{TableName: "test_users", Model: TestUser{}}
+	response := PaginatedAPIResponseWithCustomFilter[TestUser](db, c, filter, "Success")
+	assert.Equal(t, 400, response.Code)
+}
+
+func TestMetaOnly(t *testing.T) {
+	db := setupTestDB()
+	gin.SetMode(gin.TestMode)
+
+	var statements []string
+	db.Callback().Query().After("gorm:query").Register("test:record_sql", func(tx *gorm.DB) {
+		statements = append(statements, tx.Statement.SQL.String())
+	})
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request, _ = http.NewRequest("GET", "/?meta_only=true&per_page=2", nil)
+
+	response := PaginatedAPIResponse[TestUser](db, c, "test_users", []string{"name"}, "Success")
+
+	assert.Equal(t, 200, response.Code)
+	assert.Equal(t, []TestUser{}, response.Data)
+	assert.Equal(t, int64(5), response.Pagination.Total)
+	assert.Equal(t, int64(3), response.Pagination.MaxPage)
+
+	assert.Len(t, statements, 1)
+	assert.Contains(t, strings.ToLower(statements[0]), "count(")
+}
+
+func TestParseSortClause(t *testing.T) {
+	assert.Equal(t, []SortField{
+		{Field: "score", Direction: "desc"},
+		{Field: "created_at", Direction: "desc"},
+		{Field: "id", Direction: "asc"},
+	}, ParseSortClause("score desc, created_at DESC, id"))
+
+	assert.Empty(t, ParseSortClause(""))
+}
+
+func TestCompositeCursor(t *testing.T) {
+	sort := ParseSortClause("score desc, created_at desc, id asc")
+
+	cursor, err := EncodeCursor([]CursorField{
+		{Field: "score", Direction: "desc", Value: 98.5},
+		{Field: "created_at", Direction: "desc", Value: "2024-10-15T08:30:00Z"},
+		{Field: "id", Direction: "asc", Value: 42},
+	})
+	assert.NoError(t, err)
+
+	fields, err := DecodeCursor(cursor, sort)
+	assert.NoError(t, err)
+	assert.Len(t, fields, 3)
+	assert.Equal(t, 98.5, fields[0].Value)
+	assert.Equal(t, "2024-10-15T08:30:00Z", fields[1].Value)
+	assert.Equal(t, int64(42), fields[2].Value)
+
+	// Different direction
+	_, err = DecodeCursor(cursor, ParseSortClause("score asc, created_at desc, id asc"))
+	assert.ErrorIs(t, err, ErrCursorSortMismatch)
+
+	// Different column order
+	_, err = DecodeCursor(cursor, ParseSortClause("created_at desc, score desc, id asc"))
+	assert.ErrorIs(t, err, ErrCursorSortMismatch)
+
+	// Fewer columns
+	_, err = DecodeCursor(cursor, ParseSortClause("score desc, id asc"))
+	assert.ErrorIs(t, err, ErrCursorSortMismatch)
+
+	_, err = DecodeCursor("not a cursor!", sort)
+	assert.ErrorIs(t, err, ErrInvalidCursor)
+}
+
+func TestValidateCursor(t *testing.T) {
+	db := setupTestDB()
+	builder := NewSimpleQueryBuilder("test_users")
+	request := PaginationRequest{Page: 1, PerPage: 2, Sort: "age", Order: "desc"}
+
+	users, _, err := PaginatedQuery[TestUser](db, builder, request, []string{})
+	assert.NoError(t, err)
+	cursor, err := CursorFromPage(db, builder, request, users)
+	assert.NoError(t, err)
+
+	assert.NoError(t, ValidateCursor(cursor, request))
+	assert.NoError(t, ValidateCursor(cursor, PaginationRequest{}))
+
+	// Tampered
+	assert.ErrorIs(t, ValidateCursor(cursor[:len(cursor)-3]+"!!!", request), ErrInvalidCursor)
+	assert.ErrorIs(t, ValidateCursor(base64.RawURLEncoding.EncodeToString([]byte(`{"field":"age"}`)), request), ErrInvalidCursor)
+	injected, err := EncodeCursor([]CursorField{{Field: "age) OR (1=1", Direction: "desc", Value: 30}})
+	assert.NoError(t, err)
+	assert.ErrorIs(t, ValidateCursor(injected, PaginationRequest{}), ErrInvalidCursor)
+
+	// Sort mismatch
+	assert.ErrorIs(t, ValidateCursor(cursor, PaginationRequest{Sort: "age", Order: "asc"}), ErrCursorSortMismatch)
+	assert.ErrorIs(t, ValidateCursor(cursor, PaginationRequest{Sort: "name", Order: "desc"}), ErrCursorSortMismatch)
+}
+
+type testUserAgeGroup struct {
+	ID       uint   `json:"id"`
+	Name     string `json:"name"`
+	AgeGroup string `json:"age_group"`
+}
+
+func TestSelectRaw(t *testing.T) {
+	db := setupTestDB()
+
+	builder := NewSimpleQueryBuilder("test_users").
+		WithSelectRaw("CASE WHEN age < 30 THEN 'under_30' ELSE '30_plus' END", "age_group")
+
+	users, total, err := PaginatedQuery[testUserAgeGroup](db, builder, PaginationRequest{Page: 1, PerPage: 10}, []string{})
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(5), total)
+	assert.Equal(t, "John Doe", users[0].Name)
+	assert.Equal(t, "under_30", users[0].AgeGroup)
+	assert.Equal(t, "Jane Smith", users[1].Name)
+	assert.Equal(t, "30_plus", users[1].AgeGroup)
+
+	builder = NewSimpleQueryBuilder("test_users").
+		WithSelectRaw("age * 2", "age; DROP TABLE test_users")
+
+	_, _, err = PaginatedQuery[testUserAgeGroup](db, builder, PaginationRequest{Page: 1, PerPage: 10}, []string{})
+	assert.Error(t, err)
+}
+
+func TestUnknownSortField(t *testing.T) {
+	db := setupTestDB()
+
+	var failedQueries int
+	db.Callback().Query().After("gorm:query").Register("test:count_errors", func(tx *gorm.DB) {
+		if tx.Error != nil {
+			failedQueries++
+		}
+	})
+
+	pagination := PaginationRequest{Page: 1, PerPage: 10, Sort: "nonexistent_column", Order: "desc"}
+
+	// Without an allowlist the database error is caught and the default sort is used
+	users, total, err := PaginatedQuery[TestUser](db, NewSimpleQueryBuilder("test_users"), pagination, []string{})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(5), total)
+	assert.Equal(t, "John Doe", users[0].Name)
+	assert.Equal(t, 1, failedQueries)
+
+	// With an allowlist unknown fields are rejected before reaching the database
+	failedQueries = 0
+	builder := NewSimpleQueryBuilder("test_users").WithSortableFields("name", "age")
+
+	users, _, err = PaginatedQuery[TestUser](db, builder, pagination, []string{})
+	assert.NoError(t, err)
+	assert.Equal(t, "John Doe", users[0].Name)
+	assert.Equal(t, 0, failedQueries)
+
+	pagination.Sort = "age"
+	users, _, err = PaginatedQuery[TestUser](db, builder, pagination, []string{})
+	assert.NoError(t, err)
+	assert.Equal(t, "Bob Johnson", users[0].Name)
+}
+
+type TestEvent struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	Name      string    `json:"name"`
+	SportID   int       `json:"sport_id"`
+	StartDate time.Time `json:"start_date"`
+	EndDate   time.Time `json:"end_date"`
+}
+
+type testTaggedEventFilter struct {
+	BaseFilter
+	ID        int       `form:"id" filter:"id"`
+	Name      string    `form:"name" filter:"name,op=like"`
+	SportID   int       `form:"sport_id" filter:"sport_id"`
+	StartDate time.Time `form:"start_date" filter:"start_date,op=gte"`
+	EndDate   time.Time `form:"end_date" filter:"end_date,op=lte"`
+	Location  string    `form:"location"`
+}
+
+func (f *testTaggedEventFilter) ApplyFilters(query *gorm.DB) *gorm.DB {
+	return ApplyTaggedFilters(query, f)
+}
+
+func (f *testTaggedEventFilter) GetTableName() string      { return "test_events" }
+func (f *testTaggedEventFilter) GetSearchFields() []string { return []string{"name"} }
+func (f *testTaggedEventFilter) GetDefaultSort() string    { return "id asc" }
+
+type TestSport struct {
+	ID       uint        `json:"id" gorm:"primaryKey"`
+	Name     string      `json:"name"`
+	Category string      `json:"category"`
+	Events   []TestEvent `json:"events,omitempty" gorm:"foreignKey:SportID"`
+}
+
+func setupEventTestDB() *gorm.DB {
+	db, _ := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	db.AutoMigrate(&TestSport{}, &TestEvent{})
+
+	sports := []TestSport{
+		{Name: "Sepak Bola", Category: "Team Sport"},
+		{Name: "Basket", Category: "Team Sport"},
+		{Name: "Voli", Category: "Team Sport"},
+		{Name: "Badminton", Category: "Individual Sport"},
+		{Name: "Renang", Category: "Individual Sport"},
+	}
+	for _, sport := range sports {
+		db.Create(&sport)
+	}
+
+	events := []TestEvent{
+		{Name: "PON XXI Papua 2024", SportID: 1, StartDate: time.Date(2024, 10, 15, 0, 0, 0, 0, time.UTC), EndDate: time.Date(2024, 10, 30, 0, 0, 0, 0, time.UTC)},
+		{Name: "SEA Games 2023", SportID: 2, StartDate: time.Date(2023, 5, 12, 0, 0, 0, 0, time.UTC), EndDate: time.Date(2023, 5, 23, 0, 0, 0, 0, time.UTC)},
+		{Name: "Asian Games 2022", SportID: 3, StartDate: time.Date(2022, 9, 10, 0, 0, 0, 0, time.UTC), EndDate: time.Date(2022, 9, 25, 0, 0, 0, 0, time.UTC)},
+		{Name: "Pekan Olahraga Daerah 2024", SportID: 1, StartDate: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), EndDate: time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, event := range events {
+		db.Create(&event)
+	}
+
+	return db
+}
+
+func TestApplyTaggedFilters(t *testing.T) {
+	db := setupEventTestDB()
+	pagination := PaginationRequest{Page: 1, PerPage: 10}
+
+	tests := []struct {
+		name     string
+		filter   *testTaggedEventFilter
+		expected []string
+	}{
+		{"No filters", &testTaggedEventFilter{}, []string{"PON XXI Papua 2024", "SEA Games 2023", "Asian Games 2022", "Pekan Olahraga Daerah 2024"}},
+		{"ID equals", &testTaggedEventFilter{ID: 2}, []string{"SEA Games 2023"}},
+		{"Name like", &testTaggedEventFilter{Name: "Games"}, []string{"SEA Games 2023", "Asian Games 2022"}},
+		{"Sport equals", &testTaggedEventFilter{SportID: 1}, []string{"PON XXI Papua 2024", "Pekan Olahraga Daerah 2024"}},
+		{"Date range", &testTaggedEventFilter{
+			StartDate: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC),
+			EndDate:   time.Date(2024, 6, 30, 0, 0, 0, 0, time.UTC),
+		}, []string{"SEA Games 2023", "Pekan Olahraga Daerah 2024"}},
+		{"Untagged fields are ignored", &testTaggedEventFilter{Location: "Papua"}, []string{"PON XXI Papua 2024", "SEA Games 2023", "Asian Games 2022", "Pekan Olahraga Daerah 2024"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			events, total, err := PaginatedQuery[TestEvent](db, tt.filter, pagination, []string{})
+			assert.NoError(t, err)
+			assert.Equal(t, int64(len(tt.expected)), total)
+
+			names := make([]string, len(events))
+			for i, event := range events {
+				names[i] = event.Name
+			}
+			assert.Equal(t, tt.expected, names)
+		})
+	}
+}
+
+func TestIncludeThreshold(t *testing.T) {
+	db := setupRelationTestDB()
+
+	builder := NewSimpleQueryBuilder("test_provinces").WithIncludeThreshold(2)
+	builder.WithFilters(func(query *gorm.DB) *gorm.DB {
+		return query.Where("id = ?", 1)
+	})
+
+	provinces, paginationResponse, err := PaginateCore[TestProvince](db, &includingBuilder{builder, []string{"Athletes"}}, PaginationRequest{Page: 1, PerPage: 10})
+	assert.NoError(t, err)
+	assert.Empty(t, provinces[0].Athletes)
+	assert.Equal(t, []string{"Athletes"}, paginationResponse.SkippedIncludes)
+
+	provinces, paginationResponse, err = PaginateCore[TestProvince](db, &includingBuilder{builder, []string{"Athletes"}}, PaginationRequest{Page: 1, PerPage: 2})
+	assert.NoError(t, err)
+	assert.Len(t, provinces[0].Athletes, 3)
+	assert.Empty(t, paginationResponse.SkippedIncludes)
+}
+
+// includingBuilder attaches a fixed set of includes to a SimpleQueryBuilder
+type includingBuilder struct {
+	*SimpleQueryBuilder
+	includes []string
+}
+
+func (b *includingBuilder) GetIncludes() []string {
+	return b.includes
+}
+
+func TestOrderReversesDefaultSort(t *testing.T) {
+	db := setupTestDB()
+	gin.SetMode(gin.TestMode)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request, _ = http.NewRequest("GET", "/?order=desc", nil)
+
+	users, _, err := QuickPaginate[TestUser](db, c, "test_users")
+	assert.NoError(t, err)
+	assert.Equal(t, uint(5), users[0].ID)
+	assert.Equal(t, uint(1), users[4].ID)
+
+	c, _ = gin.CreateTestContext(w)
+	c.Request, _ = http.NewRequest("GET", "/?order=asc", nil)
+	users, _, err = QuickPaginate[TestUser](db, c, "test_users")
+	assert.NoError(t, err)
+	assert.Equal(t, uint(1), users[0].ID)
+
+	directed, ok := sortClauseWithDirection("age desc, id", "asc")
+	assert.True(t, ok)
+	assert.Equal(t, "age asc, id asc", directed)
+
+	directed, ok = sortClauseWithDirection("age desc, id", "desc")
+	assert.True(t, ok)
+	assert.Equal(t, "age desc, id desc", directed)
+
+	_, ok = sortClauseWithDirection("LENGTH(name) asc", "desc")
+	assert.False(t, ok)
+}
+
+func TestOrderAppliesToBuilderDefaultSort(t *testing.T) {
+	db := setupTestDB()
+	gin.SetMode(gin.TestMode)
+	builder := NewSimpleQueryBuilder("test_users").WithDefaultSort("age desc")
+
+	bind := func(url string) PaginationRequest {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request, _ = http.NewRequest("GET", url, nil)
+		return BindPagination(c)
+	}
+
+	// Without an order the default sort keeps its own direction
+	users, _, err := PaginatedQuery[TestUser](db, builder, bind("/"), []string{})
+	assert.NoError(t, err)
+	assert.Equal(t, 35, users[0].Age)
+	assert.Equal(t, 25, users[4].Age)
+	assert.Equal(t, []SortField{{Field: "age", Direction: "desc"}}, EffectiveSort(builder, bind("/")))
+
+	// An order parameter sets the direction of the default sort's columns
+	users, _, err = PaginatedQuery[TestUser](db, builder, bind("/?order=asc"), []string{})
+	assert.NoError(t, err)
+	assert.Equal(t, 25, users[0].Age)
+	assert.Equal(t, 35, users[4].Age)
+	assert.Equal(t, []SortField{{Field: "age", Direction: "asc"}}, EffectiveSort(builder, bind("/?order=asc")))
+
+	users, _, err = PaginatedQuery[TestUser](db, builder, bind("/?order=desc"), []string{})
+	assert.NoError(t, err)
+	assert.Equal(t, 35, users[0].Age)
+	assert.Equal(t, 25, users[4].Age)
+	assert.Equal(t, []SortField{{Field: "age", Direction: "desc"}}, EffectiveSort(builder, bind("/?order=desc")))
+
+	// Requests built in code or decoded from JSON carry no order parameter, so their
+	// Order leaves the default sort as configured
+	for _, pagination := range []PaginationRequest{
+		{Page: 1, PerPage: 10, Order: "asc"},
+		PaginationRequest{Page: 1, PerPage: 10, Order: "asc"}.Normalized(),
+	} {
+		users, _, err = PaginatedQuery[TestUser](db, builder, pagination, []string{})
+		assert.NoError(t, err)
+		assert.Equal(t, 35, users[0].Age)
+		assert.Equal(t, []SortField{{Field: "age", Direction: "desc"}}, EffectiveSort(builder, pagination))
+	}
+	var decoded PaginationRequest
+	assert.NoError(t, json.Unmarshal([]byte(`{"page":1,"per_page":10,"order":"asc"}`), &decoded))
+	assert.Equal(t, []SortField{{Field: "age", Direction: "desc"}}, EffectiveSort(builder, decoded))
+
+	// Filters bound by the helpers honor the order parameter
+	newContext := func(url string) *gin.Context {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request, _ = http.NewRequest("GET", url, nil)
+		return c
+	}
+	filter := &DynamicFilter{TableName: "test_users", Model: TestUser{}, DefaultSort: "age desc"}
+	response := PaginatedAPIResponseWithCustomFilter[TestUser](db, newContext("/?order=asc"), filter, "Success")
+	assert.Equal(t, 25, response.Data.([]TestUser)[0].Age)
+	filter = &DynamicFilter{TableName: "test_users", Model: TestUser{}, DefaultSort: "age desc"}
+	response = PaginatedAPIResponseWithCustomFilter[TestUser](db, newContext("/"), filter, "Success")
+	assert.Equal(t, 35, response.Data.([]TestUser)[0].Age)
+
+	// A default sort that is not a plain column list keeps its own direction
+	builder.WithDefaultSort("LENGTH(name) asc")
+	users, _, err = PaginatedQuery[TestUser](db, builder, bind("/?order=desc"), []string{})
+	assert.NoError(t, err)
+	assert.Equal(t, "John Doe", users[0].Name)
+}
+
+func TestForcedSort(t *testing.T) {
+	db := setupTestDB()
+	builder := NewSimpleQueryBuilder("test_users").WithForcedSort("age desc, id asc").WithWarnings()
+
+	for _, pagination := range []PaginationRequest{
+		{Page: 1, PerPage: 10},
+		{Page: 1, PerPage: 10, Sort: "name", Order: "asc"},
+		{Page: 1, PerPage: 10, Order: "desc"},
+	} {
+		users, paginationResponse, err := PaginateCore[TestUser](db, builder, pagination)
+		assert.NoError(t, err)
+		assert.Equal(t, []int{35, 32, 30, 28, 25}, []int{users[0].Age, users[1].Age, users[2].Age, users[3].Age, users[4].Age})
+		if pagination.Sort != "" {
+			assert.Equal(t, []string{"sort 'name' ignored, sort is fixed"}, paginationResponse.Warnings)
+		}
+	}
+
+	assert.Equal(t, []SortField{{Field: "age", Direction: "desc"}, {Field: "id", Direction: "asc"}},
+		EffectiveSort(builder, PaginationRequest{Sort: "name"}))
+
+	for _, clause := range []string{"age desc; DROP TABLE test_users", "LENGTH(name)", "age sideways", "age,"} {
+		assert.Panics(t, func() { NewSimpleQueryBuilder("test_users").WithForcedSort(clause) }, clause)
+	}
+}
+
+func TestRelationCountFilter(t *testing.T) {
+	db := setupEventTestDB()
+	pagination := PaginationRequest{Page: 1, PerPage: 10}
+
+	builder := NewSimpleQueryBuilder("test_sports").
+		WithModel(&TestSport{}).
+		WithRelationCountFilter("Events", ">=", 2)
+
+	sports, total, err := PaginatedQuery[TestSport](db, builder, pagination, []string{})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	assert.Equal(t, "Sepak Bola", sports[0].Name)
+
+	builder = NewSimpleQueryBuilder("test_sports").
+		WithModel(&TestSport{}).
+		WithRelationCountFilter("Events", "=", 0)
+
+	sports, total, err = PaginatedQuery[TestSport](db, builder, pagination, []string{})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+	assert.Len(t, sports, 2)
+
+	// A model is required to resolve the relation
+	builder = NewSimpleQueryBuilder("test_sports").
+		WithRelationCountFilter("Events", ">=", 2)
+
+	_, _, err = PaginatedQuery[TestSport](db, builder, pagination, []string{})
+	assert.Error(t, err)
+}
+
+func TestPaginatedRows(t *testing.T) {
+	db := setupTestDB()
+
+	builder := NewSimpleQueryBuilder("test_users").WithDefaultSort("age desc")
+
+	iterator, total, err := PaginatedRows[TestUser](db, builder, PaginationRequest{Page: 2, PerPage: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(5), total)
+
+	var names []string
+	for iterator.Next() {
+		names = append(names, iterator.Value().Name)
+	}
+
+	assert.NoError(t, iterator.Err())
+	assert.Equal(t, []string{"Jane Smith", "Alice Brown"}, names)
+	assert.False(t, iterator.Next())
+	assert.NoError(t, iterator.Close())
+
+	// Stopping early and closing releases the connection
+	iterator, _, err = PaginatedRows[TestUser](db, builder, PaginationRequest{Page: 1, PerPage: 5})
+	assert.NoError(t, err)
+	assert.True(t, iterator.Next())
+	assert.NoError(t, iterator.Close())
+	assert.False(t, iterator.Next())
+
+	_, total, err = PaginatedQuery[TestUser](db, builder, PaginationRequest{Page: 1, PerPage: 5}, []string{})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(5), total)
+}
+
+func TestLikeVariantOperators(t *testing.T) {
+	db := setupTestDB()
+	db.Create(&TestUser{Name: "100% Pure", Email: "pure@example.com", Age: 40})
+	db.Create(&TestUser{Name: "1000 Pure", Email: "thousand@example.com", Age: 41})
+	db.Create(&TestUser{Name: "Under_score", Email: "under@example.com", Age: 42})
+	db.Create(&TestUser{Name: "Underscore", Email: "underscore@example.com", Age: 43})
+
+	tests := []struct {
+		name     string
+		operator string
+		value    string
+		expected []string
+	}{
+		{"Starts with", "startswith", "Jo", []string{"John Doe"}},
+		{"Ends with", "endswith", "son", []string{"Bob Johnson", "Charlie Wilson"}},
+		{"Substring", "substring", "ohn", []string{"John Doe", "Bob Johnson"}},
+		{"Escaped percent", "substring", "0%", []string{"100% Pure"}},
+		{"Escaped underscore", "startswith", "Under_", []string{"Under_score"}},
+		{"Escaped escape character", "substring", "!", []string{}},
+		// contains takes the caller's own LIKE pattern, wildcards included
+		{"Contains pattern", "contains", "%ohn%", []string{"John Doe", "Bob Johnson"}},
+		{"Contains wildcards", "contains", "Under%score", []string{"Under_score", "Underscore"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter := &DynamicFilter{
+				TableName: "test_users",
+				Model:     TestUser{},
+				Filters:   []FilterCondition{{Field: "name", Operator: tt.operator, Value: tt.value}},
+			}
+
+			users, _, err := PaginatedQuery[TestUser](db, filter, PaginationRequest{Page: 1, PerPage: 20}, []string{})
+			assert.NoError(t, err)
+
+			names := []string{}
+			for _, user := range users {
+				names = append(names, user.Name)
+			}
+			assert.Equal(t, tt.expected, names)
+		})
+	}
+}
+
+func TestFilterConditionQueryParams(t *testing.T) {
+	db := setupTestDB()
+	gin.SetMode(gin.TestMode)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request, _ = http.NewRequest("GET", "/?name[startswith]=J&age[gte]=26&page=1", nil)
+
+	filter := &DynamicFilter{TableName: "test_users", Model: TestUser{}}
+	users, paginationResponse, err := PaginateWithCustomFilter[TestUser](db, c, filter)
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), paginationResponse.Total)
+	assert.Equal(t, "Jane Smith", users[0].Name)
+
+	conditions := ParseFilterConditions(map[string][]string{
+		"id[in]":  {"1,2"},
+		"age[gt]": {"30"},
+		"search":  {"ignored"},
+	})
+	assert.Equal(t, []FilterCondition{
+		{Field: "age", Operator: "GT", Value: "30", Logic: "AND"},
+		{Field: "id", Operator: "IN", Value: []string{"1", "2"}, Logic: "AND"},
+	}, conditions)
+}
+
+func TestMaxFilterConditions(t *testing.T) {
+	db := setupTestDB()
+	gin.SetMode(gin.TestMode)
+
+	SetMaxFilterConditions(2)
+	defer SetMaxFilterConditions(0)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request, _ = http.NewRequest("GET", "/?age[gte]=20&age[lte]=40&name[contains]=o", nil)
+
+	filter := &DynamicFilter{TableName: "test_users", Model: TestUser{}}
+	response := PaginatedAPIResponseWithCustomFilter[TestUser](db, c, filter, "Success")
+	assert.Equal(t, 400, response.Code)
+	assert.Contains(t, response.Message, "too many filter conditions")
+
+	_, err := ParseFilterConditionsLimited(c.Request.URL.Query())
+	assert.ErrorIs(t, err, ErrTooManyFilterConditions)
+
+	// Within the cap
+	c, _ = gin.CreateTestContext(w)
+	c.Request, _ = http.NewRequest("GET", "/?age[gte]=20&age[lte]=30", nil)
+	filter = &DynamicFilter{TableName: "test_users", Model: TestUser{}}
+	response = PaginatedAPIResponseWithCustomFilter[TestUser](db, c, filter, "Success")
+	assert.Equal(t, 200, response.Code)
+	assert.Equal(t, int64(3), response.Pagination.Total)
+
+	// Embedding filters overriding ValidateFilters and ApplyFilters can't shadow the cap
+	c, _ = gin.CreateTestContext(w)
+	c.Request, _ = http.NewRequest("GET", "/?age[gte]=20&age[lte]=40&name[contains]=o", nil)
+	ageFilter := &testAgeRangeFilter{DynamicFilter: DynamicFilter{TableName: "test_users", Model: TestUser{}}}
+	response = PaginatedAPIResponseWithCustomFilter[TestUser](db, c, ageFilter, "Success")
+	assert.Equal(t, 400, response.Code)
+	assert.Contains(t, response.Message, "too many filter conditions")
+
+	// Nor do callers skipping the helpers' binding run the query unfiltered
+	filter = &DynamicFilter{TableName: "test_users", Model: TestUser{}}
+	filter.BindPagination(c)
+	_, _, err = PaginateCore[TestUser](db, filter, filter.GetPagination())
+	assert.ErrorIs(t, err, ErrTooManyFilterConditions)
+	assert.Equal(t, 400, errorResponse(err).Code)
+}
+
+func TestFilterHash(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	bind := func(target string) *DynamicFilter {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request, _ = http.NewRequest("GET", target, nil)
+		filter := &DynamicFilter{}
+		filter.BindPagination(c)
+		return filter
+	}
+
+	hash := bind("/?age[gte]=18&age[lte]=30&id[in]=1,2,3&search=jo&sort=name&page=1").FilterHash()
+	assert.Len(t, hash, 64)
+
+	// Parameter order, value order of in and the page don't change the hash
+	assert.Equal(t, hash, bind("/?page=4&per_page=50&sort=name&search=jo&id[in]=3,1,2&age[lte]=30&age[gte]=18").FilterHash())
+	assert.Equal(t, hash, (&DynamicFilter{
+		BaseFilter: BaseFilter{Pagination: PaginationRequest{Search: "jo", Sort: "name", Order: "asc"}},
+		Filters: []FilterCondition{
+			{Field: "id", Operator: "in", Value: []string{"2", "3", "1"}},
+			{Field: "age", Operator: "<=", Value: "30"},
+			{Field: "age", Operator: "gte", Value: "18"},
+		},
+	}).FilterHash())
+
+	// Any change to the filters, search or sort does
+	for _, target := range []string{
+		"/?age[gte]=19&age[lte]=30&id[in]=1,2,3&search=jo&sort=name",
+		"/?age[gte]=18&age[lte]=30&id[in]=1,2&search=jo&sort=name",
+		"/?age[gt]=18&age[lte]=30&id[in]=1,2,3&search=jo&sort=name",
+		"/?age[gte]=18&age[lte]=30&id[in]=1,2,3&search=john&sort=name",
+		"/?age[gte]=18&age[lte]=30&id[in]=1,2,3&search=jo&sort=name&order=desc",
+		"/?age[gte]=18&age[lte]=30&id[in]=1,2,3&search=jo&sort=age",
+	} {
+		assert.NotEqual(t, hash, bind(target).FilterHash(), target)
+	}
+
+	// With OR, the order of conditions matters
+	age := FilterCondition{Field: "age", Operator: "gte", Value: "18"}
+	name := FilterCondition{Field: "name", Operator: "contains", Value: "jo", Logic: "OR"}
+	email := FilterCondition{Field: "email", Operator: "contains", Value: "example"}
+	assert.NotEqual(t,
+		(&DynamicFilter{Filters: []FilterCondition{age, name, email}}).FilterHash(),
+		(&DynamicFilter{Filters: []FilterCondition{email, name, age}}).FilterHash())
+}
+
+// testSportExclusionFilter excludes a category through a tagged ne_or_null filter
+type testSportExclusionFilter struct {
+	ExcludeCategory string `form:"exclude_category" filter:"category,op=ne_or_null"`
+}
+
+func TestNotEqualFilterKeepsNulls(t *testing.T) {
+	db := setupEventTestDB()
+	db.Exec("UPDATE test_sports SET category = NULL WHERE name = ?", "Renang")
+	gin.SetMode(gin.TestMode)
+
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request, _ = http.NewRequest("GET", "/?category[ne_or_null]=Individual%20Sport", nil)
+	filter := &DynamicFilter{TableName: "test_sports", Model: TestSport{}}
+	filter.BindPagination(c)
+
+	var sports []TestSport
+	err := filter.ApplyFilters(db.Table("test_sports")).Order("id").Find(&sports).Error
+	assert.NoError(t, err)
+	names := []string{}
+	for _, sport := range sports {
+		names = append(names, sport.Name)
+	}
+	// Renang has no category, which is not Individual Sport either
+	assert.Equal(t, []string{"Sepak Bola", "Basket", "Voli", "Renang"}, names)
+
+	// ne keeps its SQL semantics and excludes NULLs
+	c.Request, _ = http.NewRequest("GET", "/?category[ne]=Individual%20Sport", nil)
+	filter = &DynamicFilter{TableName: "test_sports", Model: TestSport{}}
+	filter.BindPagination(c)
+	sports = nil
+	err = filter.ApplyFilters(db.Table("test_sports")).Order("id").Find(&sports).Error
+	assert.NoError(t, err)
+	names = []string{}
+	for _, sport := range sports {
+		names = append(names, sport.Name)
+	}
+	assert.Equal(t, []string{"Sepak Bola", "Basket", "Voli"}, names)
+
+	sports = nil
+	err = ApplyTaggedFilters(db.Table("test_sports"), &testSportExclusionFilter{ExcludeCategory: "Team Sport"}).Order("id").Find(&sports).Error
+	assert.NoError(t, err)
+	assert.Len(t, sports, 2)
+	assert.Equal(t, "Badminton", sports[0].Name)
+	assert.Equal(t, "Renang", sports[1].Name)
+
+	applied, err := (&DynamicFilter{TableName: "test_sports", Model: TestSport{}, Filters: []FilterCondition{{Field: "category", Operator: "ne_or_null", Value: "Team Sport"}}}).AppliedConditions()
+	assert.NoError(t, err)
+	assert.Equal(t, "(category <> ? OR category IS NULL)", applied[0].SQL)
+
+	applied, err = (&DynamicFilter{TableName: "test_sports", Model: TestSport{}, Filters: []FilterCondition{{Field: "category", Operator: "<>", Value: "Team Sport"}}}).AppliedConditions()
+	assert.NoError(t, err)
+	assert.Equal(t, "category != ?", applied[0].SQL)
+}
+
+func TestNewDynamicFilterFromMap(t *testing.T) {
+	db := setupTestDB()
+
+	allowed := map[string]string{"name": "substring", "age": "gte", "id": "in"}
+	filter := NewDynamicFilterFromMap("test_users", allowed, map[string]interface{}{
+		"name":      "o",
+		"age":       28,
+		"id":        "1,3,4,5",
+		"email; --": "x",
+		"password":  "secret",
+	})
+
+	assert.Equal(t, []FilterCondition{
+		{Field: "age", Operator: "GTE", Value: 28, Logic: "AND"},
+		{Field: "id", Operator: "IN", Value: []string{"1", "3", "4", "5"}, Logic: "AND"},
+		{Field: "name", Operator: "SUBSTRING", Value: "o", Logic: "AND"},
+	}, filter.Filters)
+
+	var users []TestUser
+	err := filter.ApplyFilters(db.Table(filter.GetTableName())).Order("id").Find(&users).Error
+	assert.NoError(t, err)
+	assert.Len(t, users, 3)
+	assert.Equal(t, "Bob Johnson", users[0].Name)
+	assert.Equal(t, "Alice Brown", users[1].Name)
+
+	// Only allowlisted fields are filterable, whatever their source
+	filter.Filters = append(filter.Filters, FilterCondition{Field: "email", Operator: "=", Value: "x", Logic: "AND"})
+	users = nil
+	err = filter.ApplyFilters(db.Table(filter.GetTableName())).Find(&users).Error
+	assert.NoError(t, err)
+	assert.Len(t, users, 3)
+}
+
+func TestCountExpression(t *testing.T) {
+	tests := []struct {
+		name       string
+		expression string
+		expected   string
+		total      int64
+	}{
+		{"Default", "", "count(*)", 5},
+		{"Constant", "count(1)", "COUNT(1)", 5},
+		{"Column", "COUNT(id)", "COUNT(id)", 5},
+		{"Distinct column", "count( distinct test_users.age )", "COUNT(DISTINCT test_users.age)", 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := setupTestDB()
+
+			var statements []string
+			db.Callback().Query().After("gorm:query").Register("test:record_sql", func(tx *gorm.DB) {
+				statements = append(statements, tx.Statement.SQL.String())
+			})
+
+			builder := NewSimpleQueryBuilder("test_users").WithCountExpression(tt.expression)
+			total, err := CountOnly(db, builder, PaginationRequest{Page: 1, PerPage: 10})
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.total, total)
+			assert.Len(t, statements, 1)
+			assert.True(t, strings.HasPrefix(statements[0], "SELECT "+tt.expected+" FROM"), statements[0])
+		})
+	}
+
+	db := setupTestDB()
+	for _, expression := range []string{"SUM(age)", "COUNT(*) FROM users; --", "COUNT(DISTINCT *)", "COUNT(age + 1)"} {
+		builder := NewSimpleQueryBuilder("test_users").WithCountExpression(expression)
+		_, _, err := PaginatedQuery[TestUser](db, builder, PaginationRequest{Page: 1, PerPage: 10}, []string{})
+		assert.ErrorIs(t, err, ErrInvalidCountExpression, expression)
+	}
+}
+
+func TestGroupedResponse(t *testing.T) {
+	db := setupTestDB()
+	gin.SetMode(gin.TestMode)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request, _ = http.NewRequest("GET", "/?per_page=10", nil)
+
+	response := PaginatedAPIResponse[TestUser](db, c, "test_users", []string{"name"}, "Success")
+	response = WithGroupedResponse(response, func(user TestUser) string {
+		return strconv.Itoa(user.Age/10*10) + "s"
+	})
+
+	assert.Len(t, response.Data, 5)
+
+	groups, ok := response.Groups.([]PageGroup[TestUser])
+	assert.True(t, ok)
+	assert.Len(t, groups, 2)
+	assert.Equal(t, "20s", groups[0].Key)
+	assert.Equal(t, []string{"John Doe", "Alice Brown"}, []string{groups[0].Items[0].Name, groups[0].Items[1].Name})
+	assert.Equal(t, "30s", groups[1].Key)
+	assert.Len(t, groups[1].Items, 3)
+
+	body, err := json.Marshal(response)
+	assert.NoError(t, err)
+	assert.Contains(t, string(body), `"groups":[{"key":"20s","items":[`)
+
+	// Responses without grouping omit the field
+	body, err = json.Marshal(NewPaginatedResponse(200, "Success", []TestUser{}, PaginationResponse{}))
+	assert.NoError(t, err)
+	assert.NotContains(t, string(body), "groups")
+}
+
+func TestBindingErrorResponse(t *testing.T) {
+	db := setupTestDB()
+	gin.SetMode(gin.TestMode)
+
+	newFilter := func() *DynamicFilter {
+		return &DynamicFilter{TableName: "test_users", Model: TestUser{}}
+	}
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request, _ = http.NewRequest("GET", "/?page=abc", nil)
+
+	_, _, err := PaginateWithCustomFilter[TestUser](db, c, newFilter())
+	var paramsErr *QueryParamsError
+	assert.ErrorAs(t, err, &paramsErr)
+
+	response := PaginatedAPIResponseWithCustomFilter[TestUser](db, c, newFilter(), "Success")
+	assert.Equal(t, 400, response.Code)
+	assert.Equal(t, "error", response.Status)
+	assert.True(t, strings.HasPrefix(response.Message, "Invalid query parameters: "), response.Message)
+
+	// Database errors are still server errors
+	w = httptest.NewRecorder()
+	c, _ = gin.CreateTestContext(w)
+	c.Request, _ = http.NewRequest("GET", "/?page=1", nil)
+
+	filter := newFilter()
+	filter.TableName = "missing_table"
+	response = PaginatedAPIResponseWithCustomFilter[TestUser](db, c, filter, "Success")
+	assert.Equal(t, 500, response.Code)
+}
+
+func TestCaseInsensitiveEq(t *testing.T) {
+	db := setupEventTestDB()
+
+	var sports []TestSport
+	err := CaseInsensitiveEq(db.Model(&TestSport{}), "category", "team SPORT", SQLite).Find(&sports).Error
+	assert.NoError(t, err)
+	assert.Len(t, sports, 3)
+
+	tests := []struct {
+		dialect  DatabaseDialect
+		value    string
+		expected string
+		arg      interface{}
+	}{
+		{MySQL, "Team Sport", "WHERE LOWER(category) = LOWER(?)", "Team Sport"},
+		{SQLite, "Team Sport", "WHERE LOWER(category) = LOWER(?)", "Team Sport"},
+		{SQLServer, "Team Sport", "WHERE LOWER(category) = LOWER(?)", "Team Sport"},
+		{PostgreSQL, "Team Sport", "WHERE category ILIKE ? ESCAPE '!'", "Team Sport"},
+		{PostgreSQL, "100%_fun", "WHERE category ILIKE ? ESCAPE '!'", "100!%!_fun"},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.dialect), func(t *testing.T) {
+			stmt := CaseInsensitiveEq(db.Session(&gorm.Session{DryRun: true}).Model(&TestSport{}), "category", tt.value, tt.dialect).
+				Find(&[]TestSport{}).Statement
+			assert.Contains(t, stmt.SQL.String(), tt.expected)
+			assert.Equal(t, []interface{}{tt.arg}, stmt.Vars)
+		})
+	}
+
+	// Invalid columns are ignored
+	stmt := CaseInsensitiveEq(db.Session(&gorm.Session{DryRun: true}).Model(&TestSport{}), "category) OR (1=1", "x", SQLite).
+		Find(&[]TestSport{}).Statement
+	assert.NotContains(t, stmt.SQL.String(), "WHERE")
+}
+
+func TestTaggedCaseInsensitiveFilter(t *testing.T) {
+	db := setupEventTestDB()
+
+	type sportFilter struct {
+		Category string `filter:"category,op=ieq"`
+	}
+
+	var sports []TestSport
+	err := ApplyTaggedFilters(db.Model(&TestSport{}), &sportFilter{Category: "individual sport"}).Find(&sports).Error
+	assert.NoError(t, err)
+	assert.Len(t, sports, 2)
+}
+
+func TestTaggedFilterUnknownOperator(t *testing.T) {
+	db := setupEventTestDB()
+
+	type sportFilter struct {
+		Name     string `filter:"name"`
+		Category string `filter:"category,op=equals"`
+	}
+
+	var sports []TestSport
+	err := ApplyTaggedFilters(db.Model(&TestSport{}), &sportFilter{}).Find(&sports).Error
+	assert.ErrorIs(t, err, ErrInvalidFilterTag)
+	assert.ErrorContains(t, err, "Category")
+
+	err = ApplyTaggedFilters(db.Model(&TestSport{}), &sportFilter{Category: "Team Sport"}).Find(&sports).Error
+	assert.ErrorIs(t, err, ErrInvalidFilterTag)
+}
+
+func TestResponseWarnings(t *testing.T) {
+	db := setupRelationTestDB()
+
+	builder := NewSimpleQueryBuilder("test_provinces").WithWarnings()
+	provinces, paginationResponse, err := PaginateCore[TestProvince](db,
+		&includingBuilder{builder, []string{"Athletes", "Secret;--"}},
+		PaginationRequest{Page: 1, PerPage: 10, Sort: "name; DROP TABLE test_provinces"})
+	assert.NoError(t, err)
+	assert.Len(t, provinces[0].Athletes, 3)
+	assert.Equal(t, []string{
+		"include 'Secret;--' ignored",
+		"sort 'name; DROP TABLE test_provinces' invalid, using default",
+	}, paginationResponse.Warnings)
+
+	// A sort the database rejects falls back with a warning
+	_, paginationResponse, err = PaginateCore[TestProvince](db, builder, PaginationRequest{Page: 1, PerPage: 10, Sort: "missing_column"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"sort 'missing_column' failed, using default"}, paginationResponse.Warnings)
+
+	// An honored request has an empty list of warnings
+	_, paginationResponse, err = PaginateCore[TestProvince](db, builder, PaginationRequest{Page: 1, PerPage: 10, Sort: "name"})
+	assert.NoError(t, err)
+	assert.Empty(t, paginationResponse.Warnings)
+
+	// Warnings are off by default
+	_, paginationResponse, err = PaginateCore[TestProvince](db,
+		&includingBuilder{NewSimpleQueryBuilder("test_provinces"), []string{"Secret;--"}},
+		PaginationRequest{Page: 1, PerPage: 10, Sort: "missing_column"})
+	assert.NoError(t, err)
+	assert.Nil(t, paginationResponse.Warnings)
+}
+
+func TestPositions(t *testing.T) {
+	db := setupTestDB()
+	gin.SetMode(gin.TestMode)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request, _ = http.NewRequest("GET", "/?page=2&per_page=2", nil)
+
+	response := WithPositions(PaginatedAPIResponse[TestUser](db, c, "test_users", []string{"name"}, "Success"))
+	assert.Equal(t, []int64{3, 4}, response.Positions)
+
+	type rankedUser struct {
+		TestUser
+		Position int64 `json:"position" gorm:"-"`
+	}
+
+	pagination := PaginationRequest{Page: 2, PerPage: 2}
+	users, _, err := PaginatedQuery[rankedUser](db, NewSimpleQueryBuilder("test_users"), pagination, []string{})
+	assert.NoError(t, err)
+
+	AssignPositions(users, pagination, func(user *rankedUser, position int64) {
+		user.Position = position
+	})
+	assert.Equal(t, "Bob Johnson", users[0].Name)
+	assert.Equal(t, int64(3), users[0].Position)
+	assert.Equal(t, int64(4), users[1].Position)
+
+	// Disabled pagination starts at the first position
+	assert.Equal(t, []int64{1, 2, 3}, PagePositions(PaginationRequest{Page: 3, PerPage: 2, IsDisabled: true}, 3))
+}
+
+func TestPageBounds(t *testing.T) {
+	db := setupTestDB()
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name string
+		url  string
+		from int64
+		to   int64
+	}{
+		{"Full page", "/?page=1&per_page=2", 1, 2},
+		{"Middle page", "/?page=2&per_page=2", 3, 4},
+		{"Partial last page", "/?page=3&per_page=2", 5, 5},
+		{"Empty page", "/?page=4&per_page=2", 0, 0},
+		{"Empty search", "/?search=nobody", 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request, _ = http.NewRequest("GET", tt.url, nil)
+
+			response := WithPageBounds(PaginatedAPIResponse[TestUser](db, c, "test_users", []string{"name"}, "Success"))
+			assert.Equal(t, tt.from, response.Pagination.From)
+			assert.Equal(t, tt.to, response.Pagination.To)
+			assert.LessOrEqual(t, response.Pagination.To, response.Pagination.Total)
+		})
+	}
+
+	from, to := PageBounds(PaginationRequest{Page: 3, PerPage: 2, IsDisabled: true}, 5)
+	assert.Equal(t, []int64{1, 5}, []int64{from, to})
+}
+
+type TestPerson struct {
+	ID        uint    `json:"id" gorm:"primaryKey"`
+	FirstName string  `json:"first_name"`
+	LastName  *string `json:"last_name"`
+}
+
+func TestConcatSearchField(t *testing.T) {
+	db, _ := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	db.AutoMigrate(&TestPerson{})
+	santoso, wijaya := "Santoso", "Wijaya"
+	db.Create(&[]TestPerson{{FirstName: "Budi", LastName: &santoso}, {FirstName: "Susi", LastName: &wijaya}, {FirstName: "Budiman"}})
+
+	builder := NewSimpleQueryBuilder("test_people").WithConcatSearchField("first_name", "last_name")
+	options := PaginatedQueryOptions{Dialect: SQLite}
+
+	people, total, err := PaginatedQueryWithOptions[TestPerson](db, builder, PaginationRequest{Page: 1, PerPage: 10, Search: "budi sant"}, []string{}, options)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	assert.Equal(t, "Budi", people[0].FirstName)
+
+	// A missing last name doesn't hide the first name
+	_, total, err = PaginatedQueryWithOptions[TestPerson](db, builder, PaginationRequest{Page: 1, PerPage: 10, Search: "budiman"}, []string{}, options)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+
+	builder.WithSearchFields("first_name")
+	tests := []struct {
+		dialect  DatabaseDialect
+		expected string
+	}{
+		{MySQL, "(first_name LIKE ? OR CONCAT(COALESCE(first_name, ''), ' ', COALESCE(last_name, '')) LIKE ?)"},
+		{PostgreSQL, "(first_name ILIKE ? OR (COALESCE(first_name, '') || ' ' || COALESCE(last_name, '')) ILIKE ?)"},
+		{SQLite, "(first_name LIKE ? OR (COALESCE(first_name, '') || ' ' || COALESCE(last_name, '')) LIKE ?)"},
+		{SQLServer, "(first_name LIKE ? OR CONCAT(COALESCE(first_name, ''), ' ', COALESCE(last_name, '')) LIKE ?)"},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.dialect), func(t *testing.T) {
+			stmt := applySearch(db.Session(&gorm.Session{DryRun: true}).Table("test_people"), builder, "budi", tt.dialect).
+				Find(&[]TestPerson{}).Statement
+			assert.Contains(t, stmt.SQL.String(), tt.expected)
+			assert.Len(t, stmt.Vars, 2)
+		})
+	}
+}
+
+type TestReservedWord struct {
+	ID    uint   `json:"id" gorm:"primaryKey"`
+	Order int    `json:"order" gorm:"column:order"`
+	Group string `json:"group" gorm:"column:group"`
+}
+
+func TestQuotedIdentifiers(t *testing.T) {
+	db, _ := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	db.AutoMigrate(&TestReservedWord{})
+	db.Create(&[]TestReservedWord{{Order: 2, Group: "beta"}, {Order: 3, Group: "alpha"}, {Order: 1, Group: "gamma"}})
+
+	builder := NewSimpleQueryBuilder("test_reserved_words").
+		WithSearchFields("group").
+		WithDefaultSort("order asc").
+		WithQuotedIdentifiers()
+	options := PaginatedQueryOptions{Dialect: SQLite}
+
+	rows, _, err := PaginatedQueryWithOptions[TestReservedWord](db, builder, PaginationRequest{Page: 1, PerPage: 10, Sort: "order", Order: "desc"}, []string{}, options)
+	assert.NoError(t, err)
+	assert.Equal(t, []int{3, 2, 1}, []int{rows[0].Order, rows[1].Order, rows[2].Order})
+
+	rows, _, err = PaginatedQueryWithOptions[TestReservedWord](db, builder, PaginationRequest{Page: 1, PerPage: 10}, []string{}, options)
+	assert.NoError(t, err)
+	assert.Equal(t, []int{1, 2, 3}, []int{rows[0].Order, rows[1].Order, rows[2].Order})
+
+	rows, total, err := PaginatedQueryWithOptions[TestReservedWord](db, builder, PaginationRequest{Page: 1, PerPage: 10, Search: "alp"}, []string{}, options)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	assert.Equal(t, "alpha", rows[0].Group)
+
+	tests := []struct {
+		dialect  DatabaseDialect
+		expected string
+	}{
+		{MySQL, "ORDER BY `test_reserved_words`.`order` desc"},
+		{PostgreSQL, `ORDER BY "test_reserved_words"."order" desc`},
+		{SQLite, `ORDER BY "test_reserved_words"."order" desc`},
+		{SQLServer, "ORDER BY [test_reserved_words].[order] desc"},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.dialect), func(t *testing.T) {
+			query := applySorting(db.Session(&gorm.Session{DryRun: true}).Table("test_reserved_words"), builder,
+				PaginationRequest{Sort: "test_reserved_words.order", Order: "desc"}, PaginatedQueryOptions{Dialect: tt.dialect}, []string{"id"})
+			stmt := query.Find(&[]TestReservedWord{}).Statement
+			assert.Contains(t, stmt.SQL.String(), tt.expected)
+		})
+	}
+
+	assert.Equal(t, "`group`", quoteIdentifier("group", MySQL))
+	assert.Equal(t, "[group]", quoteIdentifier(`"group"`, SQLServer))
+	assert.Equal(t, "LENGTH(name)", quoteIdentifier("LENGTH(name)", PostgreSQL))
+	assert.Equal(t, `"order" desc, "id" asc`, quoteSortClause("order desc,id asc", SQLite))
+}
+
+func TestCastSearchFields(t *testing.T) {
+	db := setupTestDB()
+
+	builder := NewSimpleQueryBuilder("test_users").
+		WithSearchFields("name", "email").
+		WithCastSearchFields("age")
+	assert.Equal(t, []string{"name", "email", "age"}, builder.GetSearchFields())
+
+	users, total, err := PaginatedQuery[TestUser](db, builder, PaginationRequest{Page: 1, PerPage: 10, Search: "25"}, []string{})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	assert.Equal(t, "John Doe", users[0].Name)
+
+	// Without the cast only text columns are searched
+	_, total, err = PaginatedQuery[TestUser](db, NewSimpleQueryBuilder("test_users").WithSearchFields("name", "email"),
+		PaginationRequest{Page: 1, PerPage: 10, Search: "25"}, []string{})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), total)
+
+	tests := []struct {
+		dialect  DatabaseDialect
+		expected string
+	}{
+		{MySQL, "CAST(age AS CHAR) LIKE ?"},
+		{PostgreSQL, "CAST(age AS TEXT) ILIKE ?"},
+		{SQLite, "CAST(age AS TEXT) LIKE ?"},
+		{SQLServer, "CAST(age AS NVARCHAR(MAX)) LIKE ?"},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.dialect), func(t *testing.T) {
+			stmt := applySearch(db.Session(&gorm.Session{DryRun: true}).Table("test_users"), builder, "25", tt.dialect).
+				Find(&[]TestUser{}).Statement
+			assert.Contains(t, stmt.SQL.String(), "name "+getSearchOperator(tt.dialect)+" ?")
+			assert.Contains(t, stmt.SQL.String(), tt.expected)
+		})
+	}
+}
+
+func TestImpossibleConditionsShortCircuit(t *testing.T) {
+	db := setupTestDB()
+
+	var statements []string
+	db.Callback().Query().After("gorm:query").Register("test:record_sql", func(tx *gorm.DB) {
+		statements = append(statements, tx.Statement.SQL.String())
+	})
+
+	tests := []struct {
+		name       string
+		conditions []FilterCondition
+		impossible bool
+	}{
+		{"Empty IN", []FilterCondition{{Field: "id", Operator: "in", Value: []int{}}}, true},
+		{"Empty IN from params", []FilterCondition{{Field: "id", Operator: "IN", Value: []string{}}}, true},
+		{"Disjoint range", []FilterCondition{{Field: "age", Operator: "gt", Value: 30}, {Field: "age", Operator: "lt", Value: "20"}}, true},
+		{"Empty half-open range", []FilterCondition{{Field: "age", Operator: "gte", Value: 30}, {Field: "age", Operator: "lt", Value: 30}}, true},
+		{"Different equalities", []FilterCondition{{Field: "name", Operator: "eq", Value: "John Doe"}, {Field: "name", Operator: "eq", Value: "Jane Smith"}}, true},
+		{"Equal and not equal", []FilterCondition{{Field: "name", Operator: "eq", Value: "John Doe"}, {Field: "name", Operator: "ne", Value: "John Doe"}}, true},
+		{"Null and not equal", []FilterCondition{{Field: "name", Operator: "is_null", Value: true}, {Field: "name", Operator: "ne", Value: "John Doe"}}, true},
+		{"Null and not equal or null", []FilterCondition{{Field: "name", Operator: "is_null", Value: true}, {Field: "name", Operator: "ne_or_null", Value: "John Doe"}}, false},
+		{"Equality outside range", []FilterCondition{{Field: "age", Operator: "eq", Value: 25}, {Field: "age", Operator: "gt", Value: 25}}, true},
+		{"Touching inclusive range", []FilterCondition{{Field: "age", Operator: "gte", Value: 30}, {Field: "age", Operator: "lte", Value: 30}}, false},
+		{"Equalities differing in case", []FilterCondition{{Field: "name", Operator: "eq", Value: "john doe"}, {Field: "name", Operator: "eq", Value: "JOHN DOE"}}, false},
+		{"OR is not analyzed", []FilterCondition{{Field: "age", Operator: "gt", Value: 30}, {Field: "age", Operator: "lt", Value: 20, Logic: "OR"}}, false},
+		{"Different fields", []FilterCondition{{Field: "age", Operator: "gt", Value: 30}, {Field: "id", Operator: "lt", Value: 20}}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter := &DynamicFilter{TableName: "test_users", Model: TestUser{}, Filters: tt.conditions}
+			assert.Equal(t, tt.impossible, filter.HasImpossibleConditions())
+
+			statements = nil
+			users, total, err := PaginatedQuery[TestUser](db, filter, PaginationRequest{Page: 1, PerPage: 10}, []string{})
+			assert.NoError(t, err)
+			if tt.impossible {
+				assert.Empty(t, statements)
+				assert.Equal(t, []TestUser{}, users)
+				assert.Equal(t, int64(0), total)
+			} else {
+				assert.NotEmpty(t, statements)
+			}
+		})
+	}
+}
+
+func TestAppliedConditions(t *testing.T) {
+	filter := &DynamicFilter{
+		TableName: "test_users",
+		Model:     TestUser{},
+		Filters: []FilterCondition{
+			{Field: "age", Operator: "gte", Value: 25},
+			{Field: "password", Operator: "eq", Value: "ignored"},
+			{Field: "name", Operator: "startswith", Value: "J_", Logic: "OR"},
+			{Field: "email", Operator: "is_not_null", Value: true},
+			{Field: "id", Operator: "in", Value: []int{1, 2}},
+		},
+	}
+
+	applied, err := filter.AppliedConditions()
+	assert.NoError(t, err)
+	assert.Equal(t, []AppliedCondition{
+		{SQL: "age >= ?", Args: []interface{}{25}, Logic: "AND"},
+		{SQL: "name LIKE ? ESCAPE '!'", Args: []interface{}{"J!_%"}, Logic: "OR"},
+		{SQL: "email IS NOT NULL", Logic: "AND"},
+		{SQL: "id IN ?", Args: []interface{}{[]int{1, 2}}, Logic: "AND"},
+	}, applied)
+
+	RegisterPreset("test_adults", []FilterCondition{{Field: "age", Operator: "gte", Value: 18}})
+	filter = &DynamicFilter{
+		TableName: "test_users",
+		Model:     TestUser{},
+		Preset:    "test_adults",
+		Filters:   []FilterCondition{{Field: "name", Operator: "eq", Value: "Jane Smith"}},
+	}
+	applied, err = filter.AppliedConditions()
+	assert.NoError(t, err)
+	assert.Equal(t, []AppliedCondition{
+		{SQL: "age >= ?", Args: []interface{}{18}, Logic: "AND"},
+		{SQL: "name = ?", Args: []interface{}{"Jane Smith"}, Logic: "AND"},
+	}, applied)
+
+	// Nothing is applied without conditions
+	applied, err = (&DynamicFilter{Model: TestUser{}}).AppliedConditions()
+	assert.NoError(t, err)
+	assert.Empty(t, applied)
+}
+
+func TestBaseFilterDefaultSort(t *testing.T) {
+	db := setupTestDB()
+	defer SetDefaultSort("")
+
+	type inheritingFilter struct {
+		DynamicFilter
+	}
+	filter := &inheritingFilter{DynamicFilter{TableName: "test_users", Model: TestUser{}}}
+	assert.Equal(t, "id asc", filter.GetDefaultSort())
+
+	SetDefaultSort("age desc")
+	users, _, err := PaginatedQuery[TestUser](db, filter, PaginationRequest{Page: 1, PerPage: 10}, []string{})
+	assert.NoError(t, err)
+	assert.Equal(t, "Bob Johnson", users[0].Name)
+
+	// A filter's own default sort takes precedence
+	filter.BaseFilter.DefaultSort = "name asc"
+	assert.Equal(t, "name asc", filter.GetDefaultSort())
+	filter.DynamicFilter.DefaultSort = "email desc"
+	assert.Equal(t, "email desc", filter.GetDefaultSort())
+
+	base := &BaseFilter{}
+	assert.Equal(t, "age desc", base.GetDefaultSort())
+}
+
+func TestApplyNullableIntFilter(t *testing.T) {
+	db := setupEventTestDB()
+	db.Create(&TestEvent{Name: "Unassigned Event"})
+	db.Exec("UPDATE test_events SET sport_id = NULL WHERE name = ?", "Unassigned Event")
+
+	sportID := 1
+	isNull, isNotNull := true, false
+
+	tests := []struct {
+		name     string
+		value    *int
+		isNull   *bool
+		expected int
+	}{
+		{"Value", &sportID, nil, 2},
+		{"Value takes precedence", &sportID, &isNull, 2},
+		{"Null", nil, &isNull, 1},
+		{"Not null", nil, &isNotNull, 4},
+		{"Unset", nil, nil, 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var events []TestEvent
+			err := ApplyNullableIntFilter(db.Model(&TestEvent{}), "sport_id", tt.value, tt.isNull).Find(&events).Error
+			assert.NoError(t, err)
+			assert.Len(t, events, tt.expected)
+		})
+	}
+}
+
+func TestCursorFromPage(t *testing.T) {
+	db := setupTestDB()
+	builder := NewSimpleQueryBuilder("test_users")
+
+	pagination := PaginationRequest{Page: 2, PerPage: 2, Sort: "age", Order: "desc"}
+	users, _, err := PaginatedQuery[TestUser](db, builder, pagination, []string{})
+	assert.NoError(t, err)
+
+	cursor, err := CursorFromPage(db, builder, pagination, users)
+	assert.NoError(t, err)
+
+	fields, err := DecodeCursor(cursor, EffectiveSort(builder, pagination))
+	assert.NoError(t, err)
+	assert.Equal(t, []CursorField{
+		{Field: "age", Direction: "desc", Value: int64(28)},
+		{Field: "id", Direction: "desc", Value: int64(4), Tiebreaker: true},
+	}, fields)
+
+	// Without a sort the cursor follows the default sort
+	pagination = PaginationRequest{Page: 1, PerPage: 3}
+	users, _, err = PaginatedQuery[TestUser](db, builder, pagination, []string{})
+	assert.NoError(t, err)
+
+	cursor, err = CursorFromPage(db, builder, pagination, users)
+	assert.NoError(t, err)
+	fields, err = DecodeCursor(cursor, []SortField{{Field: "id", Direction: "asc"}})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(users[2].ID), fields[0].Value)
+
+	cursor, err = CursorFromPage(db, builder, pagination, []TestUser{})
+	assert.NoError(t, err)
+	assert.Empty(t, cursor)
+
+	_, err = CursorFromPage(db, NewSimpleQueryBuilder("test_users").WithDefaultSort("missing asc"), pagination, users)
+	assert.Error(t, err)
+}
+
+type TestPost struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	Title     string    `json:"title"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func TestCursorTimestampsSharingASecond(t *testing.T) {
+	db, _ := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	db.AutoMigrate(&TestPost{})
+
+	// Twelve posts within the same second, several sharing the exact microsecond
+	base := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	for i := 0; i < 12; i++ {
+		db.Create(&TestPost{Title: "Post " + strconv.Itoa(i), CreatedAt: base.Add(time.Duration(i/3*1500) * time.Microsecond)})
+	}
+
+	for _, builder := range []*SimpleQueryBuilder{
+		NewSimpleQueryBuilder("test_posts").WithDefaultSort("created_at desc"),
+		NewSimpleQueryBuilder("test_posts").WithDefaultSort("created_at desc").WithCursorTimeLayout("2006-01-02 15:04:05.999999999-07:00"),
+	} {
+		pagination := PaginationRequest{Page: 1, PerPage: 5}
+		var posts []TestPost
+		assert.NoError(t, db.Order("created_at desc, id desc").Limit(5).Find(&posts).Error)
+
+		seen := map[uint]bool{}
+		for len(posts) > 0 {
+			for _, post := range posts {
+				assert.False(t, seen[post.ID], "post %d repeated", post.ID)
+				seen[post.ID] = true
+			}
+
+			cursor, err := CursorFromPage(db, builder, pagination, posts)
+			assert.NoError(t, err)
+			fields, err := DecodeCursor(cursor, EffectiveSort(builder, pagination))
+			assert.NoError(t, err)
+			assert.Len(t, fields, 2)
+			assert.True(t, fields[1].Tiebreaker)
+
+			condition, args, err := CursorCondition(fields)
+			assert.NoError(t, err)
+			posts = nil
+			assert.NoError(t, db.Where(condition, args...).Order(CursorOrder(fields)).Limit(5).Find(&posts).Error)
+		}
+		assert.Len(t, seen, 12)
+	}
+
+	// Timestamps keep their sub-second precision in cursors
+	cursor, err := EncodeCursor([]CursorField{cursorField(SortField{Field: "created_at", Direction: "asc"}, base.Add(1500*time.Microsecond), "")})
+	assert.NoError(t, err)
+	fields, err := DecodeCursor(cursor, []SortField{{Field: "created_at", Direction: "asc"}})
+	assert.NoError(t, err)
+	assert.Equal(t, base.Add(1500*time.Microsecond), fields[0].Value)
+
+	condition, _, err := CursorCondition(fields)
+	assert.NoError(t, err)
+	assert.Equal(t, "((created_at > ?))", condition)
+
+	_, _, err = CursorCondition([]CursorField{{Field: "id; DROP TABLE test_posts", Direction: "asc", Value: 1}})
+	assert.ErrorIs(t, err, ErrInvalidCursor)
+}
+
+func TestRangeFilter(t *testing.T) {
+	db := setupTestDB()
+	gin.SetMode(gin.TestMode)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request, _ = http.NewRequest("GET", "/?age[range]=24-26&age[range]=30-32&sort=age", nil)
+
+	filter := &DynamicFilter{TableName: "test_users", Model: TestUser{}}
+	users, paginationResponse, err := PaginateWithCustomFilter[TestUser](db, c, filter)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), paginationResponse.Total)
+	assert.Equal(t, []int{25, 30, 32}, []int{users[0].Age, users[1].Age, users[2].Age})
+
+	applied, err := filter.AppliedConditions()
+	assert.NoError(t, err)
+	assert.Equal(t, []AppliedCondition{{
+		SQL:   "((age BETWEEN ? AND ?) OR (age BETWEEN ? AND ?))",
+		Args:  []interface{}{24.0, 26.0, 30.0, 32.0},
+		Logic: "AND",
+	}}, applied)
+
+	for _, value := range []string{"18", "a-b", "30-20", "18-25-30"} {
+		filter := &DynamicFilter{
+			TableName: "test_users",
+			Model:     TestUser{},
+			Filters:   []FilterCondition{{Field: "age", Operator: "range", Value: []string{"18-25", value}}},
+		}
+		_, _, err := PaginatedQuery[TestUser](db, filter, PaginationRequest{Page: 1, PerPage: 10}, []string{})
+		assert.ErrorIs(t, err, ErrInvalidRange, value)
+		assert.Equal(t, 400, errorResponse(err).Code, value)
+	}
+
+	// Malformed ranges are the client's error
+	c, _ = gin.CreateTestContext(w)
+	c.Request, _ = http.NewRequest("GET", "/?age[range]=abc", nil)
+	filter = &DynamicFilter{TableName: "test_users", Model: TestUser{}}
+	response := PaginatedAPIResponseWithCustomFilter[TestUser](db, c, filter, "Success")
+	assert.Equal(t, 400, response.Code)
+	assert.Contains(t, response.Message, "invalid range")
+
+	// Bare repeated values are ranges on range-tagged fields
+	newContext := func(url string) *gin.Context {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request, _ = http.NewRequest("GET", url, nil)
+		return c
+	}
+	newBracketFilter := func() *testAgeBracketFilter {
+		return &testAgeBracketFilter{DynamicFilter: DynamicFilter{TableName: "test_users", Model: TestUser{}}}
+	}
+	users, paginationResponse, err = PaginateWithCustomFilter[TestUser](db, newContext("/?age=24-26&age=30-32&sort=age"), newBracketFilter())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), paginationResponse.Total)
+	assert.Equal(t, []int{25, 30, 32}, []int{users[0].Age, users[1].Age, users[2].Age})
+
+	_, paginationResponse, err = PaginateWithCustomFilter[TestUser](db, newContext("/?age=24-26"), newBracketFilter())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), paginationResponse.Total)
+
+	_, paginationResponse, err = PaginateWithCustomFilter[TestUser](db, newContext("/"), newBracketFilter())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(5), paginationResponse.Total)
+
+	response = PaginatedAPIResponseWithCustomFilter[TestUser](db, newContext("/?age=18-25&age=abc"), newBracketFilter(), "Success")
+	assert.Equal(t, 400, response.Code)
+	assert.Contains(t, response.Message, "invalid range")
+}
+
+// testAgeBracketFilter matches any of the age ranges of repeated age parameters
+type testAgeBracketFilter struct {
+	DynamicFilter
+	Ages []string `form:"age" filter:"age,op=range"`
+}
+
+func (f *testAgeBracketFilter) ApplyFilters(query *gorm.DB) *gorm.DB {
+	return ApplyTaggedFilters(query, f)
+}
+
+func (f *testAgeBracketFilter) Validate() {}
+
+func TestCountTable(t *testing.T) {
+	db := setupTestDB()
+
+	type userCount struct {
+		Search string
+		Total  int64
+	}
+	db.Table("user_counts").AutoMigrate(&userCount{})
+	db.Table("user_counts").Create(&[]userCount{{Search: "", Total: 500}, {Search: "john", Total: 42}})
+
+	var statements []string
+	db.Callback().Query().After("gorm:query").Register("test:record_sql", func(tx *gorm.DB) {
+		statements = append(statements, tx.Statement.SQL.String())
+	})
+
+	builder := NewSimpleQueryBuilder("test_users").
+		WithSearchFields("name", "email").
+		WithCountTable("user_counts", func(pagination PaginationRequest) map[string]interface{} {
+			return map[string]interface{}{"search": strings.ToLower(pagination.Search)}
+		})
+
+	users, total, err := PaginatedQuery[TestUser](db, builder, PaginationRequest{Page: 1, PerPage: 2, Search: "John"}, []string{})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(42), total)
+	assert.Len(t, users, 2)
+	assert.Contains(t, statements[0], "FROM `user_counts`")
+	for _, statement := range statements {
+		assert.NotContains(t, strings.ToLower(statement), "count(")
+	}
+
+	// Without a summary row the records are counted
+	_, total, err = PaginatedQuery[TestUser](db, builder, PaginationRequest{Page: 1, PerPage: 2, Search: "alice"}, []string{})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+
+	// Partial counts never come from the summary table
+	total, err = CountWithOptions(db, builder, PaginationRequest{Search: "john"}, PaginatedQueryOptions{}, CountOptions{IncludeFilters: true})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(5), total)
+
+	builder.CountTable.Table = "user_counts; DROP TABLE test_users"
+	_, _, err = PaginatedQuery[TestUser](db, builder, PaginationRequest{Page: 1, PerPage: 2}, []string{})
+	assert.Error(t, err)
+}
+
+func TestApproximateTotal(t *testing.T) {
+	db := setupTestDB()
+
+	// Estimates only unfiltered lists, counting searches exactly
+	estimator := func(db *gorm.DB, pagination PaginationRequest) (int64, bool, error) {
+		return 340, pagination.Search == "", nil
+	}
+	builder := NewSimpleQueryBuilder("test_users").WithSearchFields("name").WithCountEstimator(estimator)
+
+	_, paginationResponse, err := PaginateCore[TestUser](db, builder, PaginationRequest{Page: 1, PerPage: 10})
+	assert.NoError(t, err)
+	assert.True(t, paginationResponse.ApproximateTotal)
+	assert.Equal(t, int64(340), paginationResponse.Total)
+	assert.Equal(t, int64(34), paginationResponse.MaxPage)
+
+	body, err := json.Marshal(paginationResponse)
+	assert.NoError(t, err)
+	assert.Contains(t, string(body), `"approximate_total":true`)
+
+	_, paginationResponse, err = PaginateCore[TestUser](db, builder, PaginationRequest{Page: 1, PerPage: 10, Search: "john"})
+	assert.NoError(t, err)
+	assert.False(t, paginationResponse.ApproximateTotal)
+	assert.Equal(t, int64(2), paginationResponse.Total)
+
+	body, err = json.Marshal(paginationResponse)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(body), "approximate_total")
+
+	// Estimator errors fail the request
+	builder.WithCountEstimator(func(*gorm.DB, PaginationRequest) (int64, bool, error) {
+		return 0, false, errors.New("no statistics")
+	})
+	_, _, err = PaginateCore[TestUser](db, builder, PaginationRequest{Page: 1, PerPage: 10})
+	assert.Error(t, err)
+}
+
+func TestCountSource(t *testing.T) {
+	db := setupTestDB()
+
+	type userCount struct {
+		Search string
+		Total  int64
+	}
+	db.Table("user_counts").AutoMigrate(&userCount{})
+	db.Table("user_counts").Create(&[]userCount{{Search: "john", Total: 42}})
+
+	countTable := func(pagination PaginationRequest) map[string]interface{} {
+		return map[string]interface{}{"search": strings.ToLower(pagination.Search)}
+	}
+
+	tests := []struct {
+		name     string
+		builder  *SimpleQueryBuilder
+		search   string
+		expected CountSource
+	}{
+		{"Exact", NewSimpleQueryBuilder("test_users").WithCountSource(), "", CountSourceExact},
+		{"Cached", NewSimpleQueryBuilder("test_users").WithCountSource().WithCountTable("user_counts", countTable), "john", CountSourceCached},
+		{"Cache miss", NewSimpleQueryBuilder("test_users").WithCountSource().WithCountTable("user_counts", countTable), "alice", CountSourceExact},
+		{"Off by default", NewSimpleQueryBuilder("test_users").WithCountTable("user_counts", countTable), "john", ""},
+		{"Estimated", NewSimpleQueryBuilder("test_users").WithCountSource().WithCountEstimator(func(*gorm.DB, PaginationRequest) (int64, bool, error) {
+			return 340, true, nil
+		}), "", CountSourceEstimated},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.builder.WithSearchFields("name")
+			_, paginationResponse, err := PaginateCore[TestUser](db, tt.builder, PaginationRequest{Page: 1, PerPage: 2, Search: tt.search})
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, paginationResponse.CountSource)
+
+			body, err := json.Marshal(paginationResponse)
+			assert.NoError(t, err)
+			if tt.expected == "" {
+				assert.NotContains(t, string(body), "count_source")
+			} else {
+				assert.Contains(t, string(body), `"count_source":"`+string(tt.expected)+`"`)
+			}
+		})
+	}
+}
+
+// testHookedProvince loads its athlete count in AfterFind, issuing one query per row
+type testHookedProvince struct {
+	ID       uint          `json:"id" gorm:"primaryKey"`
+	Name     string        `json:"name"`
+	Athletes []TestAthlete `json:"athletes,omitempty" gorm:"foreignKey:ProvinceID"`
+	Total    int64         `json:"total" gorm:"-"`
+}
+
+func (p *testHookedProvince) AfterFind(tx *gorm.DB) error {
+	return tx.Session(&gorm.Session{NewDB: true}).Table("test_athletes").Where("province_id = ?", p.ID).Count(&p.Total).Error
+}
+
+func TestQueryCountDebug(t *testing.T) {
+	db := setupRelationTestDB()
+
+	count, err := CountQueries(db, func(db *gorm.DB) error {
+		_, _, err := PaginatedQuery[TestProvince](db, NewSimpleQueryBuilder("test_provinces"), PaginationRequest{Page: 1, PerPage: 10}, []string{"Athletes"})
+		return err
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, count)
+
+	var logs strings.Builder
+	log.SetOutput(&logs)
+	defer log.SetOutput(os.Stderr)
+
+	SetQueryCountDebug(true)
+	defer SetQueryCountDebug(false)
+
+	newFilter := func() *testIncludableFilter {
+		filter := &testIncludableFilter{&DynamicFilter{TableName: "test_provinces", Model: TestProvince{}}}
+		filter.Includes = []string{"Athletes"}
+		filter.Pagination = PaginationRequest{Page: 1, PerPage: 10}
+		return filter
+	}
+
+	_, _, err = PaginatedQueryWithIncludable[TestProvince](db, newFilter())
+	assert.NoError(t, err)
+	assert.Empty(t, logs.String())
+
+	// A query per row from the hook exceeds the budget
+	provinces, _, err := PaginatedQueryWithIncludable[testHookedProvince](db, newFilter())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), provinces[0].Total)
+	assert.Contains(t, logs.String(), "possible N+1 on test_provinces: 6 queries issued for 1 includes (expected at most 3)")
+}
+
+func TestApplyDateRangeBounds(t *testing.T) {
+	db := setupEventTestDB()
+
+	// Events start on 2022-09-10, 2023-05-12, 2024-03-01 and 2024-10-15
+	from := time.Date(2023, 5, 12, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 10, 15, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		bounds   string
+		expected []string
+	}{
+		{"", []string{"SEA Games 2023", "Pekan Olahraga Daerah 2024", "PON XXI Papua 2024"}},
+		{"[]", []string{"SEA Games 2023", "Pekan Olahraga Daerah 2024", "PON XXI Papua 2024"}},
+		{"[)", []string{"SEA Games 2023", "Pekan Olahraga Daerah 2024"}},
+		{"(]", []string{"Pekan Olahraga Daerah 2024", "PON XXI Papua 2024"}},
+		{"()", []string{"Pekan Olahraga Daerah 2024"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.bounds, func(t *testing.T) {
+			var events []TestEvent
+			err := ApplyDateRange(db.Model(&TestEvent{}), "start_date", from, to, tt.bounds).Order("start_date").Find(&events).Error
+			assert.NoError(t, err)
+
+			names := []string{}
+			for _, event := range events {
+				names = append(names, event.Name)
+			}
+			assert.Equal(t, tt.expected, names)
+		})
+	}
+
+	// A zero end leaves the range open
+	var events []TestEvent
+	err := ApplyDateRange(db.Model(&TestEvent{}), "start_date", to, time.Time{}, "(]").Find(&events).Error
+	assert.NoError(t, err)
+	assert.Empty(t, events)
+
+	err = ApplyDateRange(db.Model(&TestEvent{}), "start_date", from, to, "[[").Find(&events).Error
+	assert.ErrorIs(t, err, ErrInvalidRange)
+	assert.Equal(t, 400, errorResponse(err).Code)
+}
+
+func TestBindPaginationWithConfig(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tenants := map[string]PaginationConfig{
+		"acme":    {DefaultPerPage: 25, MaxPerPage: 200},
+		"initech": {DefaultPerPage: 5, MaxPerPage: 20},
+	}
+
+	resolved := 0
+	resolve := func(ctx *gin.Context) PaginationConfig {
+		resolved++
+		return tenants[ctx.GetHeader("X-Tenant")]
+	}
+	bind := func(tenant, target string) PaginationRequest {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request, _ = http.NewRequest("GET", target, nil)
+		c.Request.Header.Set("X-Tenant", tenant)
+		return BindPaginationWithConfig(c, resolve)
+	}
+
+	assert.Equal(t, 25, bind("acme", "/").PerPage)
+	assert.Equal(t, 5, bind("initech", "/").PerPage)
+	assert.Equal(t, 2, resolved)
+
+	// Each tenant's cap applies
+	assert.Equal(t, 150, bind("acme", "/?per_page=150").PerPage)
+	assert.Equal(t, 5, bind("initech", "/?per_page=150").PerPage)
+	assert.Equal(t, 15, bind("initech", "/?per_page=15").PerPage)
+
+	// Unconfigured tenants get the package defaults
+	assert.Equal(t, DefaultPerPage, bind("unknown", "/").PerPage)
+	assert.Equal(t, MaxPerPage, bind("unknown", "/?per_page=100").PerPage)
+	assert.Equal(t, DefaultPerPage, bind("unknown", "/?per_page=150").PerPage)
+}
+
+func TestBindPaginationFromMap(t *testing.T) {
+	pagination := BindPaginationFromMap(map[string]string{
+		"page":      "3",
+		"per_page":  "25",
+		"search":    "john",
+		"sort":      "name",
+		"order":     "desc",
+		"meta_only": "true",
+	})
+	assert.Equal(t, PaginationRequest{
+		Page:     3,
+		PerPage:  25,
+		Search:   "john",
+		Sort:     "name",
+		Order:    "desc",
+		MetaOnly: true,
+
+		orderSent: true,
+	}, pagination)
+
+	// Defaulting and validation match the HTTP path
+	params := map[string]string{"page": "-1", "per_page": "1000", "order": "sideways", "is_disabled": "yes"}
+
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request, _ = http.NewRequest("GET", "/?page=-1&per_page=1000&order=sideways&is_disabled=yes", nil)
+
+	assert.Equal(t, BindPagination(c), BindPaginationFromMap(params))
+	assert.Equal(t, PaginationRequest{Page: 1, PerPage: DefaultPerPage, Order: "asc", IsDisabled: true}, BindPaginationFromMap(params))
+	assert.Equal(t, PaginationRequest{Page: 1, PerPage: DefaultPerPage, Order: "asc"}, BindPaginationFromMap(nil))
+}
+
+func TestNextCursorWithOffsetMetadata(t *testing.T) {
+	db := setupTestDB()
+	builder := NewSimpleQueryBuilder("test_users").WithNextCursor()
+
+	pagination := PaginationRequest{Page: 1, PerPage: 2, Sort: "age", Order: "asc"}
+	users, paginationResponse, err := PaginateCore[TestUser](db, builder, pagination)
+	assert.NoError(t, err)
+
+	assert.Equal(t, 1, paginationResponse.Page)
+	assert.Equal(t, int64(3), paginationResponse.MaxPage)
+	assert.NotEmpty(t, paginationResponse.NextCursor)
+
+	fields, err := DecodeCursor(paginationResponse.NextCursor, []SortField{{Field: "age", Direction: "asc"}})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(users[1].Age), fields[0].Value)
+
+	body, err := json.Marshal(paginationResponse)
+	assert.NoError(t, err)
+	assert.Contains(t, string(body), `"max_page":3`)
+	assert.Contains(t, string(body), `"next_cursor":"`)
+
+	// The last page has no next cursor
+	_, paginationResponse, err = PaginateCore[TestUser](db, builder, PaginationRequest{Page: 3, PerPage: 2, Sort: "age"})
+	assert.NoError(t, err)
+	assert.Empty(t, paginationResponse.NextCursor)
+
+	// Off by default
+	_, paginationResponse, err = PaginateCore[TestUser](db, NewSimpleQueryBuilder("test_users"), pagination)
+	assert.NoError(t, err)
+	assert.Empty(t, paginationResponse.NextCursor)
+}
+
+func TestClampPageReportsRequestedPage(t *testing.T) {
+	db := setupTestDB()
+	builder := NewSimpleQueryBuilder("test_users").WithClampPage()
+
+	users, paginationResponse, err := PaginateCore[TestUser](db, builder, PaginationRequest{Page: 99, PerPage: 2})
+	assert.NoError(t, err)
+	assert.Len(t, users, 1)
+	assert.Equal(t, uint(5), users[0].ID)
+	assert.Equal(t, 3, paginationResponse.Page)
+	assert.Equal(t, 99, paginationResponse.RequestedPage)
+	assert.Equal(t, int64(0), paginationResponse.Remaining)
+
+	body, err := json.Marshal(paginationResponse)
+	assert.NoError(t, err)
+	assert.Contains(t, string(body), `"page":3,"requested_page":99`)
+
+	// Pages in range are served as requested, without a requested page
+	_, paginationResponse, err = PaginateCore[TestUser](db, builder, PaginationRequest{Page: 2, PerPage: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, paginationResponse.Page)
+	assert.Zero(t, paginationResponse.RequestedPage)
+
+	// Off by default, a page past the end is empty
+	users, paginationResponse, err = PaginateCore[TestUser](db, NewSimpleQueryBuilder("test_users"), PaginationRequest{Page: 99, PerPage: 2})
+	assert.NoError(t, err)
+	assert.Empty(t, users)
+	assert.Equal(t, 99, paginationResponse.Page)
+	assert.Zero(t, paginationResponse.RequestedPage)
+
+	// Callers of PaginatedQuery get the served page from EffectivePage
+	pagination := PaginationRequest{Page: 99, PerPage: 2}
+	users, total, err := PaginatedQuery[TestUser](db, builder, pagination, []string{})
+	assert.NoError(t, err)
+	assert.Equal(t, uint(5), users[0].ID)
+	assert.Equal(t, 99, pagination.Page)
+	assert.Equal(t, 3, EffectivePage(builder, pagination, total))
+	assert.Equal(t, 2, EffectivePage(builder, PaginationRequest{Page: 2, PerPage: 2}, total))
+	assert.Equal(t, 99, EffectivePage(NewSimpleQueryBuilder("test_users"), pagination, total))
+
+	// Query layer responses report the served page too
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request, _ = http.NewRequest("GET", "/?page=99&per_page=2", nil)
+	filter := &testClampPageFilter{testIncludableFilter{&DynamicFilter{TableName: "test_users", Model: TestUser{}}}}
+	response := PaginatedAPIResponseWithQueryLayer(c, filter, "Success",
+		func(filter IncludableQueryBuilder) ([]TestUser, int64, error) {
+			return PaginatedQuery[TestUser](db, filter, filter.GetPagination(), []string{})
+		})
+	assert.Equal(t, 200, response.Code)
+	assert.Equal(t, 3, response.Pagination.Page)
+	assert.Equal(t, 99, response.Pagination.RequestedPage)
+	assert.Equal(t, uint(5), response.Data.([]TestUser)[0].ID)
+}
+
+// testClampPageFilter serves the last page for pages past the end
+type testClampPageFilter struct {
+	testIncludableFilter
+}
+
+func (f *testClampPageFilter) GetClampPage() bool { return true }
+
+func TestRequireSearch(t *testing.T) {
+	db := setupTestDB()
+
+	var statements []string
+	db.Callback().Query().After("gorm:query").Register("test:record_sql", func(tx *gorm.DB) {
+		statements = append(statements, tx.Statement.SQL.String())
+	})
+
+	builder := NewSimpleQueryBuilder("test_users").WithSearchFields("name").WithRequireSearch(true)
+
+	for _, search := range []string{"", "   "} {
+		users, paginationResponse, err := PaginateCore[TestUser](db, builder, PaginationRequest{Page: 1, PerPage: 10, Search: search})
+		assert.NoError(t, err)
+		assert.Empty(t, users)
+		assert.Equal(t, int64(0), paginationResponse.Total)
+	}
+	assert.Empty(t, statements)
+
+	users, paginationResponse, err := PaginateCore[TestUser](db, builder, PaginationRequest{Page: 1, PerPage: 10, Search: "john"})
+	assert.NoError(t, err)
+	assert.Len(t, users, 2)
+	assert.Equal(t, int64(2), paginationResponse.Total)
+
+	// Off by default, an empty search lists everything
+	users, _, err = PaginateCore[TestUser](db, builder.WithRequireSearch(false), PaginationRequest{Page: 1, PerPage: 10})
+	assert.NoError(t, err)
+	assert.Len(t, users, 5)
+}
+
+type TestListing struct {
+	ID       uint   `json:"id" gorm:"primaryKey"`
+	Title    string `json:"title"`
+	IsActive bool   `json:"is_active"`
+}
+
+func TestAlwaysFilter(t *testing.T) {
+	db, _ := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	db.AutoMigrate(&TestListing{})
+	db.Create(&[]TestListing{
+		{Title: "Blue widget", IsActive: true},
+		{Title: "Red widget", IsActive: false},
+		{Title: "Green gadget", IsActive: true},
+	})
+
+	builder := NewSimpleQueryBuilder("test_listings").
+		WithSearchFields("title").
+		WithAlwaysFilter("is_active", "=", true)
+
+	listings, total, err := PaginatedQuery[TestListing](db, builder, PaginationRequest{Page: 1, PerPage: 10}, []string{})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+	assert.Len(t, listings, 2)
+
+	listings, total, err = PaginatedQuery[TestListing](db, builder, PaginationRequest{Page: 1, PerPage: 10, Search: "widget"}, []string{})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	assert.Equal(t, "Blue widget", listings[0].Title)
+
+	// An OR among client filters can't escape the condition
+	builder.WithFilters(func(query *gorm.DB) *gorm.DB {
+		return query.Where("title = ?", "Red widget").Or("title = ?", "Blue widget")
+	})
+	listings, total, err = PaginatedQuery[TestListing](db, builder, PaginationRequest{Page: 1, PerPage: 10}, []string{})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	assert.Equal(t, "Blue widget", listings[0].Title)
+
+	assert.Panics(t, func() { NewSimpleQueryBuilder("test_listings").WithAlwaysFilter("is_active = 1 OR 1", "=", true) })
+	assert.Panics(t, func() { NewSimpleQueryBuilder("test_listings").WithAlwaysFilter("is_active", "equals_ish", true) })
+	assert.Panics(t, func() { NewSimpleQueryBuilder("test_listings").WithAlwaysFilter("is_active", "range", "1-2") })
+	assert.NotPanics(t, func() { NewSimpleQueryBuilder("test_listings").WithAlwaysFilter("is_active", "EQ", true) })
+
+	// Every known operator has its own condition rather than the equality fallback
+	for operator := range filterOperators {
+		if operator == "=" || operator == "EQ" || operator == "EQUALS" {
+			continue
+		}
+		assert.NotEqual(t, "f = ?", (&DynamicFilter{}).buildCondition(FilterCondition{Field: "f", Operator: operator}), operator)
+	}
+}
+
+func TestModelTable(t *testing.T) {
+	db, _ := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{
+		NamingStrategy: schema.NamingStrategy{TablePrefix: "app_", SingularTable: true},
+	})
+	db.AutoMigrate(&TestUser{})
+	db.Create(&[]TestUser{{Name: "John Doe", Age: 25}, {Name: "Jane Smith", Age: 30}})
+
+	builder := NewSimpleQueryBuilder("").WithSearchFields("name").WithModelTable(db, &TestUser{})
+	assert.Equal(t, "app_test_user", builder.GetTableName())
+
+	users, total, err := PaginatedQuery[TestUser](db, builder, PaginationRequest{Page: 1, PerPage: 10, Search: "jane"}, []string{})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	assert.Equal(t, "Jane Smith", users[0].Name)
+
+	// The name is resolved once, so querying doesn't change it
+	assert.Equal(t, "app_test_user", builder.GetTableName())
+
+	// Each database's naming strategy gets its own builder
+	defaultDB := setupTestDB()
+	defaultBuilder := NewSimpleQueryBuilder("").WithModelTable(defaultDB, &TestUser{})
+	assert.Equal(t, "test_users", defaultBuilder.GetTableName())
+	assert.Equal(t, "app_test_user", builder.GetTableName())
+
+	// A builder keeps the name it resolved on any other database
+	_, _, err = PaginatedQuery[TestUser](defaultDB, builder, PaginationRequest{Page: 1, PerPage: 10}, []string{})
+	assert.ErrorContains(t, err, "app_test_user")
+	_, total, err = PaginatedQuery[TestUser](defaultDB, defaultBuilder, PaginationRequest{Page: 1, PerPage: 10}, []string{})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(5), total)
+
+	assert.Panics(t, func() { NewSimpleQueryBuilder("").WithModelTable(db, "not a model") })
+
+	// Without it, the table name is taken as given
+	_, _, err = PaginatedQuery[TestUser](db, NewSimpleQueryBuilder("test_users"), PaginationRequest{Page: 1, PerPage: 10}, []string{})
+	assert.ErrorContains(t, err, "no such table")
+}
+
+func TestDistinctValues(t *testing.T) {
+	db := setupEventTestDB()
+	db.Create(&TestSport{Name: "Panahan", Category: "Target Sport"})
+	RegisterFacetColumns("test_sports", "category")
+
+	facets, paginationResponse, err := DistinctValues(db, "test_sports", "category", PaginationRequest{Page: 1, PerPage: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, []FacetValue{{Value: "Individual Sport", Count: 2}, {Value: "Target Sport", Count: 1}}, facets)
+	assert.Equal(t, int64(3), paginationResponse.Total)
+	assert.Equal(t, int64(2), paginationResponse.MaxPage)
+
+	facets, _, err = DistinctValues(db, "test_sports", "category", PaginationRequest{Page: 2, PerPage: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, []FacetValue{{Value: "Team Sport", Count: 3}}, facets)
+
+	facets, _, err = DistinctValues(db, "test_sports", "category", PaginationRequest{Page: 1, PerPage: 10, Sort: "count", Order: "desc"})
+	assert.NoError(t, err)
+	assert.Equal(t, []FacetValue{{Value: "Team Sport", Count: 3}, {Value: "Individual Sport", Count: 2}, {Value: "Target Sport", Count: 1}}, facets)
+
+	// Only registered columns are queried
+	_, _, err = DistinctValues(db, "test_sports", "name", PaginationRequest{Page: 1, PerPage: 10})
+	assert.ErrorIs(t, err, ErrFacetNotAllowed)
+	_, _, err = DistinctValues(db, "test_sports", "category) FROM test_sports; --", PaginationRequest{Page: 1, PerPage: 10})
+	assert.ErrorIs(t, err, ErrFacetNotAllowed)
+}
+
+func TestFirstPageOmitsOffset(t *testing.T) {
+	db := setupTestDB()
+	builder := NewSimpleQueryBuilder("test_users")
+
+	for page, expected := range map[int]string{1: "ORDER BY id asc LIMIT 2", 2: "ORDER BY id asc LIMIT 2 OFFSET 2"} {
+		dataQuery, err := buildDataQuery[TestUser](db.Session(&gorm.Session{DryRun: true}), builder, PaginationRequest{Page: page, PerPage: 2}, PaginatedQueryOptions{Dialect: SQLite})
+		assert.NoError(t, err)
+		stmt := dataQuery.Find(&[]TestUser{}).Statement
+		assert.True(t, strings.HasSuffix(db.Dialector.Explain(stmt.SQL.String(), stmt.Vars...), expected))
+	}
+}
+
+func TestOffsetLimit(t *testing.T) {
+	db := setupTestDB()
+	builder := NewSimpleQueryBuilder("test_users").WithOffsetLimit()
+
+	users, paginationResponse, err := PaginateCore[TestUser](db, builder, PaginationRequest{Page: 3, PerPage: 2})
+	assert.NoError(t, err)
+	assert.Len(t, users, 1)
+	assert.Equal(t, 4, *paginationResponse.Offset)
+	assert.Equal(t, 2, *paginationResponse.Limit)
+
+	body, err := json.Marshal(paginationResponse)
+	assert.NoError(t, err)
+	assert.Contains(t, string(body), `"offset":4,"limit":2`)
+
+	// The first page reports its zero offset, and defaults are applied
+	_, paginationResponse, err = PaginateCore[TestUser](db, builder, PaginationRequest{})
+	assert.NoError(t, err)
+	assert.Equal(t, 0, *paginationResponse.Offset)
+	assert.Equal(t, DefaultPerPage, *paginationResponse.Limit)
+
+	// A clamped page reports the offset actually applied
+	_, paginationResponse, err = PaginateCore[TestUser](db, builder.WithClampPage(), PaginationRequest{Page: 99, PerPage: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, 4, *paginationResponse.Offset)
+
+	// Off by default
+	_, paginationResponse, err = PaginateCore[TestUser](db, NewSimpleQueryBuilder("test_users"), PaginationRequest{Page: 3, PerPage: 2})
+	assert.NoError(t, err)
+	assert.Nil(t, paginationResponse.Offset)
+	assert.Nil(t, paginationResponse.Limit)
+}
+
+func TestPaginatedQueryWithSortAllow(t *testing.T) {
+	db := setupTestDB()
+	builder := NewSimpleQueryBuilder("test_users")
+	pagination := PaginationRequest{Page: 1, PerPage: 10, Sort: "age", Order: "desc"}
+
+	// An admin may sort by age
+	users, total, err := PaginatedQueryWithSortAllow[TestUser](db, builder, pagination, []string{"name", "age"})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(5), total)
+	assert.Equal(t, "Bob Johnson", users[0].Name)
+
+	// The same builder falls back to the default sort for a viewer who may not
+	users, _, err = PaginatedQueryWithSortAllow[TestUser](db, builder, pagination, []string{"name"})
+	assert.NoError(t, err)
+	assert.Equal(t, "John Doe", users[0].Name)
+
+	pagination.Sort = "name"
+	users, _, err = PaginatedQueryWithSortAllow[TestUser](db, builder, pagination, []string{"name"})
+	assert.NoError(t, err)
+	assert.Equal(t, "John Doe", users[0].Name)
+	assert.Equal(t, "Alice Brown", users[4].Name)
+
+	// The builder's sortable fields still apply
+	users, _, err = PaginatedQueryWithSortAllow[TestUser](db, NewSimpleQueryBuilder("test_users").WithSortableFields("age"), pagination, []string{"name"})
+	assert.NoError(t, err)
+	assert.Equal(t, "John Doe", users[0].Name)
+	assert.Equal(t, "Charlie Wilson", users[4].Name)
+}
+
+func TestResponseJSONIsByteStable(t *testing.T) {
+	db := setupTestDB()
+	gin.SetMode(gin.TestMode)
+
+	render := func() string {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest("GET", "/?search=o&sort=age&order=desc&per_page=3", nil)
+
+		// Rows scanned into maps, whose iteration order is random
+		response := PaginatedAPIResponse[map[string]interface{}](db, c, "test_users", []string{"name"}, "Success")
+		response = WithPageBounds(WithPositions(response))
+		c.JSON(response.Code, response)
+		return w.Body.String()
+	}
+
+	expected := render()
+	assert.Contains(t, expected, `{"age":35,"email":"bob@example.com","id":3,"name":"Bob Johnson"}`)
+	for i := 0; i < 20; i++ {
+		assert.Equal(t, expected, render())
+	}
+}
+
+func TestPaginateTableFunction(t *testing.T) {
+	db := setupTestDB()
+
+	// SQLite has no parameterized views, so a subquery taking a bind parameter stands in
+	// for a table-valued function such as get_rankings(?)
+	function := "(SELECT name, age FROM test_users WHERE age >= ?)"
+
+	users, paginationResponse, err := PaginateTableFunction[TestUser](db, function, []interface{}{28}, PaginationRequest{Page: 2, PerPage: 2, Sort: "age", Order: "desc"})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(4), paginationResponse.Total)
+	assert.Equal(t, int64(2), paginationResponse.MaxPage)
+	assert.Len(t, users, 2)
+	assert.Equal(t, "Jane Smith", users[0].Name)
+	assert.Equal(t, "Alice Brown", users[1].Name)
+
+	// Sorts failing the sort field validator are ignored
+	users, paginationResponse, err = PaginateTableFunction[TestUser](db, function, []interface{}{33}, PaginationRequest{Page: 1, PerPage: 10, Sort: "age; DROP TABLE test_users"})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), paginationResponse.Total)
+	assert.Equal(t, "Bob Johnson", users[0].Name)
+
+	_, _, err = PaginateTableFunction[TestUser](db, "missing_function(?)", []interface{}{1}, PaginationRequest{Page: 1, PerPage: 10})
+	assert.ErrorContains(t, err, "failed to count records")
+}
+
+func TestWritePaginatedNDJSON(t *testing.T) {
+	db := setupTestDB()
+	gin.SetMode(gin.TestMode)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	users, paginationResponse, err := PaginateCore[TestUser](db, NewSimpleQueryBuilder("test_users"), PaginationRequest{Page: 1, PerPage: 2})
+	assert.NoError(t, err)
+
+	assert.NoError(t, WritePaginatedNDJSON(c, users, paginationResponse))
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, "application/x-ndjson", w.Header().Get("Content-Type"))
+	assert.Equal(t, `{"id":1,"name":"John Doe","email":"john@example.com","age":25}
+{"id":2,"name":"Jane Smith","email":"jane@example.com","age":30}
+{"pagination":{"page":1,"per_page":2,"max_page":3,"total":5,"remaining":3}}
+`, w.Body.String())
+
+	// An empty page is just the metadata line
+	w = httptest.NewRecorder()
+	c, _ = gin.CreateTestContext(w)
+	assert.NoError(t, WritePaginatedNDJSON(c, []TestUser{}, PaginationResponse{Page: 9, PerPage: 2, MaxPage: 3, Total: 5}))
+	assert.Equal(t, `{"pagination":{"page":9,"per_page":2,"max_page":3,"total":5,"remaining":0}}`+"\n", w.Body.String())
+}
+
+func TestNaturalOrder(t *testing.T) {
+	db := setupTestDB()
+
+	var statements []string
+	db.Callback().Query().After("gorm:query").Register("test:record_sql", func(tx *gorm.DB) {
+		statements = append(statements, tx.Statement.SQL.String())
+	})
+
+	var logs strings.Builder
+	log.SetOutput(&logs)
+	defer log.SetOutput(os.Stderr)
+
+	builder := NewSimpleQueryBuilder("test_users").
+		WithWeightedSearch(map[string]int{"name": 1}).
+		WithNaturalOrder(true)
+	assert.Contains(t, logs.String(), "natural order on test_users")
+
+	for _, pagination := range []PaginationRequest{
+		{Page: 1, PerPage: 10},
+		{Page: 1, PerPage: 10, Order: "desc"},
+		{Page: 1, PerPage: 10, Sort: "age; --"},
+		{Page: 1, PerPage: 10, Search: "o"},
+	} {
+		statements = nil
+		_, _, err := PaginatedQuery[TestUser](db, builder, pagination, []string{})
+		assert.NoError(t, err)
+		assert.NotContains(t, statements[len(statements)-1], "ORDER BY")
+		assert.Empty(t, EffectiveSort(builder, pagination))
+	}
+
+	// A requested sort still applies
+	statements = nil
+	users, _, err := PaginatedQuery[TestUser](db, builder, PaginationRequest{Page: 1, PerPage: 10, Sort: "age", Order: "desc"}, []string{})
+	assert.NoError(t, err)
+	assert.Contains(t, statements[len(statements)-1], "ORDER BY age desc")
+	assert.Equal(t, "Bob Johnson", users[0].Name)
+
+	// Unsorted pages have no cursor to continue from
+	builder.WithNextCursor()
+	_, paginationResponse, err := PaginateCore[TestUser](db, builder, PaginationRequest{Page: 1, PerPage: 2})
+	assert.NoError(t, err)
+	assert.Empty(t, paginationResponse.NextCursor)
+}
+
+// testTraceIDKey is the context key of the trace ID in TestMetricsTraceID
+type testTraceIDKey struct{}
+
+func TestMetricsTraceID(t *testing.T) {
+	db := setupTestDB()
+
+	var reported []QueryMetrics
+	SetMetricsHook(func(metrics QueryMetrics) { reported = append(reported, metrics) })
+	defer SetMetricsHook(nil)
+	SetTraceIDExtractor(func(ctx context.Context) string {
+		traceID, _ := ctx.Value(testTraceIDKey{}).(string)
+		return traceID
+	})
+	defer SetTraceIDExtractor(nil)
+
+	ctx := context.WithValue(context.Background(), testTraceIDKey{}, "4bf92f3577b34da6a3ce929d0e0e4736")
+	_, _, err := PaginatedQuery[TestUser](db.WithContext(ctx), NewSimpleQueryBuilder("test_users"), PaginationRequest{Page: 1, PerPage: 2}, []string{})
+	assert.NoError(t, err)
+
+	assert.Len(t, reported, 1)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", reported[0].TraceID)
+	assert.Equal(t, "test_users", reported[0].Table)
+	assert.Equal(t, int64(5), reported[0].Total)
+	assert.Equal(t, 2, reported[0].Rows)
+	assert.Equal(t, CountSourceExact, reported[0].CountSource)
+	assert.NoError(t, reported[0].Err)
+	assert.Positive(t, reported[0].Duration)
+
+	// Failed queries are reported too, without a trace outside a traced context
+	_, _, err = PaginatedQuery[TestUser](db, NewSimpleQueryBuilder("missing_table"), PaginationRequest{Page: 1, PerPage: 2}, []string{})
+	assert.Error(t, err)
+	assert.Len(t, reported, 2)
+	assert.Empty(t, reported[1].TraceID)
+	assert.Error(t, reported[1].Err)
+}
+
+func TestSearchOnlyFilter(t *testing.T) {
+	db := setupTestDB()
+
+	var statements []string
+	db.Callback().Query().After("gorm:query").Register("test:record_sql", func(tx *gorm.DB) {
+		statements = append(statements, tx.Statement.SQL.String())
+	})
+
+	var terms []string
+	builder := NewSimpleQueryBuilder("test_users").
+		WithSearchFields("name").
+		WithSearchOnlyFilter(func(query *gorm.DB, term string) *gorm.DB {
+			terms = append(terms, term)
+			return query.Where("age >= ?", 30)
+		})
+
+	// Count and data both carry the condition when searching
+	users, total, err := PaginatedQuery[TestUser](db, builder, PaginationRequest{Page: 1, PerPage: 10, Search: "o"}, []string{})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+	assert.Len(t, users, 2)
+	assert.Equal(t, []string{"o", "o"}, terms)
+	assert.Len(t, statements, 2)
+	for _, statement := range statements {
+		assert.Contains(t, statement, "age >= ?")
+	}
+
+	// Without a search term it never runs
+	statements, terms = nil, nil
+	_, total, err = PaginatedQuery[TestUser](db, builder, PaginationRequest{Page: 1, PerPage: 10}, []string{})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(5), total)
+	assert.Empty(t, terms)
+	for _, statement := range statements {
+		assert.NotContains(t, statement, "age >= ?")
+	}
+}
+
+// testAgeRangeFilter rejects an empty age range
+type testAgeRangeFilter struct {
+	DynamicFilter
+	MinAge int `form:"min_age" filter:"age,op=gte"`
+	MaxAge int `form:"max_age" filter:"age,op=lte"`
+}
+
+func (f *testAgeRangeFilter) ApplyFilters(query *gorm.DB) *gorm.DB {
+	return ApplyTaggedFilters(query, f)
+}
+
+func (f *testAgeRangeFilter) Validate() {}
+
+func (f *testAgeRangeFilter) ValidateFilters() error {
+	if f.MinAge > 0 && f.MaxAge > 0 && f.MinAge > f.MaxAge {
+		return errors.New("min_age must not exceed max_age")
+	}
+	return nil
+}
+
+func TestValidateFilters(t *testing.T) {
+	db := setupTestDB()
+	gin.SetMode(gin.TestMode)
+
+	newContext := func(url string) *gin.Context {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest("GET", url, nil)
+		return c
+	}
+	newFilter := func() *testAgeRangeFilter {
+		return &testAgeRangeFilter{DynamicFilter: DynamicFilter{TableName: "test_users", Model: TestUser{}}}
+	}
+
+	response := PaginatedAPIResponseWithCustomFilter[TestUser](db, newContext("/?min_age=40&max_age=20"), newFilter(), "Success")
+	assert.Equal(t, 400, response.Code)
+	assert.Equal(t, "Invalid query parameters: min_age must not exceed max_age", response.Message)
+
+	var paramsErr *QueryParamsError
+	err := BindAndValidateFilter(newContext("/?min_age=40&max_age=20"), newFilter())
+	assert.ErrorAs(t, err, &paramsErr)
+
+	response = PaginatedAPIResponseWithCustomFilter[TestUser](db, newContext("/?min_age=26&max_age=31"), newFilter(), "Success")
+	assert.Equal(t, 200, response.Code)
+	assert.Equal(t, int64(2), response.Pagination.Total)
+}
+
+// testProvinceAthleteFilter lists the athletes of the province in the path
+type testProvinceAthleteFilter struct {
+	DynamicFilter
+	ProvinceID int    `form:"province_id" uri:"province_id" filter:"province_id"`
+	Gender     string `form:"gender" filter:"gender"`
+}
+
+func (f *testProvinceAthleteFilter) ApplyFilters(query *gorm.DB) *gorm.DB {
+	return ApplyTaggedFilters(query, f)
+}
+
+func (f *testProvinceAthleteFilter) Validate() {}
+
+func TestBindFilterURIParams(t *testing.T) {
+	db := setupRelationTestDB()
+	gin.SetMode(gin.TestMode)
+
+	newContext := func(url string, provinceID string) *gin.Context {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest("GET", url, nil)
+		c.Params = gin.Params{{Key: "province_id", Value: provinceID}}
+		return c
+	}
+	newFilter := func() *testProvinceAthleteFilter {
+		return &testProvinceAthleteFilter{DynamicFilter: DynamicFilter{TableName: "test_athletes", Model: TestAthlete{}}}
+	}
+
+	filter := newFilter()
+	assert.NoError(t, BindAndValidateFilter(newContext("/provinces/2/athletes?gender=Female", "2"), filter))
+	assert.Equal(t, 2, filter.ProvinceID)
+	assert.Equal(t, "Female", filter.Gender)
+
+	athletes, _, err := PaginatedQuery[TestAthlete](db, filter, filter.GetPagination(), nil)
+	assert.NoError(t, err)
+	assert.Len(t, athletes, 1)
+	assert.Equal(t, "Dewi Sartika", athletes[0].Name)
+
+	// The path wins over a query parameter of the same field
+	response := PaginatedAPIResponseWithCustomFilter[TestAthlete](db, newContext("/provinces/1/athletes?province_id=2", "1"), newFilter(), "Success")
+	assert.Equal(t, 200, response.Code)
+	assert.Equal(t, int64(3), response.Pagination.Total)
+
+	response = PaginatedAPIResponseWithCustomFilter[TestAthlete](db, newContext("/provinces/abc/athletes", "abc"), newFilter(), "Success")
+	assert.Equal(t, 400, response.Code)
+}
+
+func TestSearchWithGroupAndHaving(t *testing.T) {
+	db := setupRelationTestDB()
+	for _, province := range []struct {
+		name     string
+		athletes int
+	}{{"Jawa Timur", 3}, {"Jawa Tengah", 4}, {"Sumatera Utara", 5}} {
+		p := TestProvince{Name: province.name}
+		db.Create(&p)
+		for i := 0; i < province.athletes; i++ {
+			db.Create(&TestAthlete{Name: "Athlete " + strconv.Itoa(i), ProvinceID: p.ID})
+		}
+	}
+
+	var statements []string
+	db.Callback().Query().After("gorm:query").Register("test:record_sql", func(tx *gorm.DB) {
+		statements = append(statements, tx.Statement.SQL.String())
+	})
+
+	// Provinces whose name matches "jawa" having more than 2 athletes
+	builder := NewChainableQueryBuilder("test_provinces").
+		Select("test_provinces.*", "COUNT(test_athletes.id) AS athletes_count").
+		Join("JOIN test_athletes ON test_athletes.province_id = test_provinces.id").
+		GroupBy("test_provinces.id").
+		Having("COUNT(test_athletes.id) > 2")
+	builder.WithSearchFields("test_provinces.name")
+
+	pagination := PaginationRequest{Page: 1, PerPage: 1, Search: "jawa", Sort: "athletes_count", Order: "desc"}
+	provinces, total, err := PaginatedQuery[TestProvince](db, builder, pagination, []string{})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+	assert.Len(t, provinces, 1)
+	assert.Equal(t, "Jawa Tengah", provinces[0].Name)
+	assert.Equal(t, int64(4), provinces[0].AthletesCount)
+
+	// The groups are counted by the database, not fetched
+	assert.Contains(t, strings.Join(statements, "\n"), ") AS grouped_rows")
+
+	pagination.Page = 2
+	provinces, total, err = PaginatedQuery[TestProvince](db, builder, pagination, []string{})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+	assert.Equal(t, "Jawa Timur", provinces[0].Name)
+
+	// A having on a select alias works for the count as well
+	aliased := NewChainableQueryBuilder("test_provinces").
+		Select("test_provinces.*", "COUNT(test_athletes.id) AS athletes_count").
+		Join("JOIN test_athletes ON test_athletes.province_id = test_provinces.id").
+		GroupBy("test_provinces.id").
+		Having("athletes_count > 2")
+	_, total, err = PaginatedQuery[TestProvince](db, aliased, PaginationRequest{Page: 1, PerPage: 10}, []string{})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(4), total)
+}
+
+func TestPaginateChannel(t *testing.T) {
+	db := setupTestDB()
+	builder := NewSimpleQueryBuilder("test_users")
+
+	var names []string
+	pages := 0
+	for page := range PaginateChannel[TestUser](context.Background(), db, builder, PaginationRequest{Page: 1, PerPage: 2}) {
+		assert.NoError(t, page.Err)
+		pages++
+		assert.Equal(t, pages, page.Pagination.Page)
+		for _, user := range page.Data {
+			names = append(names, user.Name)
+		}
+	}
+	assert.Equal(t, 3, pages)
+	assert.Equal(t, []string{"John Doe", "Jane Smith", "Bob Johnson", "Alice Brown", "Charlie Wilson"}, names)
+
+	// Errors end the stream
+	var results []PageResult[TestUser]
+	for page := range PaginateChannel[TestUser](context.Background(), db, NewSimpleQueryBuilder("missing_table"), PaginationRequest{Page: 1, PerPage: 2}) {
+		results = append(results, page)
+	}
+	assert.Len(t, results, 1)
+	assert.Error(t, results[0].Err)
+
+	// Cancellation stops fetching and closes the channel
+	ctx, cancel := context.WithCancel(context.Background())
+	pageResults := PaginateChannel[TestUser](ctx, db, builder, PaginationRequest{Page: 1, PerPage: 2})
+	first := <-pageResults
+	assert.NoError(t, first.Err)
+	cancel()
+	for page := range pageResults {
+		assert.ErrorIs(t, page.Err, context.Canceled)
+	}
+}
+
+func TestOrderByIDs(t *testing.T) {
+	db := setupTestDB()
+
+	var statements []string
+	db.Callback().Query().After("gorm:query").Register("test:record_sql", func(tx *gorm.DB) {
+		statements = append(statements, tx.Statement.SQL.String())
+	})
+
+	ids := []interface{}{4, 1, 3}
+	builder := NewSimpleQueryBuilder("test_users").
+		WithFilters(func(query *gorm.DB) *gorm.DB { return query.Where("id IN ?", ids) }).
+		WithOrderByIDs("id", ids)
+
+	// The client's sort is ignored
+	for _, pagination := range []PaginationRequest{
+		{Page: 1, PerPage: 10},
+		{Page: 1, PerPage: 10, Sort: "age", Order: "desc"},
+	} {
+		statements = nil
+		users, total, err := PaginatedQuery[TestUser](db, builder, pagination, []string{})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(3), total)
+		assert.Contains(t, statements[len(statements)-1], "ORDER BY CASE id WHEN ? THEN 0 WHEN ? THEN 1 WHEN ? THEN 2 ELSE 3 END, id asc")
+		if assert.Len(t, users, 3) {
+			assert.Equal(t, []uint{4, 1, 3}, []uint{users[0].ID, users[1].ID, users[2].ID})
+		}
+		assert.Empty(t, EffectiveSort(builder, pagination))
+	}
+
+	// Pages follow the ID order, with unlisted rows last
+	all := NewSimpleQueryBuilder("test_users").WithOrderByIDs("id", []interface{}{5, 2})
+	users, _, err := PaginatedQuery[TestUser](db, all, PaginationRequest{Page: 1, PerPage: 3}, []string{})
+	assert.NoError(t, err)
+	if assert.Len(t, users, 3) {
+		assert.Equal(t, []uint{5, 2, 1}, []uint{users[0].ID, users[1].ID, users[2].ID})
+	}
+
+	assert.Panics(t, func() { NewSimpleQueryBuilder("test_users").WithOrderByIDs("id; --", ids) })
+}
+
+// testExportFilter allows including posts and sorting by name or age
+type testExportFilter struct {
+	testAgeRangeFilter
+}
+
+func (f *testExportFilter) GetAllowedIncludes() map[string]bool {
+	return map[string]bool{"Posts": true}
+}
+
+func (f *testExportFilter) GetSortableFields() map[string]bool {
+	return map[string]bool{"name": true, "age": true}
+}
+
+func TestValidateOnly(t *testing.T) {
+	db := setupTestDB()
+	gin.SetMode(gin.TestMode)
+
+	var statements []string
+	db.Callback().Query().After("gorm:query").Register("test:record_sql", func(tx *gorm.DB) {
+		statements = append(statements, tx.Statement.SQL.String())
+	})
+
+	newContext := func(url string) *gin.Context {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest("GET", url, nil)
+		return c
+	}
+	newFilter := func() *testExportFilter {
+		return &testExportFilter{testAgeRangeFilter{DynamicFilter: DynamicFilter{TableName: "test_users", Model: TestUser{}}}}
+	}
+
+	schema, err := ValidateOnly(newContext("/?page=0&sort=name&order=desc&includes=Posts&min_age=20&max_age=30"), newFilter())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, schema.Pagination.Page)
+	assert.Equal(t, []SortField{{Field: "name", Direction: "desc"}}, schema.Sort)
+	assert.Equal(t, []string{"Posts"}, schema.Includes)
+
+	schema, err = ValidateOnly(newContext("/"), newFilter())
+	assert.NoError(t, err)
+	assert.Equal(t, []SortField{{Field: "id", Direction: "asc"}}, schema.Sort)
+	assert.Equal(t, []string{}, schema.Includes)
+
+	// Every problem is reported at once
+	_, err = ValidateOnly(newContext("/?sort=email&includes=Posts,Secrets&min_age=40&max_age=20"), newFilter())
+	var paramsErr *QueryParamsError
+	if assert.ErrorAs(t, err, &paramsErr) {
+		assert.Contains(t, err.Error(), "min_age must not exceed max_age")
+		assert.Contains(t, err.Error(), `include "Secrets" is not allowed`)
+		assert.Contains(t, err.Error(), `sort "email" is not allowed`)
+		assert.NotContains(t, err.Error(), `"Posts"`)
+	}
+	assert.Equal(t, 400, errorResponse(err).Code)
+
+	_, err = ValidateOnly(newContext("/?sort=name--"), newFilter())
+	assert.ErrorContains(t, err, `sort "name--" is not allowed`)
+
+	assert.Empty(t, statements)
+}
+
+// TestPlayerEvent is a join table with a composite primary key
+type TestPlayerEvent struct {
+	PlayerID uint `json:"player_id" gorm:"primaryKey;autoIncrement:false"`
+	EventID  uint `json:"event_id" gorm:"primaryKey;autoIncrement:false"`
+	Score    int  `json:"score"`
+}
+
+func TestCompositePrimaryKey(t *testing.T) {
+	db, _ := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	db.AutoMigrate(&TestPlayerEvent{})
+	db.Create(&[]TestPlayerEvent{
+		{PlayerID: 1, EventID: 1, Score: 10},
+		{PlayerID: 1, EventID: 2, Score: 10},
+		{PlayerID: 2, EventID: 1, Score: 10},
+		{PlayerID: 2, EventID: 2, Score: 5},
+		{PlayerID: 3, EventID: 1, Score: 5},
+	})
+
+	builder := NewSimpleQueryBuilder("test_player_events").WithDefaultSort("score desc")
+
+	// The cursor tiebreaker holds every key column
+	pagination := PaginationRequest{Page: 1, PerPage: 2}
+	var rows []TestPlayerEvent
+	assert.NoError(t, db.Order("score desc, player_id desc, event_id desc").Limit(2).Find(&rows).Error)
+
+	cursor, err := CursorFromPage(db, builder, pagination, rows)
+	assert.NoError(t, err)
+	fields, err := DecodeCursor(cursor, EffectiveSort(builder, pagination))
+	assert.NoError(t, err)
+	assert.Equal(t, []CursorField{
+		{Field: "score", Direction: "desc", Value: int64(10)},
+		{Field: "player_id", Direction: "desc", Value: int64(rows[1].PlayerID), Tiebreaker: true},
+		{Field: "event_id", Direction: "desc", Value: int64(rows[1].EventID), Tiebreaker: true},
+	}, fields)
+
+	// Following cursors visits every row exactly once
+	seen := map[[2]uint]bool{}
+	for _, row := range rows {
+		seen[[2]uint{row.PlayerID, row.EventID}] = true
+	}
+	for cursor != "" {
+		fields, err := DecodeCursor(cursor, EffectiveSort(builder, pagination))
+		assert.NoError(t, err)
+		condition, args, err := CursorCondition(fields)
+		assert.NoError(t, err)
+
+		var page []TestPlayerEvent
+		assert.NoError(t, db.Table("test_player_events").Where(condition, args...).Order(CursorOrder(fields)).Limit(2).Find(&page).Error)
+		for _, row := range page {
+			key := [2]uint{row.PlayerID, row.EventID}
+			assert.False(t, seen[key], "row %v repeated", key)
+			seen[key] = true
+		}
+		cursor, err = CursorFromPage(db, builder, pagination, page)
+		assert.NoError(t, err)
+	}
+	assert.Len(t, seen, 5)
+
+	// Sorting by a key column leaves only the other as a tiebreaker
+	pagination = PaginationRequest{Page: 1, PerPage: 2, Sort: "player_id"}
+	rows, _, err = PaginatedQuery[TestPlayerEvent](db, builder, pagination, []string{})
+	assert.NoError(t, err)
+	cursor, err = CursorFromPage(db, builder, pagination, rows)
+	assert.NoError(t, err)
+	fields, err = DecodeCursor(cursor, EffectiveSort(builder, pagination))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"player_id", "event_id"}, []string{fields[0].Field, fields[1].Field})
+	assert.Len(t, fields, 2)
+
+	// Distinct key combinations are counted in a subquery on SQLite
+	for _, expression := range []string{"COUNT(DISTINCT (player_id, event_id))", "count(distinct player_id, event_id)"} {
+		total, err := CountOnly(db, NewSimpleQueryBuilder("test_player_events").WithCountExpression(expression), PaginationRequest{Page: 1, PerPage: 10})
+		assert.NoError(t, err, expression)
+		assert.Equal(t, int64(5), total, expression)
+	}
+
+	for _, tt := range []struct {
+		dialect  DatabaseDialect
+		expected string
+	}{
+		{PostgreSQL, "COUNT(DISTINCT (player_id, event_id))"},
+		{MySQL, "COUNT(DISTINCT player_id, event_id)"},
+	} {
+		expression, columns, err := parseCountExpression("count(distinct (player_id, event_id))", tt.dialect)
+		assert.NoError(t, err)
+		assert.Equal(t, tt.expected, expression)
+		assert.Empty(t, columns)
+	}
+	for _, expression := range []string{"COUNT(player_id, event_id)", "COUNT(DISTINCT (player_id, 1 + 1))"} {
+		_, _, err := parseCountExpression(expression, PostgreSQL)
+		assert.ErrorIs(t, err, ErrInvalidCountExpression, expression)
+	}
+}
+
+// testStrictSortFilter rejects sorts other than name and age
+type testStrictSortFilter struct {
+	testExportFilter
+}
+
+func (f *testStrictSortFilter) GetStrictSort() bool { return true }
+
+func TestStrictSort(t *testing.T) {
+	db := setupTestDB()
+	gin.SetMode(gin.TestMode)
+
+	builder := NewSimpleQueryBuilder("test_users").WithSortableFields("name", "age").WithStrictSort(true)
+
+	users, total, err := PaginatedQuery[TestUser](db, builder, PaginationRequest{Page: 1, PerPage: 10, Sort: "age", Order: "desc"}, []string{})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(5), total)
+	assert.Equal(t, "Bob Johnson", users[0].Name)
+
+	for _, sort := range []string{"email", "age; DROP TABLE test_users"} {
+		_, _, err = PaginatedQuery[TestUser](db, builder, PaginationRequest{Page: 1, PerPage: 10, Sort: sort}, []string{})
+		assert.ErrorIs(t, err, ErrInvalidSortField, sort)
+		assert.Equal(t, 400, errorResponse(err).Code)
+	}
+
+	// Without an allowlist an unknown column is rejected once the database fails on it
+	builder = NewSimpleQueryBuilder("test_users").WithStrictSort(true)
+	_, _, err = PaginatedQuery[TestUser](db, builder, PaginationRequest{Page: 1, PerPage: 10, Sort: "missing"}, []string{})
+	assert.ErrorIs(t, err, ErrInvalidSortField)
+
+	// Other data query errors are not blamed on the sort
+	failingDB := setupTestDB()
+	failingDB.Callback().Query().After("gorm:query").Register("test:fail_data_query", func(tx *gorm.DB) {
+		if strings.Contains(tx.Statement.SQL.String(), "LIMIT") {
+			tx.AddError(errors.New("connection reset by peer"))
+		}
+	})
+	_, _, err = PaginatedQuery[TestUser](failingDB, builder, PaginationRequest{Page: 1, PerPage: 10, Sort: "name"}, []string{})
+	assert.ErrorContains(t, err, "connection reset by peer")
+	assert.NotErrorIs(t, err, ErrInvalidSortField)
+	assert.Equal(t, 500, errorResponse(err).Code)
+
+	_, _, err = PaginatedQueryWithSortAllow[TestUser](db, builder, PaginationRequest{Page: 1, PerPage: 10, Sort: "email"}, []string{"name"})
+	assert.ErrorIs(t, err, ErrInvalidSortField)
+
+	// The default stays lenient
+	_, _, err = PaginatedQuery[TestUser](db, NewSimpleQueryBuilder("test_users").WithSortableFields("name"), PaginationRequest{Page: 1, PerPage: 10, Sort: "email"}, []string{})
+	assert.NoError(t, err)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request, _ = http.NewRequest("GET", "/?sort=email", nil)
+	filter := &testStrictSortFilter{testExportFilter{testAgeRangeFilter{DynamicFilter: DynamicFilter{TableName: "test_users", Model: TestUser{}}}}}
+	response := PaginatedAPIResponseWithCustomFilter[TestUser](db, c, filter, "Success")
+	assert.Equal(t, 400, response.Code)
+	assert.Equal(t, `Invalid query parameters: invalid sort field: "email"`, response.Message)
+}
+
+func TestRespondCountHeaders(t *testing.T) {
+	db := setupTestDB()
+	gin.SetMode(gin.TestMode)
+
+	var statements []string
+	db.Callback().Query().After("gorm:query").Register("test:record_sql", func(tx *gorm.DB) {
+		statements = append(statements, tx.Statement.SQL.String())
+	})
+
+	builder := NewSimpleQueryBuilder("test_users").WithSearchFields("name")
+	handler := func(c *gin.Context) {
+		pagination := BindPagination(c)
+		handled, err := RespondCountHeaders(c, db, builder, pagination, c.Query("count_only") == "true")
+		if err != nil {
+			c.AbortWithStatus(500)
+			return
+		}
+		if handled {
+			return
+		}
+		c.JSON(200, PaginatedAPIResponse[TestUser](db, c, "test_users", []string{"name"}, "Success"))
+	}
+	router := gin.New()
+	router.GET("/users", handler)
+	router.HEAD("/users", handler)
+
+	for _, url := range []string{"/users?per_page=2", "/users?per_page=2&count_only=true"} {
+		method := "HEAD"
+		if strings.Contains(url, "count_only") {
+			method = "GET"
+		}
+		statements = nil
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(method, url, nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, 200, w.Code, url)
+		assert.Equal(t, "5", w.Header().Get(TotalCountHeader), url)
+		assert.Equal(t, "3", w.Header().Get(TotalPagesHeader), url)
+		assert.Empty(t, w.Body.String(), url)
+		if assert.Len(t, statements, 1, url) {
+			assert.Contains(t, statements[0], "count(*)")
+		}
+	}
+
+	// The search narrows the count
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("HEAD", "/users?search=john", nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, "2", w.Header().Get(TotalCountHeader))
+	assert.Equal(t, "1", w.Header().Get(TotalPagesHeader))
+
+	// Other requests list the page as usual
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/users?per_page=2", nil)
+	router.ServeHTTP(w, req)
+	assert.Empty(t, w.Header().Get(TotalCountHeader))
+	assert.Contains(t, w.Body.String(), `"data"`)
+}
+
+func TestTokenizedSearch(t *testing.T) {
+	db := setupTestDB()
+
+	builder := NewSimpleQueryBuilder("test_users").WithSearchFields("name", "email")
+
+	// As a single phrase the words are never adjacent
+	_, total, err := PaginatedQuery[TestUser](db, builder, PaginationRequest{Page: 1, PerPage: 10, Search: "johnson bob@"}, []string{})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), total)
+
+	builder.WithTokenizedSearch(true)
+
+	// Each word may match a different field
+	users, total, err := PaginatedQuery[TestUser](db, builder, PaginationRequest{Page: 1, PerPage: 10, Search: "johnson  bob@"}, []string{})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	if assert.Len(t, users, 1) {
+		assert.Equal(t, "Bob Johnson", users[0].Name)
+	}
+
+	// Every word must match
+	_, total, err = PaginatedQuery[TestUser](db, builder, PaginationRequest{Page: 1, PerPage: 10, Search: "john jane"}, []string{})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), total)
+
+	// A single word searches as before, without surrounding spaces
+	_, total, err = PaginatedQuery[TestUser](db, builder, PaginationRequest{Page: 1, PerPage: 10, Search: " john "}, []string{})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+
+	stmt := applySearch(db.Session(&gorm.Session{DryRun: true}).Table("test_users"), builder, "a b", SQLite).Find(&[]TestUser{}).Statement
+	assert.Contains(t, stmt.SQL.String(), "((name LIKE ? OR email LIKE ?) AND (name LIKE ? OR email LIKE ?))")
+	assert.Equal(t, []interface{}{"%a%", "%a%", "%b%", "%b%"}, stmt.Vars)
+}
+
+func TestPaginateAfterID(t *testing.T) {
+	db := setupTestDB()
+
+	var statements []string
+	db.Callback().Query().After("gorm:query").Register("test:record_sql", func(tx *gorm.DB) {
+		statements = append(statements, tx.Statement.SQL.String())
+	})
+
+	builder := NewSimpleQueryBuilder("test_users").WithFilters(func(query *gorm.DB) *gorm.DB {
+		return query.Where("age < ?", 35)
+	})
+
+	// Iterate the feed to exhaustion, starting without an after id
+	var names []string
+	var afterID int64
+	for calls := 0; ; calls++ {
+		assert.Less(t, calls, 4)
+		users, lastID, err := PaginateAfterID[TestUser](db, builder, afterID, 2)
+		assert.NoError(t, err)
+		if len(users) == 0 {
+			assert.Equal(t, afterID, lastID)
+			break
+		}
+		for _, user := range users {
+			names = append(names, user.Name)
+		}
+		assert.Equal(t, int64(users[len(users)-1].ID), lastID)
+		afterID = lastID
+	}
+	assert.Equal(t, []string{"John Doe", "Jane Smith", "Alice Brown", "Charlie Wilson"}, names)
+	assert.Contains(t, statements[1], "test_users.id > ?")
+	assert.Contains(t, statements[1], "ORDER BY test_users.id asc LIMIT 3")
+	assert.NotContains(t, statements[0], "test_users.id >")
+
+	// Composite keys have no single id to continue after
+	_, _, err := PaginateAfterID[TestPlayerEvent](db, NewSimpleQueryBuilder("test_player_events"), 0, 10)
+	assert.Error(t, err)
+}
+
+func TestFieldSelection(t *testing.T) {
+	selection, err := ParseFieldSelection("id, name,athletes{name,gender},code")
+	assert.NoError(t, err)
+	assert.Equal(t, &FieldSelection{
+		Fields:    []string{"id", "name", "code"},
+		Relations: map[string]*FieldSelection{"athletes": {Fields: []string{"name", "gender"}}},
+	}, selection)
+
+	nested, err := ParseFieldSelection("name,province{name,region{code}}")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"code"}, nested.Relations["province"].Relations["region"].Fields)
+
+	for _, fields := range []string{"name,athletes{name", "name}", "{name}"} {
+		_, err := ParseFieldSelection(fields)
+		assert.ErrorIs(t, err, ErrInvalidFieldSelection, fields)
+	}
+
+	db := setupRelationTestDB()
+	var statements []string
+	db.Callback().Query().After("gorm:query").Register("test:record_sql", func(tx *gorm.DB) {
+		statements = append(statements, tx.Statement.SQL.String())
+	})
+
+	// Code, age and the unknown relation aren't selectable, so they're ignored
+	selection, err = ParseFieldSelection("name,code,athletes{name,age},sponsors{name}")
+	assert.NoError(t, err)
+	builder := NewSimpleQueryBuilder("test_provinces").
+		WithSelectableFields("", "id", "name").
+		WithSelectableFields("Athletes", "name", "gender").
+		WithFieldSelection(selection)
+
+	provinces, total, err := PaginatedQuery[TestProvince](db, builder, PaginationRequest{Page: 1, PerPage: 2}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), total)
+	if assert.Len(t, provinces, 2) {
+		assert.Equal(t, "DKI Jakarta", provinces[0].Name)
+		assert.Empty(t, provinces[0].Code)
+		if assert.Len(t, provinces[0].Athletes, 3) {
+			athlete := provinces[0].Athletes[0]
+			assert.Equal(t, "Budi Santoso", athlete.Name)
+			assert.Zero(t, athlete.Age)
+			assert.Equal(t, uint(1), athlete.ProvinceID)
+		}
+		assert.Len(t, provinces[1].Athletes, 2)
+	}
+	assert.Contains(t, statements[len(statements)-1], "SELECT test_provinces.name,test_provinces.id FROM")
+	assert.Contains(t, statements[len(statements)-2], "SELECT `name`,`id`,`province_id` FROM `test_athletes`")
+
+	// Without selectable fields for the relation it isn't loaded
+	builder = NewSimpleQueryBuilder("test_provinces").
+		WithSelectableFields("", "name").
+		WithFieldSelection(selection)
+	provinces, _, err = PaginatedQuery[TestProvince](db, builder, PaginationRequest{Page: 1, PerPage: 2}, nil)
+	assert.NoError(t, err)
+	assert.Empty(t, provinces[0].Athletes)
+	assert.Equal(t, uint(1), provinces[0].ID)
+}
+
+func TestEffectiveSearch(t *testing.T) {
+	db := setupTestDB()
+
+	builder := NewSimpleQueryBuilder("test_users").
+		WithSearchFields("name", "email").
+		WithTokenizedSearch(true).
+		WithEffectiveSearch()
+
+	users, paginationResponse, err := PaginateCore[TestUser](db, builder, PaginationRequest{Page: 1, PerPage: 10, Search: "  johnson   bob@ "})
+	assert.NoError(t, err)
+	assert.Len(t, users, 1)
+	if assert.NotNil(t, paginationResponse.EffectiveSearch) {
+		assert.Equal(t, "johnson bob@", *paginationResponse.EffectiveSearch)
+	}
+
+	body, err := json.Marshal(paginationResponse)
+	assert.NoError(t, err)
+	assert.Contains(t, string(body), `"effective_search":"johnson bob@"`)
+
+	// A search the builder can't run is reported as ignored
+	ignored := NewSimpleQueryBuilder("test_users").WithEffectiveSearch()
+	_, paginationResponse, err = PaginateCore[TestUser](db, ignored, PaginationRequest{Page: 1, PerPage: 10, Search: "john"})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(5), paginationResponse.Total)
+	if assert.NotNil(t, paginationResponse.EffectiveSearch) {
+		assert.Empty(t, *paginationResponse.EffectiveSearch)
+	}
+
+	// Only echoed when enabled
+	_, paginationResponse, err = PaginateCore[TestUser](db, NewSimpleQueryBuilder("test_users").WithSearchFields("name"), PaginationRequest{Page: 1, PerPage: 10, Search: "john"})
+	assert.NoError(t, err)
+	assert.Nil(t, paginationResponse.EffectiveSearch)
+}
+
+func TestRegisterStatusMapping(t *testing.T) {
+	t.Cleanup(func() {
+		statusMappingsMu.Lock()
+		statusMappings = nil
+		statusMappingsMu.Unlock()
+	})
+
+	RegisterStatusMapping(StatusRange{300, 399}, "redirect")
+	RegisterStatusMapping(StatusRange{206, 206}, "partial")
+	RegisterStatusMapping(StatusRange{304, 304}, "not_modified")
+
+	tests := []struct {
+		code     int
+		expected string
+	}{
+		{200, "success"},
+		{206, "partial"},
+		{301, "redirect"},
+		{399, "redirect"},
+		{304, "not_modified"},
+		{404, "error"},
+		{500, "error"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, NewPaginatedResponse(tt.code, "", nil, PaginationResponse{}).Status, tt.code)
+	}
+}
+
+// testViewerKey holds the maximum age the test viewer may see
+type testViewerKey struct{}
+
+func TestContextPredicate(t *testing.T) {
+	db := setupTestDB()
+	gin.SetMode(gin.TestMode)
+
+	builder := NewSimpleQueryBuilder("test_users").
+		WithSearchFields("name").
+		WithFilters(func(query *gorm.DB) *gorm.DB {
+			return query.Where("name = ?", "Jane Smith").Or("name = ?", "Bob Johnson")
+		}).
+		WithContextPredicate(func(ctx context.Context) (string, []interface{}) {
+			maxAge, ok := ctx.Value(testViewerKey{}).(int)
+			if !ok {
+				return "1 = 0", nil
+			}
+			return "age <= ?", []interface{}{maxAge}
+		})
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		if maxAge, err := strconv.Atoi(c.GetHeader("X-Max-Age")); err == nil {
+			c.Request = c.Request.WithContext(context.WithValue(c.Request.Context(), testViewerKey{}, maxAge))
+		}
+	})
+	router.GET("/users", func(c *gin.Context) {
+		data, paginationResponse, err := PaginateCore[TestUser](db.WithContext(c.Request.Context()), builder, BindPagination(c))
+		if err != nil {
+			c.AbortWithStatus(500)
+			return
+		}
+		c.JSON(200, NewPaginatedResponse(200, "Success", data, paginationResponse))
+	})
+
+	// The same endpoint gives each viewer its own rows and totals, and the filter's OR
+	// can't escape the predicate
+	for _, tt := range []struct {
+		maxAge string
+		total  int64
+	}{
+		{"40", 2},
+		{"30", 1},
+		{"20", 0},
+		{"", 0},
+	} {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/users", nil)
+		req.Header.Set("X-Max-Age", tt.maxAge)
+		router.ServeHTTP(w, req)
+
+		var response PaginatedResponse
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, tt.total, response.Pagination.Total, tt.maxAge)
+		assert.Len(t, response.Data, int(tt.total), tt.maxAge)
+	}
+
+	// An empty predicate fails closed
+	emptyPredicate := NewSimpleQueryBuilder("test_users").WithContextPredicate(func(context.Context) (string, []interface{}) {
+		return "", nil
+	})
+	users, total, err := PaginatedQuery[TestUser](db, emptyPredicate, PaginationRequest{Page: 1, PerPage: 10}, []string{})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), total)
+	assert.Empty(t, users)
+
+	// Estimates can't see the predicate, so viewers always get exact totals
+	builder.WithCountEstimator(func(*gorm.DB, PaginationRequest) (int64, bool, error) {
+		return 1000, true, nil
+	})
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/users", nil)
+	req.Header.Set("X-Max-Age", "30")
+	router.ServeHTTP(w, req)
+
+	var response PaginatedResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, int64(1), response.Pagination.Total)
+}
+
+func TestAggregates(t *testing.T) {
+	db := setupRelationTestDB()
+
+	builder := NewSimpleQueryBuilder("test_athletes").
+		WithSearchFields("name").
+		WithFilters(func(query *gorm.DB) *gorm.DB { return query.Where("gender = ?", "Female") }).
+		WithAggregates(map[string]string{"total_height": "SUM(height)", "max_age": "MAX(age)"})
+
+	// Computed over every filtered row, not just the page
+	athletes, paginationResponse, err := PaginateCore[TestAthlete](db, builder, PaginationRequest{Page: 1, PerPage: 1})
+	assert.NoError(t, err)
+	assert.Len(t, athletes, 1)
+	assert.Equal(t, int64(3), paginationResponse.Total)
+	assert.Equal(t, map[string]interface{}{"total_height": int64(165 + 162 + 168), "max_age": int64(31)}, paginationResponse.Aggregates)
+
+	body, err := json.Marshal(paginationResponse)
+	assert.NoError(t, err)
+	assert.Contains(t, string(body), `"aggregates":{"max_age":31,"total_height":495}`)
+
+	// The search narrows them too
+	_, paginationResponse, err = PaginateCore[TestAthlete](db, builder, PaginationRequest{Page: 1, PerPage: 10, Search: "Dewi"})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(168), paginationResponse.Aggregates["total_height"])
+
+	// Over no rows they're null
+	_, paginationResponse, err = PaginateCore[TestAthlete](db, builder, PaginationRequest{Page: 1, PerPage: 10, Search: "nobody"})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"total_height": nil, "max_age": nil}, paginationResponse.Aggregates)
+
+	_, _, err = PaginateCore[TestAthlete](db, NewSimpleQueryBuilder("test_athletes").WithAggregates(map[string]string{"total; --": "SUM(height)"}), PaginationRequest{Page: 1, PerPage: 10})
+	assert.Error(t, err)
+
+	// Without aggregates the response has none
+	_, paginationResponse, err = PaginateCore[TestAthlete](db, NewSimpleQueryBuilder("test_athletes"), PaginationRequest{Page: 1, PerPage: 10})
+	assert.NoError(t, err)
+	assert.Nil(t, paginationResponse.Aggregates)
+}
+
+func TestDisabledIncludesPolicy(t *testing.T) {
+	db := setupRelationTestDB()
+
+	newBuilder := func(policy DisabledIncludesPolicy) *includingBuilder {
+		return &includingBuilder{NewSimpleQueryBuilder("test_provinces").WithDisabledIncludesPolicy(policy), []string{"Athletes"}}
+	}
+	disabled := PaginationRequest{Page: 1, PerPage: 10, IsDisabled: true}
+
+	// By default disabled pagination still loads includes
+	provinces, paginationResponse, err := PaginateCore[TestProvince](db, newBuilder(DisabledIncludesAllow), disabled)
+	assert.NoError(t, err)
+	assert.Len(t, provinces[0].Athletes, 3)
+	assert.Empty(t, paginationResponse.SkippedIncludes)
+
+	provinces, paginationResponse, err = PaginateCore[TestProvince](db, newBuilder(DisabledIncludesSkip), disabled)
+	assert.NoError(t, err)
+	assert.Len(t, provinces, 3)
+	assert.Empty(t, provinces[0].Athletes)
+	assert.Equal(t, []string{"Athletes"}, paginationResponse.SkippedIncludes)
+
+	_, _, err = PaginateCore[TestProvince](db, newBuilder(DisabledIncludesReject), disabled)
+	assert.ErrorIs(t, err, ErrIncludesWithDisabledPagination)
+	assert.Equal(t, 400, errorResponse(err).Code)
+
+	// Paginated requests are unaffected
+	for _, policy := range []DisabledIncludesPolicy{DisabledIncludesSkip, DisabledIncludesReject} {
+		provinces, _, err = PaginateCore[TestProvince](db, newBuilder(policy), PaginationRequest{Page: 1, PerPage: 10})
+		assert.NoError(t, err)
+		assert.Len(t, provinces[0].Athletes, 3)
+	}
+
+	// So is disabled pagination without includes
+	_, _, err = PaginateCore[TestProvince](db, NewSimpleQueryBuilder("test_provinces").WithDisabledIncludesPolicy(DisabledIncludesReject), disabled)
+	assert.NoError(t, err)
+
+	// A relation loaded by the field selection counts as an include
+	selection, err := ParseFieldSelection("name,athletes{name}")
+	assert.NoError(t, err)
+	selecting := NewSimpleQueryBuilder("test_provinces").
+		WithSelectableFields("", "name").
+		WithSelectableFields("Athletes", "name").
+		WithFieldSelection(selection).
+		WithDisabledIncludesPolicy(DisabledIncludesReject)
+	_, _, err = PaginateCore[TestProvince](db, selecting, disabled)
+	assert.ErrorIs(t, err, ErrIncludesWithDisabledPagination)
+	assert.Equal(t, 400, errorResponse(err).Code)
+
+	provinces, _, err = PaginateCore[TestProvince](db, selecting, PaginationRequest{Page: 1, PerPage: 10})
+	assert.NoError(t, err)
+	assert.Len(t, provinces[0].Athletes, 3)
+
+	// Selecting only base table fields doesn't
+	selection, err = ParseFieldSelection("name")
+	assert.NoError(t, err)
+	_, _, err = PaginateCore[TestProvince](db, selecting.WithFieldSelection(selection), disabled)
+	assert.NoError(t, err)
+}
+
+func TestQueryPlan(t *testing.T) {
+	db := setupTestDB()
+	t.Cleanup(func() {
+		SetExplainEnabled(false)
+		SetExplainAnalyzeAllowed(false)
+	})
+
+	builder := NewSimpleQueryBuilder("test_users").WithSearchFields("name").WithExplain(true)
+	pagination := PaginationRequest{Page: 1, PerPage: 2, Search: "o", Sort: "age"}
+
+	// Disabled by default
+	_, paginationResponse, err := PaginateCore[TestUser](db, builder, pagination)
+	assert.NoError(t, err)
+	assert.Nil(t, paginationResponse.QueryPlan)
+
+	SetExplainEnabled(true)
+	users, paginationResponse, err := PaginateCore[TestUser](db, builder, pagination)
+	assert.NoError(t, err)
+	assert.Len(t, users, 2)
+	if assert.NotEmpty(t, paginationResponse.QueryPlan) {
+		assert.Contains(t, strings.Join(paginationResponse.QueryPlan, "\n"), "test_users")
+	}
+
+	body, err := json.Marshal(paginationResponse)
+	assert.NoError(t, err)
+	assert.Contains(t, string(body), `"query_plan":[`)
+
+	// Only builders asking for it get a plan
+	_, paginationResponse, err = PaginateCore[TestUser](db, NewSimpleQueryBuilder("test_users"), pagination)
+	assert.NoError(t, err)
+	assert.Nil(t, paginationResponse.QueryPlan)
+
+	// ANALYZE is only used when allowed
+	for _, tt := range []struct {
+		dialect  DatabaseDialect
+		analyze  bool
+		expected string
+	}{
+		{PostgreSQL, false, "EXPLAIN "},
+		{PostgreSQL, true, "EXPLAIN ANALYZE "},
+		{MySQL, true, "EXPLAIN ANALYZE "},
+		{SQLite, true, "EXPLAIN QUERY PLAN "},
+	} {
+		prefix, ok := explainPrefix(tt.dialect, tt.analyze)
+		assert.True(t, ok)
+		assert.Equal(t, tt.expected, prefix)
+	}
+	_, ok := explainPrefix(SQLServer, false)
+	assert.False(t, ok)
+
+	var statements []string
+	db.Callback().Raw().After("gorm:raw").Register("test:record_sql", func(tx *gorm.DB) {
+		statements = append(statements, tx.Statement.SQL.String())
+	})
+	db.Callback().Row().After("gorm:row").Register("test:record_sql", func(tx *gorm.DB) {
+		statements = append(statements, tx.Statement.SQL.String())
+	})
+	_, _, err = PaginateCore[TestUser](db, builder, pagination)
+	assert.NoError(t, err)
+	if assert.NotEmpty(t, statements) {
+		assert.True(t, strings.HasPrefix(statements[len(statements)-1], "EXPLAIN QUERY PLAN SELECT"), statements[len(statements)-1])
+	}
+}
+
+func TestColumnComparisonFilter(t *testing.T) {
+	db := setupTestDB()
+	db.AutoMigrate(&TestEvent{})
+
+	start := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	db.Create(&[]TestEvent{
+		{Name: "Valid", StartDate: start, EndDate: start.AddDate(0, 0, 2)},
+		{Name: "Ends Before Start", StartDate: start, EndDate: start.AddDate(0, 0, -1)},
+		{Name: "Same Day", StartDate: start, EndDate: start},
+	})
+
+	filter := &DynamicFilter{
+		TableName: "test_events",
+		Model:     TestEvent{},
+		Filters: []FilterCondition{
+			{Field: "end_date", Operator: "<", CompareField: "start_date"},
+		},
+	}
+	applied, err := filter.AppliedConditions()
+	assert.NoError(t, err)
+	assert.Equal(t, []AppliedCondition{{SQL: "end_date < start_date", Logic: "AND"}}, applied)
+
+	events, total, err := PaginatedQuery[TestEvent](db, filter, PaginationRequest{Page: 1, PerPage: 10}, []string{})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	if assert.Len(t, events, 1) {
+		assert.Equal(t, "Ends Before Start", events[0].Name)
+	}
+
+	filter.Filters[0].Operator = "lte"
+	_, total, err = PaginatedQuery[TestEvent](db, filter, PaginationRequest{Page: 1, PerPage: 10}, []string{})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+	assert.False(t, filter.HasImpossibleConditions())
+
+	// The compared column is validated like the filtered one, failing rather than
+	// dropping the condition
+	for _, compareField := range []string{"start_date; DROP TABLE test_events", "unknown_column", "start_date*5"} {
+		filter.Filters[0].CompareField = compareField
+		_, err = filter.AppliedConditions()
+		assert.ErrorIs(t, err, ErrInvalidColumnComparison, compareField)
+
+		_, _, err = PaginatedQuery[TestEvent](db, filter, PaginationRequest{Page: 1, PerPage: 10}, []string{})
+		assert.ErrorIs(t, err, ErrInvalidColumnComparison, compareField)
+	}
+
+	filter.Filters[0] = FilterCondition{Field: "end_date", Operator: "LIKE", CompareField: "start_date"}
+	_, err = filter.AppliedConditions()
+	assert.ErrorIs(t, err, ErrInvalidColumnComparison)
+}
+
+func TestPaginateAfterIDPageHasNext(t *testing.T) {
+	db := setupTestDB()
+
+	builder := NewSimpleQueryBuilder("test_users").WithFilters(func(query *gorm.DB) *gorm.DB {
+		return query.Where("age < ?", 35)
+	})
+
+	// Four matching users: the second page of two ends exactly at the last row
+	first, err := PaginateAfterIDPage[TestUser](db, builder, 0, 2)
+	assert.NoError(t, err)
+	assert.Len(t, first.Data, 2)
+	assert.True(t, first.HasNext)
+	assert.Equal(t, int64(first.Data[1].ID), first.LastID)
+
+	last, err := PaginateAfterIDPage[TestUser](db, builder, first.LastID, 2)
+	assert.NoError(t, err)
+	assert.Len(t, last.Data, 2)
+	assert.False(t, last.HasNext)
+	assert.Equal(t, int64(last.Data[1].ID), last.LastID)
+
+	exhausted, err := PaginateAfterIDPage[TestUser](db, builder, last.LastID, 2)
+	assert.NoError(t, err)
+	assert.Empty(t, exhausted.Data)
+	assert.False(t, exhausted.HasNext)
+	assert.Equal(t, last.LastID, exhausted.LastID)
+
+	// A short final page has no next page either
+	short, err := PaginateAfterIDPage[TestUser](db, builder, first.LastID, 3)
+	assert.NoError(t, err)
+	assert.Len(t, short.Data, 2)
+	assert.False(t, short.HasNext)
+}
+
+func TestRespondPaginated(t *testing.T) {
+	db := setupTestDB()
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	router.GET("/users", func(c *gin.Context) {
+		RespondPaginated[TestUser](c, db, &testAgeRangeFilter{DynamicFilter: DynamicFilter{TableName: "test_users", Model: TestUser{}}}, "Users retrieved")
+	})
+	router.GET("/missing", func(c *gin.Context) {
+		RespondPaginated[TestUser](c, db, &DynamicFilter{TableName: "missing_users", Model: TestUser{}}, "Users retrieved")
+	})
+
+	serve := func(url string) (*httptest.ResponseRecorder, PaginatedResponse) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", url, nil)
+		router.ServeHTTP(w, req)
+
+		var response PaginatedResponse
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		return w, response
+	}
+
+	w, response := serve("/users?min_age=26&max_age=31&per_page=1")
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, 200, response.Code)
+	assert.Equal(t, "Users retrieved", response.Message)
+	assert.Equal(t, int64(2), response.Pagination.Total)
+	assert.Len(t, response.Data, 1)
+
+	w, response = serve("/users?min_age=40&max_age=20")
+	assert.Equal(t, 400, w.Code)
+	assert.Equal(t, "Invalid query parameters: min_age must not exceed max_age", response.Message)
+
+	w, response = serve("/missing")
+	assert.Equal(t, 500, w.Code)
+	assert.Equal(t, 500, response.Code)
+}
+
+func TestPaginatedQueryByCursor(t *testing.T) {
+	db := setupTestDB()
+
+	var statements []string
+	db.Callback().Query().After("gorm:query").Register("test:record_sql", func(tx *gorm.DB) {
+		statements = append(statements, tx.Statement.SQL.String())
+	})
+
+	builder := NewSimpleQueryBuilder("test_users")
+	names := func(users []TestUser) []string {
+		var result []string
+		for _, user := range users {
+			result = append(result, user.Name)
+		}
+		return result
+	}
+
+	// Walk forward through every page, ordered by the default sort on id
+	var pages [][]string
+	var cursors []CursorPaginationResponse
+	request := CursorPaginationRequest{PerPage: 2}
+	for calls := 0; ; calls++ {
+		assert.Less(t, calls, 4)
+		users, response, err := PaginatedQueryByCursor[TestUser](db, builder, request)
+		assert.NoError(t, err)
+		pages = append(pages, names(users))
+		cursors = append(cursors, response)
+		if !response.HasNext {
+			break
+		}
+		request.Cursor = response.NextCursor
+	}
+	assert.Equal(t, [][]string{
+		{"John Doe", "Jane Smith"},
+		{"Bob Johnson", "Alice Brown"},
+		{"Charlie Wilson"},
+	}, pages)
+	assert.False(t, cursors[0].HasPrev)
+	assert.True(t, cursors[1].HasPrev)
+	assert.Equal(t, "", cursors[2].NextCursor)
+	assert.Contains(t, statements[1], "(id > ?)")
+	assert.Contains(t, statements[1], "ORDER BY id asc LIMIT 3")
+	for _, statement := range statements {
+		assert.NotContains(t, statement, "OFFSET")
+	}
+
+	// The previous cursor of the last page leads back to the middle one, still in order
+	users, response, err := PaginatedQueryByCursor[TestUser](db, builder, CursorPaginationRequest{Cursor: cursors[2].PrevCursor, PerPage: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Bob Johnson", "Alice Brown"}, names(users))
+	assert.True(t, response.HasPrev)
+	assert.True(t, response.HasNext)
+	assert.Contains(t, statements[len(statements)-1], "(id < ?)")
+
+	users, response, err = PaginatedQueryByCursor[TestUser](db, builder, CursorPaginationRequest{Cursor: response.PrevCursor, PerPage: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"John Doe", "Jane Smith"}, names(users))
+	assert.False(t, response.HasPrev)
+
+	// order=desc sorts the default sort's columns descending
+	users, response, err = PaginatedQueryByCursor[TestUser](db, builder, CursorPaginationRequest{PerPage: 2, Order: "desc"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Charlie Wilson", "Alice Brown"}, names(users))
+	users, _, err = PaginatedQueryByCursor[TestUser](db, builder, CursorPaginationRequest{Cursor: response.NextCursor, PerPage: 2, Order: "desc"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Bob Johnson", "Jane Smith"}, names(users))
+
+	// The default sort picks the keyset columns, with the id as tiebreaker
+	byAge := NewSimpleQueryBuilder("test_users").WithDefaultSort("age desc")
+	users, response, err = PaginatedQueryByCursor[TestUser](db, byAge, CursorPaginationRequest{PerPage: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Bob Johnson", "Charlie Wilson"}, names(users))
+	users, _, err = PaginatedQueryByCursor[TestUser](db, byAge, CursorPaginationRequest{Cursor: response.NextCursor, PerPage: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Jane Smith", "Alice Brown"}, names(users))
+	assert.Contains(t, statements[len(statements)-1], "ORDER BY age desc, id desc")
+
+	// Without an order parameter a desc default sort keeps its direction; with one the
+	// order sets it, as on offset pages
+	bindCursor := func(url string) CursorPaginationRequest {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request, _ = http.NewRequest("GET", url, nil)
+		return BindCursorPagination(c)
+	}
+	for _, tt := range []struct {
+		url      string
+		expected []string
+		order    string
+	}{
+		{"/?per_page=2", []string{"Bob Johnson", "Charlie Wilson"}, "ORDER BY age desc, id desc"},
+		{"/?per_page=2&order=sideways", []string{"Bob Johnson", "Charlie Wilson"}, "ORDER BY age desc, id desc"},
+		{"/?per_page=2&order=desc", []string{"Bob Johnson", "Charlie Wilson"}, "ORDER BY age desc, id desc"},
+		{"/?per_page=2&order=asc", []string{"John Doe", "Alice Brown"}, "ORDER BY age asc, id asc"},
+	} {
+		users, _, err = PaginatedQueryByCursor[TestUser](db, byAge, bindCursor(tt.url))
+		assert.NoError(t, err, tt.url)
+		assert.Equal(t, tt.expected, names(users), tt.url)
+		assert.Contains(t, statements[len(statements)-1], tt.order, tt.url)
+	}
+	assert.Equal(t, "", bindCursor("/").Order)
+
+	// A cursor issued for another sort is rejected
+	_, _, err = PaginatedQueryByCursor[TestUser](db, builder, CursorPaginationRequest{Cursor: response.NextCursor, PerPage: 2})
+	assert.ErrorIs(t, err, ErrCursorSortMismatch)
+	_, _, err = PaginatedQueryByCursor[TestUser](db, builder, CursorPaginationRequest{Cursor: "not a cursor"})
+	assert.ErrorIs(t, err, ErrInvalidCursor)
+}
+
+type testListingFilter struct {
+	DynamicFilter
+	IsActive *bool `form:"is_active" filter:"is_active"`
+}
+
+func (f *testListingFilter) ApplyFilters(query *gorm.DB) *gorm.DB {
+	return ApplyTaggedFilters(query, f)
+}
+
+func TestRegisterBooleanTokens(t *testing.T) {
+	t.Cleanup(func() {
+		booleanTokensMu.Lock()
+		booleanTokens = nil
+		booleanTokensMu.Unlock()
+	})
+	gin.SetMode(gin.TestMode)
+
+	db, _ := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	db.AutoMigrate(&TestListing{})
+	db.Create(&[]TestListing{
+		{Title: "Blue widget", IsActive: true},
+		{Title: "Red widget", IsActive: false},
+		{Title: "Green gadget", IsActive: true},
+	})
+
+	newContext := func(url string) *gin.Context {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest("GET", url, nil)
+		return c
+	}
+	newFilter := func() *testListingFilter {
+		return &testListingFilter{DynamicFilter: DynamicFilter{TableName: "test_listings", Model: TestListing{}}}
+	}
+
+	// Unregistered tokens are not booleans
+	_, ok := ParseBoolToken("ya")
+	assert.False(t, ok)
+	response := PaginatedAPIResponseWithCustomFilter[TestListing](db, newContext("/?is_active=tidak"), newFilter(), "Success")
+	assert.Equal(t, 400, response.Code)
+
+	RegisterBooleanTokens([]string{"ya", "benar"}, []string{"tidak", "salah"})
+
+	tests := []struct {
+		token    string
+		expected bool
+		ok       bool
+	}{
+		{"ya", true, true},
+		{"YA", true, true},
+		{" benar ", true, true},
+		{"tidak", false, true},
+		{"Salah", false, true},
+		{"yes", true, true},
+		{"off", false, true},
+		{"mungkin", false, false},
+	}
+	for _, tt := range tests {
+		parsed, ok := ParseBoolToken(tt.token)
+		assert.Equal(t, tt.expected, parsed, tt.token)
+		assert.Equal(t, tt.ok, ok, tt.token)
+	}
+
+	assert.True(t, BindPagination(newContext("/?is_disabled=ya")).IsDisabled)
+	assert.False(t, BindPagination(newContext("/?is_disabled=tidak")).IsDisabled)
+
+	// Bool fields of filters bind the tokens
+	response = PaginatedAPIResponseWithCustomFilter[TestListing](db, newContext("/?is_active=tidak"), newFilter(), "Success")
+	assert.Equal(t, 200, response.Code)
+	assert.Equal(t, int64(1), response.Pagination.Total)
+	response = PaginatedAPIResponseWithCustomFilter[TestListing](db, newContext("/?is_active=Ya"), newFilter(), "Success")
+	assert.Equal(t, int64(2), response.Pagination.Total)
+
+	// As do operator parameters on bool columns
+	response = PaginatedAPIResponseWithCustomFilter[TestListing](db, newContext("/?is_active[eq]=tidak"), &DynamicFilter{TableName: "test_listings", Model: TestListing{}}, "Success")
+	assert.Equal(t, 200, response.Code)
+	assert.Equal(t, int64(1), response.Pagination.Total)
+}
+
+type TestNote struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	Title     string    `json:"title"`
+	Pinned    bool      `json:"pinned"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func TestDatasetVersion(t *testing.T) {
+	db, _ := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	db.AutoMigrate(&TestNote{})
+	notes := []TestNote{{Title: "Groceries", Pinned: true}, {Title: "Ideas"}, {Title: "Errands", Pinned: true}}
+	db.Create(&notes)
+
+	builder := NewSimpleQueryBuilder("test_notes").WithDatasetVersion("updated_at").
+		WithFilters(func(query *gorm.DB) *gorm.DB { return query.Where("pinned = ?", true) })
+	version := func() string {
+		_, meta, err := PaginateCore[TestNote](db, builder, PaginationRequest{Page: 1, PerPage: 1})
+		assert.NoError(t, err)
+		assert.NotEmpty(t, meta.DatasetVersion)
+		return meta.DatasetVersion
+	}
+
+	first := version()
+	assert.Equal(t, first, version())
+
+	// Rows outside the filter don't change it
+	db.Model(&notes[1]).Update("title", "Better ideas")
+	assert.Equal(t, first, version())
+
+	// Updating a row on a later page does
+	db.Model(&notes[2]).Update("title", "Weekend errands")
+	updated := version()
+	assert.NotEqual(t, first, updated)
+
+	db.Delete(&notes[0])
+	assert.NotEqual(t, updated, version())
+
+	_, meta, err := PaginateCore[TestNote](db, NewSimpleQueryBuilder("test_notes"), PaginationRequest{Page: 1, PerPage: 1})
+	assert.NoError(t, err)
+	assert.Empty(t, meta.DatasetVersion)
+
+	assert.Panics(t, func() { NewSimpleQueryBuilder("test_notes").WithDatasetVersion("updated_at) OR (1") })
+}
+
+func TestMultiColumnSort(t *testing.T) {
+	db := setupTestDB()
+	db.Create(&TestUser{Name: "Aaron Lee", Email: "aaron@example.com", Age: 30})
+	gin.SetMode(gin.TestMode)
+
+	var statements []string
+	db.Callback().Query().After("gorm:query").Register("test:record_sql", func(tx *gorm.DB) {
+		statements = append(statements, tx.Statement.SQL.String())
+	})
+
+	bind := func(query string) PaginationRequest {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request, _ = http.NewRequest("GET", "/?"+query, nil)
+		return BindPagination(c)
+	}
+	names := func(users []TestUser) []string {
+		var result []string
+		for _, user := range users {
+			result = append(result, user.Name)
+		}
+		return result
+	}
+
+	pagination := bind("sort=age:desc,name:asc&per_page=3")
+	assert.Equal(t, []SortField{{Field: "age", Direction: "desc"}, {Field: "name", Direction: "asc"}}, pagination.Sorts)
+	assert.Equal(t, "age:desc,name:asc", pagination.Sort)
+
+	builder := NewSimpleQueryBuilder("test_users")
+	users, _, err := PaginatedQuery[TestUser](db, builder, pagination, []string{})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Bob Johnson", "Charlie Wilson", "Aaron Lee"}, names(users))
+	assert.Contains(t, statements[len(statements)-1], "ORDER BY age desc, name asc")
+
+	// Columns without a direction take the order parameter, or a bare direction after them
+	assert.Equal(t, []SortField{{Field: "age", Direction: "desc"}, {Field: "name", Direction: "asc"}}, bind("sort=age,name:asc&order=desc").Sorts)
+	assert.Equal(t, []SortField{{Field: "name", Direction: "desc"}}, bind("sort=name,desc").Sorts)
+	assert.Equal(t, []SortField{{Field: "age", Direction: "asc"}, {Field: "name", Direction: "desc"}}, ParseSortParam("age:up, name,desc,name:asc,asc", ""))
+
+	// Invalid columns are dropped, and the default sort is used when none remain
+	builder.WithWarnings()
+	users, meta, err := PaginateCore[TestUser](db, builder, bind("sort=age:desc,bad%20col:asc,name:asc"))
+	assert.NoError(t, err)
+	assert.Equal(t, "Bob Johnson", users[0].Name)
+	assert.Contains(t, statements[len(statements)-1], "ORDER BY age desc, name asc")
+	assert.Equal(t, []string{"sort 'bad col' invalid, ignored"}, meta.Warnings)
+
+	_, meta, err = PaginateCore[TestUser](db, builder, bind("sort=bad%20col:desc,name%20asc:asc"))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"sort 'bad col:desc,name asc:asc' invalid, using default"}, meta.Warnings)
+	assert.Contains(t, statements[len(statements)-1], "ORDER BY id asc")
+
+	// Sortable fields apply to every column
+	restricted := NewSimpleQueryBuilder("test_users").WithSortableFields("age")
+	assert.Equal(t, []SortField{{Field: "age", Direction: "desc"}}, EffectiveSort(restricted, bind("sort=name:asc,age:desc")))
+
+	// The single sort and order parameters still work
+	pagination = bind("sort=age&order=desc")
+	assert.Empty(t, pagination.Sorts)
+	users, _, err = PaginatedQuery[TestUser](db, builder, pagination, []string{})
+	assert.NoError(t, err)
+	assert.Equal(t, "Bob Johnson", users[0].Name)
+	assert.Contains(t, statements[len(statements)-1], "ORDER BY age desc")
+
+	// Under strict sorting, any invalid column rejects the request
+	_, _, err = PaginatedQuery[TestUser](db, NewSimpleQueryBuilder("test_users").WithStrictSort(true), bind("sort=age:desc,bad%20col:asc"), []string{})
+	assert.ErrorIs(t, err, ErrInvalidSortField)
+	assert.ErrorContains(t, err, `"bad col"`)
+}
+
+func TestSortFallbackOnlyForUnknownSortColumn(t *testing.T) {
+	db := setupTestDB()
+
+	// Other data query errors are not retried with the default sort
+	failingDB := setupTestDB()
+	var dataQueries int
+	failingDB.Callback().Query().After("gorm:query").Register("test:fail_data_query", func(tx *gorm.DB) {
+		if strings.Contains(tx.Statement.SQL.String(), "LIMIT") {
+			dataQueries++
+			tx.AddError(errors.New("connection reset by peer"))
+		}
+	})
+	_, _, err := PaginatedQuery[TestUser](failingDB, NewSimpleQueryBuilder("test_users"), PaginationRequest{Page: 1, PerPage: 10, Sort: "name"}, []string{})
+	assert.ErrorContains(t, err, "connection reset by peer")
+	assert.Equal(t, 1, dataQueries)
+
+	_, unknown := unknownSortColumn(errors.New("no such column: missing_filter_column"), []SortField{{Field: "name", Direction: "asc"}})
+	assert.False(t, unknown)
+	_, unknown = unknownSortColumn(errors.New("no such column: bid"), []SortField{{Field: "id", Direction: "asc"}})
+	assert.False(t, unknown)
+	_, unknown = unknownSortColumn(errors.New("connection reset by peer"), []SortField{{Field: "name", Direction: "asc"}})
+	assert.False(t, unknown)
+	for _, message := range []string{
+		"no such column: test_users.nickname",
+		"Error 1054 (42S22): Unknown column 'nickname' in 'order clause'",
+		`ERROR: column "nickname" does not exist (SQLSTATE 42703)`,
+		"mssql: Invalid column name 'nickname'.",
+		"no such column: NickName",
+	} {
+		field, unknown := unknownSortColumn(errors.New(message), []SortField{{Field: "name", Direction: "asc"}, {Field: "nickname", Direction: "desc"}})
+		assert.True(t, unknown, message)
+		assert.Equal(t, "nickname", field.Field, message)
+	}
+
+	// Fallbacks are logged once per table, whatever sorts clients send
+	for _, sort := range []string{"random_a", "random_b", "random_c"} {
+		_, _, err = PaginatedQuery[TestUser](db, NewSimpleQueryBuilder("test_users"), PaginationRequest{Page: 1, PerPage: 10, Sort: sort}, []string{})
+		assert.NoError(t, err)
+	}
+	sortFallbacksLogged.Range(func(key, _ interface{}) bool {
+		assert.NotContains(t, key, "random_")
+		return true
+	})
+}
@@ -1,12 +1,36 @@
 package pagination
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"log"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// ErrQueryTimeout is returned when a count or data query runs longer than the builder's maximum duration
+var ErrQueryTimeout = errors.New("query exceeded maximum duration")
+
+// ErrInvalidSortField is returned, as a QueryParamsError, when a strict-sort builder is
+// asked to sort by a field it doesn't allow
+var ErrInvalidSortField = errors.New("invalid sort field")
+
+// ErrIncludesWithDisabledPagination is returned, as a QueryParamsError, when includes are
+// requested with pagination disabled on a builder rejecting them
+var ErrIncludesWithDisabledPagination = errors.New("includes are not allowed when pagination is disabled")
+
+// ErrInvalidCountExpression is returned when a builder's count expression is not a safe COUNT form
+var ErrInvalidCountExpression = errors.New("invalid count expression")
+
 type QueryBuilder interface {
 	ApplyFilters(query *gorm.DB) *gorm.DB
 	GetTableName() string
@@ -30,6 +54,181 @@ type DatabaseProvider interface {
 	GetDB() *gorm.DB
 }
 
+// QueryTimeoutProvider interface for query builders that bound how long each query may run
+type QueryTimeoutProvider interface {
+	GetMaxQueryDuration() time.Duration
+}
+
+// SortFieldValidatorProvider interface for query builders that validate sort fields themselves
+type SortFieldValidatorProvider interface {
+	GetSortFieldValidator() func(string) bool
+}
+
+// SmartSearchProvider interface for query builders that derive an extra ORed search predicate from the term
+type SmartSearchProvider interface {
+	GetSmartSearch() func(term string) (sql string, args []interface{}, ok bool)
+}
+
+// WeightedSearchProvider interface for query builders that rank search results by weighted field matches
+type WeightedSearchProvider interface {
+	GetSearchWeights() map[string]int
+}
+
+// RawSelect is a server-defined SQL expression selected under an alias
+type RawSelect struct {
+	Expression string
+	Alias      string
+}
+
+// RawSelectProvider interface for query builders that select computed columns
+type RawSelectProvider interface {
+	GetRawSelects() []RawSelect
+}
+
+// SortableFieldsProvider interface for query builders that restrict which fields can be sorted on
+type SortableFieldsProvider interface {
+	GetSortableFields() map[string]bool
+}
+
+// CountExpressionProvider interface for query builders that control what the count query counts
+type CountExpressionProvider interface {
+	GetCountExpression() string
+}
+
+// CastSearchFieldsProvider interface for query builders that search non-text columns as text
+type CastSearchFieldsProvider interface {
+	GetCastSearchFields() map[string]bool
+}
+
+// QuotedIdentifiersProvider interface for query builders that quote the sort and search columns they emit
+type QuotedIdentifiersProvider interface {
+	GetQuotedIdentifiers() bool
+}
+
+// ConcatSearchFieldsProvider interface for query builders that search columns joined with spaces
+type ConcatSearchFieldsProvider interface {
+	GetConcatSearchFields() [][]string
+}
+
+// CountTableProvider interface for query builders that read totals from a summary table
+type CountTableProvider interface {
+	GetCountTable() *CountTable
+}
+
+// ForcedSortProvider interface for query builders whose sort clients can't override
+type ForcedSortProvider interface {
+	GetForcedSort() string
+}
+
+// CountEstimator estimates the request's total, e.g. from the planner statistics of
+// large tables, reporting false to fall back to an exact count
+type CountEstimator func(db *gorm.DB, pagination PaginationRequest) (int64, bool, error)
+
+// CountEstimatorProvider interface for query builders that estimate totals instead of counting
+type CountEstimatorProvider interface {
+	GetCountEstimator() CountEstimator
+}
+
+// OrderByIDsProvider interface for query builders that order rows by their position in a list of IDs
+type OrderByIDsProvider interface {
+	GetOrderByIDs() (column string, ids []interface{})
+}
+
+// TokenizedSearchProvider interface for query builders that match each word of the
+// search term separately
+type TokenizedSearchProvider interface {
+	GetTokenizedSearch() bool
+}
+
+// StrictSortProvider interface for query builders that reject disallowed sorts instead of
+// falling back to the default sort
+type StrictSortProvider interface {
+	GetStrictSort() bool
+}
+
+// NaturalOrderProvider interface for query builders that leave rows in the database's
+// natural order when no sort is requested
+type NaturalOrderProvider interface {
+	GetNaturalOrder() bool
+}
+
+// EffectiveSearchProvider interface for query builders that echo the search term their query matched in the response
+type EffectiveSearchProvider interface {
+	GetEffectiveSearchEnabled() bool
+}
+
+// ContextPredicateProvider interface for query builders that restrict rows by a predicate
+// derived from the request's context, such as row-level security
+type ContextPredicateProvider interface {
+	GetContextPredicate() func(ctx context.Context) (string, []interface{})
+}
+
+// OffsetLimitProvider interface for query builders that echo the applied offset and limit in the response
+type OffsetLimitProvider interface {
+	GetOffsetLimitEnabled() bool
+}
+
+// CountSourceProvider interface for query builders that report how the total was obtained
+type CountSourceProvider interface {
+	GetCountSourceEnabled() bool
+}
+
+// NextCursorProvider interface for query builders that add a cursor for the next page to offset metadata
+type NextCursorProvider interface {
+	GetNextCursor() bool
+}
+
+// ClampPageProvider interface for query builders that serve the last page for pages past the end
+type ClampPageProvider interface {
+	GetClampPage() bool
+}
+
+// AlwaysFiltersProvider interface for query builders with conditions applied to every data
+// and count query, which client filters and search can't override
+type AlwaysFiltersProvider interface {
+	GetAlwaysFilters() []FilterCondition
+}
+
+// SearchOnlyFilterProvider interface for query builders with conditions that only apply when searching
+type SearchOnlyFilterProvider interface {
+	GetSearchOnlyFilter() func(query *gorm.DB, term string) *gorm.DB
+}
+
+// RequireSearchProvider interface for query builders that return nothing until a search term is given
+type RequireSearchProvider interface {
+	GetRequireSearch() bool
+}
+
+// WarningsProvider interface for query builders that report ignored includes and sorts as response warnings
+type WarningsProvider interface {
+	GetWarningsEnabled() bool
+}
+
+// IncludeThresholdProvider interface for query builders that only load includes on small pages
+type IncludeThresholdProvider interface {
+	GetIncludeThreshold() int
+}
+
+// DisabledIncludesPolicy decides what happens to includes requested with pagination disabled
+type DisabledIncludesPolicy int
+
+const (
+	// DisabledIncludesAllow loads includes on disabled pagination unless an include
+	// threshold is configured, the default
+	DisabledIncludesAllow DisabledIncludesPolicy = iota
+	// DisabledIncludesSkip never loads includes on disabled pagination, reporting them
+	// as skipped
+	DisabledIncludesSkip
+	// DisabledIncludesReject fails requests combining includes with disabled pagination
+	// with ErrIncludesWithDisabledPagination
+	DisabledIncludesReject
+)
+
+// DisabledIncludesPolicyProvider interface for query builders that guard includes on disabled pagination
+type DisabledIncludesPolicyProvider interface {
+	GetDisabledIncludesPolicy() DisabledIncludesPolicy
+}
+
 // QueryLayerBuilder interface that combines query building with database access
 type QueryLayerBuilder interface {
 	IncludableQueryBuilder
@@ -38,15 +237,24 @@ type QueryLayerBuilder interface {
 
 // applyAutoSearch applies search automatically based on provided search fields
 func applyAutoSearch(query *gorm.DB, searchTerm string, searchFields []string, dialect DatabaseDialect) *gorm.DB {
-	if len(searchFields) == 0 || searchTerm == "" {
+	whereClause, args := buildSearchCondition(searchTerm, searchFields, dialect)
+	if whereClause == "" {
 		return query
 	}
+	return query.Where(whereClause, args...)
+}
+
+// buildSearchCondition builds the LIKE condition matching the search term against any of the search fields
+func buildSearchCondition(searchTerm string, searchFields []string, dialect DatabaseDialect) (string, []interface{}) {
+	if len(searchFields) == 0 || searchTerm == "" {
+		return "", nil
+	}
 
 	searchPattern := "%" + searchTerm + "%"
 	operator := getSearchOperator(dialect)
 
 	if len(searchFields) == 1 {
-		return query.Where(searchFields[0]+" "+operator+" ?", searchPattern)
+		return searchFields[0] + " " + operator + " ?", []interface{}{searchPattern}
 	}
 
 	conditions := make([]string, len(searchFields))
@@ -57,10 +265,138 @@ func applyAutoSearch(query *gorm.DB, searchTerm string, searchFields []string, d
 		args[i] = searchPattern
 	}
 
-	whereClause := "(" + strings.Join(conditions, " OR ") + ")"
+	return "(" + strings.Join(conditions, " OR ") + ")", args
+}
+
+// searchColumns returns the builder's search fields, casting the fields it marks as
+// non-text to the dialect's text type so they can be matched with LIKE
+func searchColumns(builder QueryBuilder, dialect DatabaseDialect) []string {
+	columns := builder.GetSearchFields()
+	quote := identifierQuoter(builder, dialect)
+
+	castFields := map[string]bool{}
+	if provider, ok := builder.(CastSearchFieldsProvider); ok {
+		castFields = provider.GetCastSearchFields()
+	}
+	if len(castFields) > 0 || quotesIdentifiers(builder) {
+		columns = make([]string, len(builder.GetSearchFields()))
+		for i, field := range builder.GetSearchFields() {
+			columns[i] = quote(field)
+			if castFields[field] {
+				columns[i] = "CAST(" + quote(field) + " AS " + textType(dialect) + ")"
+			}
+		}
+	}
+
+	if provider, ok := builder.(ConcatSearchFieldsProvider); ok && len(provider.GetConcatSearchFields()) > 0 {
+		columns = slices.Clip(columns)
+		for _, fields := range provider.GetConcatSearchFields() {
+			quoted := make([]string, len(fields))
+			for i, field := range fields {
+				quoted[i] = quote(field)
+			}
+			columns = append(columns, concatExpression(quoted, dialect))
+		}
+	}
+
+	return columns
+}
+
+// concatExpression joins the columns with spaces in the dialect's syntax, treating
+// NULL columns as empty so one missing part doesn't hide the others
+func concatExpression(fields []string, dialect DatabaseDialect) string {
+	parts := make([]string, len(fields))
+	for i, field := range fields {
+		parts[i] = "COALESCE(" + field + ", '')"
+	}
+
+	switch dialect {
+	case MySQL, SQLServer:
+		return "CONCAT(" + strings.Join(parts, ", ' ', ") + ")"
+	default:
+		return "(" + strings.Join(parts, " || ' ' || ") + ")"
+	}
+}
+
+// textType returns the dialect's type for casting a column to text
+func textType(dialect DatabaseDialect) string {
+	switch dialect {
+	case MySQL:
+		return "CHAR"
+	case SQLServer:
+		return "NVARCHAR(MAX)"
+	default:
+		return "TEXT"
+	}
+}
+
+// applySearch applies the auto search, ORed with the builder's smart search predicate when it matches the term
+func applySearch(query *gorm.DB, builder QueryBuilder, searchTerm string, dialect DatabaseDialect) *gorm.DB {
+	whereClause, args := buildSearchCondition(searchTerm, searchColumns(builder, dialect), dialect)
+
+	// Tokenized search matches every word, each against any field
+	if tokens := strings.Fields(searchTerm); len(tokens) > 0 && usesTokenizedSearch(builder) {
+		conditions := make([]string, 0, len(tokens))
+		args = nil
+		for _, token := range tokens {
+			condition, tokenArgs := buildSearchCondition(token, searchColumns(builder, dialect), dialect)
+			if condition == "" {
+				break
+			}
+			conditions = append(conditions, condition)
+			args = append(args, tokenArgs...)
+		}
+		switch len(conditions) {
+		case 0:
+			whereClause = ""
+		case 1:
+			whereClause = conditions[0]
+		default:
+			whereClause = "(" + strings.Join(conditions, " AND ") + ")"
+		}
+	}
+
+	if provider, ok := builder.(SmartSearchProvider); ok && provider.GetSmartSearch() != nil {
+		if smartClause, smartArgs, ok := provider.GetSmartSearch()(searchTerm); ok && smartClause != "" {
+			if whereClause == "" {
+				whereClause = "(" + smartClause + ")"
+			} else {
+				whereClause = "(" + whereClause + " OR (" + smartClause + "))"
+			}
+			args = append(args, smartArgs...)
+		}
+	}
+
+	if whereClause == "" {
+		return query
+	}
 	return query.Where(whereClause, args...)
 }
 
+// EffectiveSearch returns the search term the builder's query matches for the request:
+// the words of a tokenized search joined by single spaces, the term as given otherwise,
+// or "" when the search is ignored because the builder has nothing to search
+func EffectiveSearch(builder QueryBuilder, pagination PaginationRequest) string {
+	searchTerm := pagination.Search
+	if usesTokenizedSearch(builder) {
+		searchTerm = strings.Join(strings.Fields(searchTerm), " ")
+	}
+
+	if len(searchColumns(builder, "")) > 0 {
+		return searchTerm
+	}
+	if provider, ok := builder.(SmartSearchProvider); ok && provider.GetSmartSearch() != nil {
+		return searchTerm
+	}
+	return ""
+}
+
+// usesTokenizedSearch reports whether the builder splits search terms into words
+func usesTokenizedSearch(builder interface{}) bool {
+	provider, ok := builder.(TokenizedSearchProvider)
+	return ok && provider.GetTokenizedSearch()
+}
+
 func getSearchOperator(dialect DatabaseDialect) string {
 	switch dialect {
 	case PostgreSQL:
@@ -100,6 +436,33 @@ func PaginatedQuery[T any](
 	})
 }
 
+// PaginatedQueryWithSortAllow paginates like PaginatedQuery, only honoring the request's
+// sort columns that are in allowed, so the sorts permitted can depend on the caller,
+// e.g. on their role, with a single builder. Other columns are dropped, falling back to
+// the builder's default sort when none remain, as an unknown column does, or fail with
+// ErrInvalidSortField under WithStrictSort. The builder's own sortable fields still apply.
+func PaginatedQueryWithSortAllow[T any](
+	db *gorm.DB,
+	builder QueryBuilder,
+	pagination PaginationRequest,
+	allowed []string,
+) ([]T, int64, error) {
+	if pagination.Sort != "" {
+		var kept []SortField
+		for _, field := range pagination.SortFields() {
+			if slices.Contains(allowed, field.Field) {
+				kept = append(kept, field)
+			} else if usesStrictSort(builder) && !sortIsFixed(builder) {
+				return nil, 0, invalidSortError(field.Field)
+			}
+		}
+		if len(kept) < len(pagination.SortFields()) {
+			pagination = pagination.withSortFields(kept)
+		}
+	}
+	return PaginatedQuery[T](db, builder, pagination, []string{})
+}
+
 // PaginatedQueryWithIncludable handles queries with includable query builders
 func PaginatedQueryWithIncludable[T any](
 	db *gorm.DB,
@@ -120,10 +483,27 @@ func PaginatedQueryWithIncludable[T any](
 	// Get pagination and includes from the builder
 	pagination := builder.GetPagination()
 	includes := builder.GetIncludes()
-
-	return PaginatedQueryWithOptions[T](db, builder, pagination, includes, PaginatedQueryOptions{
+	options := PaginatedQueryOptions{
 		Dialect: MySQL, // Default to MySQL for backward compatibility
+	}
+
+	if !isQueryCountDebug() {
+		return PaginatedQueryWithOptions[T](db, builder, pagination, includes, options)
+	}
+
+	var result []T
+	var total int64
+	count, err := CountQueries(db, func(db *gorm.DB) error {
+		var err error
+		result, total, err = PaginatedQueryWithOptions[T](db, builder, pagination, includes, options)
+		return err
 	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	warnOnExcessQueries(builder.GetTableName(), validateIncludes(builder, includes), count)
+	return result, total, nil
 }
 
 // PaginatedQueryWithIncludableAndOptions handles queries with includable query builders and custom options
@@ -142,6 +522,10 @@ func PaginatedQueryWithIncludableAndOptions[T any](
 	return PaginatedQueryWithOptions[T](db, builder, pagination, includes, options)
 }
 
+// PaginatedQueryWithOptions runs the count and data queries on the given db handle.
+// When db is a transaction (db.Begin() or inside db.Transaction), both queries run
+// sequentially on that transaction so the total and the page come from the same
+// snapshot; no separate session or connection is ever opened.
 func PaginatedQueryWithOptions[T any](
 	db *gorm.DB,
 	builder QueryBuilder,
@@ -149,147 +533,1314 @@ func PaginatedQueryWithOptions[T any](
 	includes []string,
 	options PaginatedQueryOptions,
 ) ([]T, int64, error) {
-	var result []T
-	var totalCount int64
+	result, err := paginatedQuery[T](db, builder, pagination, includes, options)
+	return result.Data, result.Total, err
+}
 
-	// Build count query
-	countQuery := db.Table(builder.GetTableName())
-	countQuery = builder.ApplyFilters(countQuery)
+// queryResult is a fetched page with the total and how it was obtained
+type queryResult[T any] struct {
+	Data []T
+	// Page is the page served: the requested one, or the last page when clamped
+	Page        int
+	Total       int64
+	CountSource CountSource
+	// Warnings describe how the request was altered, nil unless the builder enables them
+	Warnings []string
+}
 
-	// Apply soft delete handling if enabled
-	if options.EnableSoftDelete {
-		countQuery = countQuery.Where("deleted_at IS NULL")
-	}
+// paginatedQuery runs the count and data queries, reporting them to the metrics hook.
+// A page past the end is clamped to the last page when the builder enables it.
+func paginatedQuery[T any](
+	db *gorm.DB,
+	builder QueryBuilder,
+	pagination PaginationRequest,
+	includes []string,
+	options PaginatedQueryOptions,
+) (queryResult[T], error) {
+	start := time.Now()
+	result, err := runPaginatedQuery[T](db, builder, pagination, includes, options)
+	reportQueryMetrics(db, builder, start, result.Total, len(result.Data), result.CountSource, err)
+	return result, err
+}
 
-	// Execute count query
-	if options.CustomCountQuery != "" {
-		if err := countQuery.Raw(options.CustomCountQuery).Count(&totalCount).Error; err != nil {
-			return nil, 0, fmt.Errorf("failed to count records: %w", err)
-		}
-	} else {
-		if err := countQuery.Count(&totalCount).Error; err != nil {
-			return nil, 0, fmt.Errorf("failed to count records: %w", err)
-		}
+// runPaginatedQuery runs the count and data queries of paginatedQuery
+func runPaginatedQuery[T any](
+	db *gorm.DB,
+	builder QueryBuilder,
+	pagination PaginationRequest,
+	includes []string,
+	options PaginatedQueryOptions,
+) (queryResult[T], error) {
+	if err := strictSortError(builder, pagination); err != nil {
+		return queryResult[T]{}, err
+	}
+	if err := rejectedIncludesError[T](db, builder, pagination, includes); err != nil {
+		return queryResult[T]{}, err
 	}
 
-	// Build data query
-	dataQuery := db.Table(builder.GetTableName())
-	dataQuery = builder.ApplyFilters(dataQuery)
+	// Filters that provably match nothing, or a required search that's missing, never reach the database
+	if hasImpossibleConditions(builder) || missingRequiredSearch(builder, pagination) {
+		return queryResult[T]{Data: []T{}, Page: pagination.Normalized().Page, CountSource: CountSourceExact, Warnings: requestWarnings(builder, pagination, includes)}, nil
+	}
 
-	if pagination.Search != "" {
-		dataQuery = applyAutoSearch(dataQuery, pagination.Search, builder.GetSearchFields(), options.Dialect)
+	totalCount, source, err := countRecordsWithSource(db, builder, pagination, options, allScopes)
+	if err != nil {
+		return queryResult[T]{}, err
 	}
 
-	// Apply soft delete handling if enabled
-	if options.EnableSoftDelete {
-		dataQuery = dataQuery.Where("deleted_at IS NULL")
+	pagination.Page = EffectivePage(builder, pagination, totalCount)
+
+	page := queryResult[T]{Data: []T{}, Page: pagination.Page, Total: totalCount, CountSource: source, Warnings: requestWarnings(builder, pagination, includes)}
+
+	// Metadata-only requests skip the data query entirely
+	if pagination.MetaOnly {
+		return page, nil
 	}
 
-	// Apply sorting
-	if pagination.Sort != "" {
-		// Validate sort field to prevent SQL injection
-		if isValidSortField(pagination.Sort) {
-			orderClause := pagination.Sort + " " + pagination.Order
-			dataQuery = dataQuery.Order(orderClause)
-		} else {
-			dataQuery = dataQuery.Order(builder.GetDefaultSort())
+	result, err := fetchRecords[T](db, builder, pagination, includes, options)
+	if field, unknown := unknownSortColumn(err, pagination.SortFields()); unknown && !hasSortableFields(builder) && usesStrictSort(builder) {
+		return queryResult[T]{}, invalidSortError(field.Field)
+	}
+	if _, unknown := unknownSortColumn(err, pagination.SortFields()); unknown && !hasSortableFields(builder) {
+		// Without an allowlist an unknown sort column only surfaces as a database
+		// error, so retry once with the default sort instead of failing the request.
+		// Any other error, such as a lost connection, fails the request as is.
+		logSortFallback(builder.GetTableName(), pagination.Sort, err)
+		if page.Warnings != nil {
+			page.Warnings = append(page.Warnings, fmt.Sprintf("sort '%s' failed, using default", pagination.Sort))
 		}
-	} else {
-		dataQuery = dataQuery.Order(builder.GetDefaultSort())
+		pagination = pagination.withSortFields(nil)
+		result, err = fetchRecords[T](db, builder, pagination, includes, options)
+	}
+	if err != nil {
+		return queryResult[T]{}, err
 	}
 
-	// Apply pagination unless disabled
-	if !pagination.IsDisabled {
-		dataQuery = dataQuery.Offset(pagination.GetOffset()).Limit(pagination.GetLimit())
+	page.Data = result
+	return page, nil
+}
+
+// missingRequiredSearch reports whether the builder requires a search term and the
+// request has none
+func missingRequiredSearch(builder QueryBuilder, pagination PaginationRequest) bool {
+	provider, ok := builder.(RequireSearchProvider)
+	return ok && provider.GetRequireSearch() && strings.TrimSpace(pagination.Search) == ""
+}
+
+// EffectivePage returns the page the builder's query serves for the request out of
+// totalCount records: the last page when the builder clamps pages and the requested
+// page is past it, the requested page otherwise. Callers of PaginatedQuery building
+// their own metadata pass it to CalculatePagination, as PaginateCore does.
+func EffectivePage(builder QueryBuilder, pagination PaginationRequest, totalCount int64) int {
+	pagination = pagination.Normalized()
+	if provider, ok := builder.(ClampPageProvider); !ok || !provider.GetClampPage() || pagination.IsDisabled {
+		return pagination.Page
 	}
 
-	// Validate and apply preloads
-	validatedIncludes := validateIncludes(builder, includes)
-	for _, include := range validatedIncludes {
-		dataQuery = dataQuery.Preload(include)
+	maxPage := CalculatePagination(pagination, totalCount).MaxPage
+	if int64(pagination.Page) <= maxPage {
+		return pagination.Page
 	}
+	return int(maxPage)
+}
 
-	// Execute data query
-	if err := dataQuery.Find(&result).Error; err != nil {
-		return nil, 0, fmt.Errorf("failed to fetch records: %w", err)
+// requestWarnings returns human-readable notes on the includes and sort of the request
+// that won't be honored, or nil when the builder doesn't enable warnings
+func requestWarnings(builder QueryBuilder, pagination PaginationRequest, includes []string) []string {
+	if provider, ok := builder.(WarningsProvider); !ok || !provider.GetWarningsEnabled() {
+		return nil
 	}
 
-	return result, totalCount, nil
-}
+	warnings := []string{}
 
-// isValidSortField validates sort field to prevent SQL injection
-func isValidSortField(field string) bool {
-	// Allow only alphanumeric characters, underscores, and dots
-	for _, char := range field {
-		if !((char >= 'a' && char <= 'z') ||
-			(char >= 'A' && char <= 'Z') ||
-			(char >= '0' && char <= '9') ||
-			char == '_' || char == '.') {
-			return false
+	validIncludes := map[string]bool{}
+	for _, include := range validateIncludes(builder, includes) {
+		validIncludes[include] = true
+	}
+	for _, include := range includes {
+		if include != "" && !validIncludes[include] {
+			warnings = append(warnings, fmt.Sprintf("include '%s' ignored", include))
 		}
 	}
-	return len(field) > 0
-}
+	for _, include := range SkippedIncludes(builder, pagination, includes) {
+		warnings = append(warnings, fmt.Sprintf("include '%s' skipped, page exceeds include threshold", include))
+	}
 
-// isValidInclude validates include field to prevent SQL injection
-func isValidInclude(include string) bool {
-	// Allow only alphanumeric characters, underscores, and dots
-	for _, char := range include {
-		if !((char >= 'a' && char <= 'z') ||
-			(char >= 'A' && char <= 'Z') ||
-			(char >= '0' && char <= '9') ||
-			char == '_' || char == '.') {
-			return false
+	if sortIsFixed(builder) && pagination.Sort != "" {
+		warnings = append(warnings, fmt.Sprintf("sort '%s' ignored, sort is fixed", pagination.Sort))
+	} else if valid, invalid := partitionSorts(builder, pagination); len(valid) == 0 && pagination.Sort != "" {
+		warnings = append(warnings, fmt.Sprintf("sort '%s' invalid, using default", pagination.Sort))
+	} else {
+		for _, field := range invalid {
+			warnings = append(warnings, fmt.Sprintf("sort '%s' invalid, ignored", field.Field))
 		}
 	}
-	return len(include) > 0
+
+	return warnings
 }
 
-// validateIncludes validates includes against allowed includes for the builder
-func validateIncludes(builder interface{}, includes []string) []string {
-	if includeValidator, ok := builder.(AllowedIncludesProvider); ok {
-		allowedIncludes := includeValidator.GetAllowedIncludes()
-		var validIncludes []string
-		for _, include := range includes {
-			if isValidInclude(include) && allowedIncludes[include] {
-				validIncludes = append(validIncludes, include)
+// fetchRecords builds and executes the data query
+func fetchRecords[T any](
+	db *gorm.DB,
+	builder QueryBuilder,
+	pagination PaginationRequest,
+	includes []string,
+	options PaginatedQueryOptions,
+) ([]T, error) {
+	var result []T
+
+	// Build data query
+	dataDB, cancelData := withQueryTimeout(db, builder)
+	defer cancelData()
+	dataQuery, err := buildDataQuery[T](dataDB, builder, pagination, options)
+	if err != nil {
+		return nil, err
+	}
+
+	// Validate and apply preloads, unless the page is too large for them. Relations
+	// named in the field selection are loaded with only their selected columns.
+	if !exceedsIncludeThreshold(builder, pagination) {
+		validatedIncludes := validateIncludes(builder, includes)
+		includeFilters := includeFilterConditions(builder)
+		_, selectedPreloads := fieldSelectionPlan[T](dataQuery, builder)
+		for _, preload := range selectedPreloads {
+			dataQuery = dataQuery.Preload(preload.Path, selectedPreloadConditions(preload.Columns, includeFilters[preload.Path]))
+		}
+		for _, include := range validatedIncludes {
+			if slices.ContainsFunc(selectedPreloads, func(preload selectedPreload) bool { return preload.Path == include }) {
+				continue
+			}
+			if filters := includeFilters[include]; len(filters) > 0 {
+				dataQuery = dataQuery.Preload(include, preloadConditions(filters))
+			} else {
+				dataQuery = dataQuery.Preload(include)
 			}
 		}
-		return validIncludes
 	}
 
-	// Fallback: just validate syntax if no allowed includes defined
-	var validIncludes []string
-	for _, include := range includes {
-		if isValidInclude(include) {
-			validIncludes = append(validIncludes, include)
-		}
+	// Execute data query
+	if err := dataQuery.Find(&result).Error; err != nil {
+		return nil, queryError(dataDB, "failed to fetch records", err)
 	}
-	return validIncludes
-}
 
-type SimpleQueryBuilder struct {
-	TableName    string
-	FilterFunc   func(*gorm.DB) *gorm.DB
-	SearchFields []string
-	DefaultSort  string
-	Dialect      DatabaseDialect
+	return emptyIfNil(result), nil
 }
 
-func (s *SimpleQueryBuilder) ApplyFilters(query *gorm.DB) *gorm.DB {
-	if s.FilterFunc != nil {
-		return s.FilterFunc(query)
-	}
-	return query
-}
+// buildDataQuery builds the filtered, sorted and paginated data query, without preloads
+func buildDataQuery[T any](
+	db *gorm.DB,
+	builder QueryBuilder,
+	pagination PaginationRequest,
+	options PaginatedQueryOptions,
+) (*gorm.DB, error) {
+	dataQuery := applyBaseQuery(db.Table(builder.GetTableName()), builder, pagination, options, allScopes)
 
-func (s *SimpleQueryBuilder) GetSearchFields() []string {
-	return s.SearchFields
-}
+	// Select only the client's fields, when the builder allows choosing them
+	if columns, _ := fieldSelectionPlan[T](dataQuery, builder); len(columns) > 0 {
+		dataQuery = dataQuery.Select(columns)
+	}
 
-func (s *SimpleQueryBuilder) GetTableName() string {
-	return s.TableName
-}
+	// Select computed columns and relation counts
+	dataQuery, err := applyComputedColumns[T](dataQuery, builder)
+	if err != nil {
+		return nil, err
+	}
+
+	// Apply sorting, by a registered relation count or the requested column; a forced
+	// sort ignores the client's sort and order entirely
+	if sortIsFixed(builder) {
+		pagination = pagination.withSortFields(nil)
+	}
+	countSort, ok, direction := "", false, ""
+	if sorts := pagination.SortFields(); len(sorts) == 1 {
+		countSort, ok, err = countSortExpression[T](dataQuery, builder, sorts[0].Field)
+		if err != nil {
+			return nil, err
+		}
+		direction = sorts[0].Direction
+	}
+	if ok {
+		dataQuery = dataQuery.Order(countSort + " " + direction)
+	} else {
+		var primaryKeys []string
+		if modelSchema, err := parseModelSchema[T](dataQuery); err == nil {
+			primaryKeys = primaryKeyColumns(modelSchema)
+		}
+		dataQuery = applySorting(dataQuery, builder, pagination, options, primaryKeys)
+	}
+
+	// Apply pagination unless disabled. The first page has no OFFSET clause at all,
+	// which some planners handle better than OFFSET 0.
+	if !pagination.IsDisabled {
+		if offset := pagination.GetOffset(); offset > 0 {
+			dataQuery = dataQuery.Offset(offset)
+		}
+		dataQuery = dataQuery.Limit(pagination.GetLimit())
+	}
+
+	return dataQuery, nil
+}
+
+// sortFallbacksLogged records the tables already logged by logSortFallback. Tables are
+// server-defined, unlike sorts, so it stays bounded.
+var sortFallbacksLogged sync.Map
+
+// unknownColumnError matches the errors each dialect reports for a missing column:
+// SQLite's "no such column", MySQL's "Unknown column", PostgreSQL's "column ... does
+// not exist" and SQL Server's "Invalid column name"
+var unknownColumnError = regexp.MustCompile(`(?i)no such column|unknown column|column .* does not exist|invalid column name`)
+
+// unknownColumnPhrases are the fixed words of unknownColumnError's messages
+var unknownColumnPhrases = regexp.MustCompile(`(?i)no such column|unknown column|does not exist|invalid column name`)
+
+// unknownSortColumn returns the sort column a database error reports as missing, if
+// any, so only that error and not, say, a lost connection or a failing filter is
+// treated as a bad sort
+func unknownSortColumn(err error, sort []SortField) (SortField, bool) {
+	if err == nil || errors.Is(err, ErrQueryTimeout) {
+		return SortField{}, false
+	}
+	message := err.Error()
+	if !unknownColumnError.MatchString(message) {
+		return SortField{}, false
+	}
+	// Leave out the phrase itself, so a column such as "name" isn't matched in it, and
+	// match the sort columns against the whole words left
+	words := map[string]bool{}
+	for _, word := range strings.FieldsFunc(unknownColumnPhrases.ReplaceAllString(message, " "), isNotWordRune) {
+		words[strings.ToLower(word)] = true
+	}
+
+	for _, field := range sort {
+		segments, _ := splitIdentifier(field.Field)
+		column, _ := unquoteIdentifier(segments[len(segments)-1])
+		if words[strings.ToLower(column)] {
+			return field, true
+		}
+	}
+	return SortField{}, false
+}
+
+// isNotWordRune reports whether r separates the words of an error message
+func isNotWordRune(r rune) bool {
+	return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_')
+}
+
+// usesStrictSort reports whether the builder rejects disallowed sorts
+func usesStrictSort(builder interface{}) bool {
+	provider, ok := builder.(StrictSortProvider)
+	return ok && provider.GetStrictSort()
+}
+
+// invalidSortError reports a rejected sort as invalid query parameters
+func invalidSortError(sort string) error {
+	return &QueryParamsError{Err: fmt.Errorf("%w: %q", ErrInvalidSortField, sort)}
+}
+
+// strictSortError returns the error for the first requested sort column a strict-sort
+// builder doesn't allow. Sorts ignored for a fixed ordering are never rejected.
+func strictSortError(builder interface{}, pagination PaginationRequest) error {
+	if !usesStrictSort(builder) || pagination.Sort == "" || sortIsFixed(builder) {
+		return nil
+	}
+	if _, invalid := partitionSorts(builder, pagination); len(invalid) > 0 {
+		return invalidSortError(invalid[0].Field)
+	}
+	return nil
+}
+
+// partitionSorts splits the requested sort columns into those the builder's validator
+// and sortable fields allow and the others, in order. Relation count sorts are only
+// valid alone.
+func partitionSorts(builder interface{}, pagination PaginationRequest) ([]SortField, []SortField) {
+	requested := pagination.SortFields()
+
+	var valid, invalid []SortField
+	for _, field := range requested {
+		if validateSortField(builder, field.Field) && isSortableField(builder, field.Field) &&
+			(len(requested) == 1 || !isCountSort(builder, field.Field)) {
+			valid = append(valid, field)
+		} else {
+			invalid = append(invalid, field)
+		}
+	}
+	return valid, invalid
+}
+
+// logSortFallback logs, once per table, that a sort failed and the default sort was used
+func logSortFallback(tableName string, sort string, err error) {
+	if _, logged := sortFallbacksLogged.LoadOrStore(tableName, true); !logged {
+		log.Printf("pagination: sort %q on %s failed, retrying with the default sort: %v", sort, tableName, err)
+	}
+}
+
+// applyComputedColumns adds the builder's raw select expressions and relation counts to the data query
+func applyComputedColumns[T any](query *gorm.DB, builder QueryBuilder) (*gorm.DB, error) {
+	var computed []string
+
+	if provider, ok := builder.(RawSelectProvider); ok {
+		for _, rawSelect := range provider.GetRawSelects() {
+			if !isValidIdentifier(rawSelect.Alias) {
+				return nil, fmt.Errorf("invalid select alias %q", rawSelect.Alias)
+			}
+			computed = append(computed, "("+rawSelect.Expression+") AS "+rawSelect.Alias)
+		}
+	}
+
+	relationCounts, err := relationCountColumns[T](query, builder)
+	if err != nil {
+		return nil, err
+	}
+	computed = append(computed, relationCounts...)
+
+	if len(computed) == 0 {
+		return query, nil
+	}
+
+	columns := query.Statement.Selects
+	if len(columns) == 0 {
+		columns = []string{builder.GetTableName() + ".*"}
+	}
+	return query.Select(append(columns, computed...)), nil
+}
+
+// applySorting orders the data query by the requested sort columns, the search
+// relevance or the builder's default sort. Rows ranked equally by relevance are ordered by the primary
+// key after the default sort, when the model has one.
+func applySorting(query *gorm.DB, builder QueryBuilder, pagination PaginationRequest, options PaginatedQueryOptions, primaryKeys []string) *gorm.DB {
+	quoteClause := func(clause string) string { return clause }
+	if quotesIdentifiers(builder) {
+		quoteClause = func(clause string) string { return quoteSortClause(clause, options.Dialect) }
+	}
+	defaultSort := quoteClause(builder.GetDefaultSort())
+
+	if clause, forced := forcedSort(builder); forced {
+		return query.Order(quoteClause(clause))
+	}
+
+	if column, ids, ok := orderByIDs(builder); ok {
+		return query.Order(clause.OrderBy{Expression: clause.Expr{
+			SQL:                idPositionExpression(identifierQuoter(builder, options.Dialect)(column), len(ids)) + ", " + defaultSort,
+			Vars:               ids,
+			WithoutParentheses: true,
+		}})
+	}
+
+	// Validate sort fields to prevent SQL injection, dropping the invalid ones
+	if valid, _ := partitionSorts(builder, pagination); len(valid) > 0 {
+		quote := identifierQuoter(builder, options.Dialect)
+		orderClauses := make([]string, len(valid))
+		for i, field := range valid {
+			orderClauses[i] = quote(field.Field) + " " + field.Direction
+		}
+		return query.Order(strings.Join(orderClauses, ", "))
+	}
+
+	// Natural order emits no ORDER BY at all unless a valid sort is requested
+	if usesNaturalOrder(builder) {
+		return query
+	}
+
+	if pagination.Sort != "" {
+		return query.Order(defaultSort)
+	}
+
+	// Rank by weighted search relevance when searching without an explicit sort
+	if provider, ok := builder.(WeightedSearchProvider); ok && pagination.Search != "" {
+		if relevance, args := buildRelevanceExpression(pagination.Search, provider.GetSearchWeights(), options.Dialect); relevance != "" {
+			order := relevance + " DESC, " + defaultSort
+			if tiebreaker := relevanceTiebreaker(builder, primaryKeys, options.Dialect); tiebreaker != "" {
+				order += ", " + tiebreaker
+			}
+
+			// A single expression, as GORM drops an ORDER BY expression merged with plain columns
+			return query.Order(clause.OrderBy{Expression: clause.Expr{
+				SQL:                order,
+				Vars:               args,
+				WithoutParentheses: true,
+			}})
+		}
+	}
+
+	// An order sent without a sort column sets the direction of the default sort
+	if order, ok := pagination.explicitOrder(); ok {
+		if directed, ok := sortClauseWithDirection(builder.GetDefaultSort(), order); ok {
+			return query.Order(quoteClause(directed))
+		}
+	}
+
+	return query.Order(defaultSort)
+}
+
+// orderByIDs returns the column and IDs the builder orders rows by, if any
+func orderByIDs(builder interface{}) (string, []interface{}, bool) {
+	provider, ok := builder.(OrderByIDsProvider)
+	if !ok {
+		return "", nil, false
+	}
+	column, ids := provider.GetOrderByIDs()
+	return column, ids, len(ids) > 0
+}
+
+// sortIsFixed reports whether the builder's ordering ignores the client's sort and order
+func sortIsFixed(builder interface{}) bool {
+	_, forced := forcedSort(builder)
+	_, _, byIDs := orderByIDs(builder)
+	return forced || byIDs
+}
+
+// idPositionExpression returns the CASE expression evaluating to the position of the
+// column's value among n bound IDs, placing other values last, e.g.
+// CASE id WHEN ? THEN 0 WHEN ? THEN 1 ELSE 2 END. Unlike PostgreSQL's array_position
+// it works on every dialect.
+func idPositionExpression(column string, n int) string {
+	var expression strings.Builder
+	expression.WriteString("CASE " + column)
+	for i := 0; i < n; i++ {
+		expression.WriteString(" WHEN ? THEN " + strconv.Itoa(i))
+	}
+	expression.WriteString(" ELSE " + strconv.Itoa(n) + " END")
+	return expression.String()
+}
+
+// usesNaturalOrder reports whether the builder leaves unsorted rows in natural order
+func usesNaturalOrder(builder interface{}) bool {
+	provider, ok := builder.(NaturalOrderProvider)
+	return ok && provider.GetNaturalOrder()
+}
+
+// relevanceTiebreaker returns the primary key ordering that keeps rows sharing a
+// relevance score in a stable order, with each column of a composite key the default
+// sort doesn't already include
+func relevanceTiebreaker(builder QueryBuilder, primaryKeys []string, dialect DatabaseDialect) string {
+	sorted := sortColumns(ParseSortClause(builder.GetDefaultSort()))
+	quote := identifierQuoter(builder, dialect)
+
+	var parts []string
+	for _, primaryKey := range primaryKeys {
+		if sorted[primaryKey] {
+			continue
+		}
+		column := primaryKey
+		if isValidIdentifier(builder.GetTableName()) {
+			column = builder.GetTableName() + "." + primaryKey
+		}
+		parts = append(parts, quote(column)+" asc")
+	}
+	return strings.Join(parts, ", ")
+}
+
+// sortColumns returns the unqualified, unquoted columns of a sort
+func sortColumns(sort []SortField) map[string]bool {
+	columns := make(map[string]bool, len(sort))
+	for _, field := range sort {
+		segments, _ := splitIdentifier(field.Field)
+		column, _ := unquoteIdentifier(segments[len(segments)-1])
+		columns[column] = true
+	}
+	return columns
+}
+
+// quotesIdentifiers reports whether the builder quotes the sort and search columns it emits
+func quotesIdentifiers(builder interface{}) bool {
+	provider, ok := builder.(QuotedIdentifiersProvider)
+	return ok && provider.GetQuotedIdentifiers()
+}
+
+// identifierQuoter returns quoteIdentifier for the dialect when the builder quotes
+// identifiers, or a function returning columns as they are
+func identifierQuoter(builder interface{}, dialect DatabaseDialect) func(string) string {
+	if !quotesIdentifiers(builder) {
+		return func(identifier string) string { return identifier }
+	}
+	return func(identifier string) string { return quoteIdentifier(identifier, dialect) }
+}
+
+// quoteIdentifier quotes each segment of a column reference in the dialect's style,
+// e.g. `order` for MySQL, "users"."group" for PostgreSQL and SQLite or [order] for
+// SQL Server. Expressions and other invalid references are returned as they are.
+func quoteIdentifier(identifier string, dialect DatabaseDialect) string {
+	if !isValidIdentifier(identifier) {
+		return identifier
+	}
+
+	open, close := `"`, `"`
+	switch dialect {
+	case MySQL:
+		open, close = "`", "`"
+	case SQLServer:
+		open, close = "[", "]"
+	}
+
+	segments, _ := splitIdentifier(identifier)
+	for i, segment := range segments {
+		name, _ := unquoteIdentifier(segment)
+		segments[i] = open + name + close
+	}
+	return strings.Join(segments, ".")
+}
+
+// quoteSortClause quotes the columns of a sort clause listing columns with optional
+// directions, returning other clauses as they are
+func quoteSortClause(clause string, dialect DatabaseDialect) string {
+	if validateSortClause(clause) != nil {
+		return clause
+	}
+
+	parts := strings.Split(clause, ",")
+	for i, part := range parts {
+		tokens := strings.Fields(part)
+		tokens[0] = quoteIdentifier(tokens[0], dialect)
+		parts[i] = strings.Join(tokens, " ")
+	}
+	return strings.Join(parts, ", ")
+}
+
+// forcedSort returns the builder's forced sort clause, if any
+func forcedSort(builder interface{}) (string, bool) {
+	provider, ok := builder.(ForcedSortProvider)
+	if !ok || provider.GetForcedSort() == "" {
+		return "", false
+	}
+	return provider.GetForcedSort(), true
+}
+
+// validateSortClause checks that a sort clause only lists columns, each optionally
+// followed by asc or desc
+func validateSortClause(clause string) error {
+	parts := strings.Split(clause, ",")
+	for _, part := range parts {
+		tokens := strings.Fields(part)
+		if len(tokens) == 0 || len(tokens) > 2 || !isValidIdentifier(tokens[0]) {
+			return fmt.Errorf("invalid sort clause %q", clause)
+		}
+		if len(tokens) == 2 && !strings.EqualFold(tokens[1], "asc") && !strings.EqualFold(tokens[1], "desc") {
+			return fmt.Errorf("invalid sort direction %q in sort clause %q", tokens[1], clause)
+		}
+	}
+	return nil
+}
+
+// sortClauseWithDirection sets the direction of every column of a simple sort clause
+// like "age desc, id" to direction
+func sortClauseWithDirection(sortClause, direction string) (string, bool) {
+	fields := ParseSortClause(sortClause)
+	if len(fields) == 0 {
+		return "", false
+	}
+
+	parts := make([]string, len(fields))
+	for i, field := range fields {
+		if !isValidIdentifier(field.Field) {
+			return "", false
+		}
+
+		parts[i] = field.Field + " " + direction
+	}
+
+	return strings.Join(parts, ", "), true
+}
+
+// buildRelevanceExpression builds a score summing the weight of every field matching the search term
+func buildRelevanceExpression(searchTerm string, weights map[string]int, dialect DatabaseDialect) (string, []interface{}) {
+	fields := weightedFields(weights)
+	if len(fields) == 0 {
+		return "", nil
+	}
+
+	searchPattern := "%" + searchTerm + "%"
+	operator := getSearchOperator(dialect)
+
+	cases := make([]string, len(fields))
+	args := make([]interface{}, len(fields))
+	for i, field := range fields {
+		cases[i] = "CASE WHEN " + field + " " + operator + " ? THEN " + strconv.Itoa(weights[field]) + " ELSE 0 END"
+		args[i] = searchPattern
+	}
+
+	return "(" + strings.Join(cases, " + ") + ")", args
+}
+
+// weightedFields returns the weighted fields ordered by descending weight, then name
+func weightedFields(weights map[string]int) []string {
+	fields := make([]string, 0, len(weights))
+	for field := range weights {
+		fields = append(fields, field)
+	}
+
+	sort.Slice(fields, func(i, j int) bool {
+		if weights[fields[i]] != weights[fields[j]] {
+			return weights[fields[i]] > weights[fields[j]]
+		}
+		return fields[i] < fields[j]
+	})
+	return fields
+}
+
+// CountOptions controls which parts of the query contribute to a count, so an
+// endpoint can report an "X of Y" total that ignores the search, the builder's
+// filters or the default scopes (soft delete handling)
+type CountOptions struct {
+	IncludeSearch        bool
+	IncludeFilters       bool
+	IncludeDefaultScopes bool
+}
+
+// allScopes includes every part of the query, as used by the count and data queries
+var allScopes = CountOptions{
+	IncludeSearch:        true,
+	IncludeFilters:       true,
+	IncludeDefaultScopes: true,
+}
+
+// CountOnly returns the number of records matching the builder's filters and the
+// request's search term, without fetching any rows
+func CountOnly(db *gorm.DB, builder QueryBuilder, pagination PaginationRequest) (int64, error) {
+	return countRecords(db, builder, pagination, PaginatedQueryOptions{
+		Dialect: MySQL, // Default to MySQL for backward compatibility
+	}, allScopes)
+}
+
+// CountWithOptions returns the number of records matching only the parts of the
+// query selected by countOptions
+func CountWithOptions(
+	db *gorm.DB,
+	builder QueryBuilder,
+	pagination PaginationRequest,
+	options PaginatedQueryOptions,
+	countOptions CountOptions,
+) (int64, error) {
+	return countRecords(db, builder, pagination, options, countOptions)
+}
+
+// applyBaseQuery applies the filters, search and soft delete handling selected by scope
+func applyBaseQuery(
+	query *gorm.DB,
+	builder QueryBuilder,
+	pagination PaginationRequest,
+	options PaginatedQueryOptions,
+	scope CountOptions,
+) *gorm.DB {
+	if scope.IncludeFilters {
+		query = builder.ApplyFilters(query)
+	}
+
+	if scope.IncludeSearch && pagination.Search != "" {
+		query = applySearch(query, builder, pagination.Search, options.Dialect)
+
+		if provider, ok := builder.(SearchOnlyFilterProvider); ok && provider.GetSearchOnlyFilter() != nil {
+			query = provider.GetSearchOnlyFilter()(query, pagination.Search)
+		}
+	}
+
+	// Apply soft delete handling if enabled
+	if scope.IncludeDefaultScopes && options.EnableSoftDelete {
+		query = query.Where("deleted_at IS NULL")
+	}
+
+	query = applyAlwaysFilters(query, builder)
+	return applyContextPredicate(query, builder)
+}
+
+// groupWhereConditions groups the query's conditions so an OR among them can't escape
+// the conditions added after them
+func groupWhereConditions(query *gorm.DB) {
+	if where, ok := query.Statement.Clauses["WHERE"]; ok {
+		if expression, ok := where.Expression.(clause.Where); ok && len(expression.Exprs) > 1 {
+			expression.Exprs = []clause.Expression{clause.And(expression.Exprs...)}
+			where.Expression = expression
+			query.Statement.Clauses["WHERE"] = where
+		}
+	}
+}
+
+// applyContextPredicate adds the predicate the builder derives from the query's context,
+// grouping the conditions before it so an OR among client filters can't escape it
+func applyContextPredicate(query *gorm.DB, builder QueryBuilder) *gorm.DB {
+	predicate := contextPredicate(builder)
+	if predicate == nil {
+		return query
+	}
+
+	ctx := query.Statement.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	// Fail closed: an empty predicate, e.g. from a bug in the caller's, matches no rows
+	sql, args := predicate(ctx)
+	if strings.TrimSpace(sql) == "" {
+		sql, args = "1 = 0", nil
+	}
+
+	groupWhereConditions(query)
+	return query.Where("("+sql+")", args...)
+}
+
+// contextPredicate returns the builder's context predicate, if any
+func contextPredicate(builder interface{}) func(ctx context.Context) (string, []interface{}) {
+	if provider, ok := builder.(ContextPredicateProvider); ok {
+		return provider.GetContextPredicate()
+	}
+	return nil
+}
+
+// applyAlwaysFilters adds the builder's always-applied conditions, grouping the
+// conditions before them so an OR among client filters can't escape them
+func applyAlwaysFilters(query *gorm.DB, builder QueryBuilder) *gorm.DB {
+	provider, ok := builder.(AlwaysFiltersProvider)
+	if !ok || len(provider.GetAlwaysFilters()) == 0 {
+		return query
+	}
+
+	groupWhereConditions(query)
+
+	conditions := &DynamicFilter{}
+	for _, filter := range provider.GetAlwaysFilters() {
+		condition := conditions.buildCondition(filter)
+		if strings.Contains(condition, "?") {
+			query = query.Where(condition, conditions.conditionValue(filter))
+		} else {
+			query = query.Where(condition)
+		}
+	}
+	return query
+}
+
+// countRecords builds and executes the count query
+func countRecords(
+	db *gorm.DB,
+	builder QueryBuilder,
+	pagination PaginationRequest,
+	options PaginatedQueryOptions,
+	scope CountOptions,
+) (int64, error) {
+	totalCount, _, err := countRecordsWithSource(db, builder, pagination, options, scope)
+	return totalCount, err
+}
+
+// countRecordsWithSource counts the records, also reporting how the total was obtained
+func countRecordsWithSource(
+	db *gorm.DB,
+	builder QueryBuilder,
+	pagination PaginationRequest,
+	options PaginatedQueryOptions,
+	scope CountOptions,
+) (int64, CountSource, error) {
+	if scope.IncludeFilters && hasImpossibleConditions(builder) {
+		return 0, CountSourceExact, nil
+	}
+
+	if scope.IncludeSearch && missingRequiredSearch(builder, pagination) {
+		return 0, CountSourceExact, nil
+	}
+
+	// Precomputed and estimated totals can't reflect a per-request predicate
+	if provider, ok := builder.(CountTableProvider); ok && provider.GetCountTable() != nil && scope == allScopes && contextPredicate(builder) == nil {
+		if total, found, err := lookupCountTable(db, builder, *provider.GetCountTable(), pagination); err != nil || found {
+			return total, CountSourceCached, err
+		}
+	}
+
+	if provider, ok := builder.(CountEstimatorProvider); ok && provider.GetCountEstimator() != nil && scope == allScopes && contextPredicate(builder) == nil {
+		if total, estimated, err := provider.GetCountEstimator()(db, pagination); err != nil || estimated {
+			return total, CountSourceEstimated, err
+		}
+	}
+
+	totalCount, err := countExact(db, builder, pagination, options, scope)
+	return totalCount, CountSourceExact, err
+}
+
+// countExact runs the count query
+func countExact(
+	db *gorm.DB,
+	builder QueryBuilder,
+	pagination PaginationRequest,
+	options PaginatedQueryOptions,
+	scope CountOptions,
+) (int64, error) {
+	var totalCount int64
+
+	countDB, cancel := withQueryTimeout(db, builder)
+	defer cancel()
+	countQuery := applyBaseQuery(countDB.Table(builder.GetTableName()), builder, pagination, options, scope)
+
+	provider, hasCountExpression := builder.(CountExpressionProvider)
+	hasCountExpression = hasCountExpression && provider.GetCountExpression() != ""
+	var distinctColumns []string
+	if hasCountExpression {
+		// The driver decides whether the SQL runs, so its dialect picks the form rather
+		// than options.Dialect, which defaults to MySQL
+		expression, columns, err := parseCountExpression(provider.GetCountExpression(), driverDialect(countDB))
+		if err != nil {
+			return 0, err
+		}
+		if len(columns) > 0 {
+			distinctColumns = columns
+		} else {
+			countQuery = countQuery.Select(expression)
+		}
+	}
+
+	// Execute count query
+	if len(distinctColumns) > 0 && options.CustomCountQuery == "" {
+		// Count the distinct column combinations in a subquery where the dialect can't
+		// count them directly
+		countQuery = countQuery.Select("DISTINCT " + strings.Join(distinctColumns, ", "))
+		if err := countDB.Session(&gorm.Session{NewDB: true}).Table("(?) AS distinct_rows", countQuery).Count(&totalCount).Error; err != nil {
+			return 0, queryError(countDB, "failed to count records", err)
+		}
+	} else if _, grouped := countQuery.Statement.Clauses["GROUP BY"]; grouped && !hasCountExpression && options.CustomCountQuery == "" {
+		// Count the groups in the database rather than fetching a row per group; the
+		// builder's selects are kept so HAVING can reference their aliases
+		if len(countQuery.Statement.Selects) == 0 {
+			countQuery = countQuery.Select("1")
+		}
+		if err := countDB.Session(&gorm.Session{NewDB: true}).Table("(?) AS grouped_rows", countQuery).Count(&totalCount).Error; err != nil {
+			return 0, queryError(countDB, "failed to count records", err)
+		}
+	} else if options.CustomCountQuery != "" {
+		if err := countQuery.Raw(options.CustomCountQuery).Count(&totalCount).Error; err != nil {
+			return 0, queryError(countDB, "failed to count records", err)
+		}
+	} else {
+		if err := countQuery.Count(&totalCount).Error; err != nil {
+			return 0, queryError(countDB, "failed to count records", err)
+		}
+	}
+
+	return totalCount, nil
+}
+
+// CountTable reads totals from a summary table holding a precomputed count per filter
+// combination. Key returns the column values identifying the request's row, or nil
+// when the table has no row for it.
+type CountTable struct {
+	Table  string
+	Column string
+	Key    func(pagination PaginationRequest) map[string]interface{}
+}
+
+// lookupCountTable reads the request's total from the count table, reporting whether a row was found
+func lookupCountTable(db *gorm.DB, builder QueryBuilder, countTable CountTable, pagination PaginationRequest) (int64, bool, error) {
+	if !isValidIdentifier(countTable.Table) || !isValidIdentifier(countTable.Column) {
+		return 0, false, fmt.Errorf("invalid count table %s.%s", countTable.Table, countTable.Column)
+	}
+	if countTable.Key == nil {
+		return 0, false, nil
+	}
+
+	key := countTable.Key(pagination)
+	if key == nil {
+		return 0, false, nil
+	}
+	for column := range key {
+		if !isValidIdentifier(column) {
+			return 0, false, fmt.Errorf("invalid count table key column %q", column)
+		}
+	}
+
+	countDB, cancel := withQueryTimeout(db, builder)
+	defer cancel()
+
+	var totals []int64
+	if err := countDB.Table(countTable.Table).Where(key).Limit(1).Pluck(countTable.Column, &totals).Error; err != nil {
+		return 0, false, queryError(countDB, "failed to read count table", err)
+	}
+	if len(totals) == 0 {
+		return 0, false, nil
+	}
+	return totals[0], true, nil
+}
+
+// countExpressionPattern matches COUNT(*), COUNT(1), COUNT(column) and COUNT(DISTINCT column),
+// as well as COUNT(DISTINCT a, b) and COUNT(DISTINCT (a, b))
+var countExpressionPattern = regexp.MustCompile(`(?i)^\s*COUNT\s*\(\s*(DISTINCT\s+)?(\([^()]+\)|[^()]+?)\s*\)\s*$`)
+
+// parseCountExpression validates a count expression and returns it in canonical form for
+// the dialect. A count of distinct column combinations, such as a composite primary key,
+// is written COUNT(DISTINCT (a, b)) on PostgreSQL and COUNT(DISTINCT a, b) on MySQL;
+// other dialects have no such form, so the columns are returned for the caller to count
+// the distinct rows in a subquery instead.
+func parseCountExpression(expression string, dialect DatabaseDialect) (string, []string, error) {
+	matches := countExpressionPattern.FindStringSubmatch(expression)
+	if matches == nil {
+		return "", nil, fmt.Errorf("%w: %q", ErrInvalidCountExpression, expression)
+	}
+
+	distinct, argument := matches[1] != "", matches[2]
+	if strings.HasPrefix(argument, "(") {
+		argument = strings.TrimSuffix(strings.TrimPrefix(argument, "("), ")")
+	}
+	columns := strings.Split(argument, ",")
+	for i, column := range columns {
+		columns[i] = strings.TrimSpace(column)
+	}
+
+	switch {
+	case len(columns) > 1:
+		if !distinct {
+			return "", nil, fmt.Errorf("%w: %q", ErrInvalidCountExpression, expression)
+		}
+		for _, column := range columns {
+			if !isValidIdentifier(column) {
+				return "", nil, fmt.Errorf("%w: %q", ErrInvalidCountExpression, expression)
+			}
+		}
+		switch dialect {
+		case PostgreSQL:
+			return "COUNT(DISTINCT (" + strings.Join(columns, ", ") + "))", nil, nil
+		case MySQL:
+			return "COUNT(DISTINCT " + strings.Join(columns, ", ") + ")", nil, nil
+		default:
+			return "", columns, nil
+		}
+	case columns[0] == "*" || columns[0] == "1":
+		if distinct {
+			return "", nil, fmt.Errorf("%w: %q", ErrInvalidCountExpression, expression)
+		}
+	case !isValidIdentifier(columns[0]):
+		return "", nil, fmt.Errorf("%w: %q", ErrInvalidCountExpression, expression)
+	}
+
+	if distinct {
+		return "COUNT(DISTINCT " + columns[0] + ")", nil, nil
+	}
+	return "COUNT(" + columns[0] + ")", nil, nil
+}
+
+// driverDialect returns the dialect of the database driver, or "" for unknown drivers
+func driverDialect(db *gorm.DB) DatabaseDialect {
+	if db.Dialector == nil {
+		return ""
+	}
+	switch db.Dialector.Name() {
+	case "postgres":
+		return PostgreSQL
+	case "mysql":
+		return MySQL
+	case "sqlite":
+		return SQLite
+	case "sqlserver":
+		return SQLServer
+	}
+	return ""
+}
+
+// withQueryTimeout bounds the query by the builder's maximum duration, if one is configured
+func withQueryTimeout(db *gorm.DB, builder interface{}) (*gorm.DB, context.CancelFunc) {
+	provider, ok := builder.(QueryTimeoutProvider)
+	if !ok || provider.GetMaxQueryDuration() <= 0 {
+		return db, func() {}
+	}
+
+	parent := db.Statement.Context
+	if parent == nil {
+		parent = context.Background()
+	}
+
+	ctx, cancel := context.WithTimeout(parent, provider.GetMaxQueryDuration())
+	return db.WithContext(ctx), cancel
+}
+
+// queryError wraps a failed query error, reporting an exceeded deadline as ErrQueryTimeout
+func queryError(db *gorm.DB, action string, err error) error {
+	ctx := db.Statement.Context
+	if errors.Is(err, context.DeadlineExceeded) || (ctx != nil && errors.Is(ctx.Err(), context.DeadlineExceeded)) {
+		return fmt.Errorf("%s: %w", action, ErrQueryTimeout)
+	}
+	return fmt.Errorf("%s: %w", action, err)
+}
+
+var (
+	sortFieldValidatorMu sync.RWMutex
+	sortFieldValidator   = isValidSortField
+)
+
+// SetSortFieldValidator replaces the package-wide sort field validator.
+// Passing nil restores the default alphanumeric, underscore and dot check.
+func SetSortFieldValidator(validator func(string) bool) {
+	sortFieldValidatorMu.Lock()
+	defer sortFieldValidatorMu.Unlock()
+
+	if validator == nil {
+		validator = isValidSortField
+	}
+	sortFieldValidator = validator
+}
+
+// validateSortField checks the sort field with the builder's validator, falling back to the package-wide one
+func validateSortField(builder interface{}, field string) bool {
+	if provider, ok := builder.(SortFieldValidatorProvider); ok {
+		if validator := provider.GetSortFieldValidator(); validator != nil {
+			return validator(field)
+		}
+	}
+
+	sortFieldValidatorMu.RLock()
+	validator := sortFieldValidator
+	sortFieldValidatorMu.RUnlock()
+
+	return validator(field)
+}
+
+// hasSortableFields reports whether the builder restricts sorting to an allowlist
+func hasSortableFields(builder interface{}) bool {
+	provider, ok := builder.(SortableFieldsProvider)
+	return ok && len(provider.GetSortableFields()) > 0
+}
+
+// isSortableField checks the field against the builder's sortable fields allowlist, if any
+func isSortableField(builder interface{}, field string) bool {
+	if !hasSortableFields(builder) || isCountSort(builder, field) {
+		return true
+	}
+	return builder.(SortableFieldsProvider).GetSortableFields()[field]
+}
+
+// isValidSortField validates sort field to prevent SQL injection
+func isValidSortField(field string) bool {
+	return isValidIdentifier(field)
+}
+
+// isValidIdentifier validates a column reference that may be schema-qualified
+// (analytics.users.created_at) or quoted ("Created At"), checking each segment
+func isValidIdentifier(identifier string) bool {
+	segments, ok := splitIdentifier(identifier)
+	if !ok {
+		return false
+	}
+
+	for _, segment := range segments {
+		if !isValidIdentifierSegment(segment) {
+			return false
+		}
+	}
+	return true
+}
+
+// splitIdentifier splits an identifier on the dots that are not inside quotes
+func splitIdentifier(identifier string) ([]string, bool) {
+	var segments []string
+	var closing rune
+	start := 0
+
+	for i, char := range identifier {
+		switch {
+		case closing != 0:
+			if char == closing {
+				closing = 0
+			}
+		case char == '"':
+			closing = '"'
+		case char == '`':
+			closing = '`'
+		case char == '[':
+			closing = ']'
+		case char == '.':
+			segments = append(segments, identifier[start:i])
+			start = i + 1
+		}
+	}
+
+	// Unterminated quote
+	if closing != 0 {
+		return nil, false
+	}
+	return append(segments, identifier[start:]), true
+}
+
+// isValidIdentifierSegment validates a single bare or quoted identifier segment
+func isValidIdentifierSegment(segment string) bool {
+	if inner, quoted := unquoteIdentifier(segment); quoted {
+		// Quoted segments may also contain spaces, but never quote characters
+		for _, char := range inner {
+			if !isIdentifierChar(char) && char != ' ' {
+				return false
+			}
+		}
+		return strings.TrimSpace(inner) != ""
+	}
+
+	for _, char := range segment {
+		if !isIdentifierChar(char) {
+			return false
+		}
+	}
+	return len(segment) > 0
+}
+
+// unquoteIdentifier strips matching double quotes, backticks or brackets from a segment
+func unquoteIdentifier(segment string) (string, bool) {
+	if len(segment) < 2 {
+		return segment, false
+	}
+
+	first, last := segment[0], segment[len(segment)-1]
+	if (first == '"' && last == '"') || (first == '`' && last == '`') || (first == '[' && last == ']') {
+		return segment[1 : len(segment)-1], true
+	}
+	return segment, false
+}
+
+func isIdentifierChar(char rune) bool {
+	return (char >= 'a' && char <= 'z') ||
+		(char >= 'A' && char <= 'Z') ||
+		(char >= '0' && char <= '9') ||
+		char == '_'
+}
+
+// isValidInclude validates include field to prevent SQL injection
+func isValidInclude(include string) bool {
+	// Allow only alphanumeric characters, underscores, and dots
+	for _, char := range include {
+		if !((char >= 'a' && char <= 'z') ||
+			(char >= 'A' && char <= 'Z') ||
+			(char >= '0' && char <= '9') ||
+			char == '_' || char == '.') {
+			return false
+		}
+	}
+	return len(include) > 0
+}
+
+// disabledIncludesPolicy returns the builder's policy for includes on disabled pagination
+func disabledIncludesPolicy(builder interface{}) DisabledIncludesPolicy {
+	if provider, ok := builder.(DisabledIncludesPolicyProvider); ok {
+		return provider.GetDisabledIncludesPolicy()
+	}
+	return DisabledIncludesAllow
+}
+
+// rejectedIncludesError returns the error for includes requested with pagination
+// disabled on a builder rejecting them, counting relations loaded by the field
+// selection as includes
+func rejectedIncludesError[T any](db *gorm.DB, builder QueryBuilder, pagination PaginationRequest, includes []string) error {
+	if !pagination.IsDisabled || disabledIncludesPolicy(builder) != DisabledIncludesReject {
+		return nil
+	}
+	if _, selectedPreloads := fieldSelectionPlan[T](db, builder); len(validateIncludes(builder, includes)) == 0 && len(selectedPreloads) == 0 {
+		return nil
+	}
+	return &QueryParamsError{Err: ErrIncludesWithDisabledPagination}
+}
+
+// exceedsIncludeThreshold reports whether the page is larger than the builder's include threshold.
+// Disabled pagination always exceeds a configured threshold, or any under DisabledIncludesSkip.
+func exceedsIncludeThreshold(builder interface{}, pagination PaginationRequest) bool {
+	if pagination.IsDisabled && disabledIncludesPolicy(builder) == DisabledIncludesSkip {
+		return true
+	}
+
+	provider, ok := builder.(IncludeThresholdProvider)
+	if !ok || provider.GetIncludeThreshold() <= 0 {
+		return false
+	}
+	return pagination.IsDisabled || pagination.GetLimit() > provider.GetIncludeThreshold()
+}
+
+// SkippedIncludes returns the valid includes that are not loaded because the page
+// exceeds the builder's include threshold
+func SkippedIncludes(builder interface{}, pagination PaginationRequest, includes []string) []string {
+	if !exceedsIncludeThreshold(builder, pagination) {
+		return nil
+	}
+	return validateIncludes(builder, includes)
+}
+
+// validateIncludes validates includes against allowed includes for the builder
+func validateIncludes(builder interface{}, includes []string) []string {
+	if includeValidator, ok := builder.(AllowedIncludesProvider); ok {
+		allowedIncludes := includeValidator.GetAllowedIncludes()
+		var validIncludes []string
+		for _, include := range includes {
+			if isValidInclude(include) && allowedIncludes[include] {
+				validIncludes = append(validIncludes, include)
+			}
+		}
+		return validIncludes
+	}
+
+	// Fallback: just validate syntax if no allowed includes defined
+	var validIncludes []string
+	for _, include := range includes {
+		if isValidInclude(include) {
+			validIncludes = append(validIncludes, include)
+		}
+	}
+	return validIncludes
+}
+
+type SimpleQueryBuilder struct {
+	TableName            string
+	FilterFunc           func(*gorm.DB) *gorm.DB
+	SearchFields         []string
+	DefaultSort          string
+	Dialect              DatabaseDialect
+	MaxQueryDuration     time.Duration
+	SortFieldValidator   func(string) bool
+	SmartSearch          func(term string) (sql string, args []interface{}, ok bool)
+	RelationCounts       []RelationCount
+	CountSorts           []RelationCount
+	SearchWeights        map[string]int
+	RawSelects           []RawSelect
+	SortableFields       map[string]bool
+	IncludeThreshold     int
+	DisabledIncludes     DisabledIncludesPolicy
+	Model                interface{}
+	RelationCountFilters []RelationCountFilter
+	CountExpression      string
+	Warnings             bool
+	CastSearchFields     map[string]bool
+	ConcatSearchFields   [][]string
+	CountTable           *CountTable
+	NextCursor           bool
+	ClampPage            bool
+	RequireSearch        bool
+	SearchOnlyFilter     func(query *gorm.DB, term string) *gorm.DB
+	AlwaysFilters        []FilterCondition
+	CountSource          bool
+	OffsetLimit          bool
+	EchoEffectiveSearch  bool
+	ContextPredicate     func(ctx context.Context) (string, []interface{})
+	AggregateExpressions map[string]string
+	DatasetVersionColumn string
+	Explain              bool
+	ExplainAnalyze       bool
+	NaturalOrder         bool
+	StrictSort           bool
+	FieldSelection       *FieldSelection
+	SelectableFields     map[string]map[string]bool
+	TokenizedSearch      bool
+	OrderByIDsColumn     string
+	OrderByIDs           []interface{}
+	CountEstimator       CountEstimator
+	ForcedSort           string
+	CursorTimeLayout     string
+	QuotedIdentifiers    bool
+	IncludeFilters       []IncludeFilter
+	IncludeFilterFields  map[string]map[string]bool
+}
+
+func (s *SimpleQueryBuilder) ApplyFilters(query *gorm.DB) *gorm.DB {
+	if s.FilterFunc != nil {
+		query = s.FilterFunc(query)
+	}
+
+	for _, filter := range s.RelationCountFilters {
+		query = applyRelationCountFilter(query, s.Model, s.TableName, filter)
+	}
+
+	return query
+}
+
+func (s *SimpleQueryBuilder) GetSearchFields() []string {
+	return s.SearchFields
+}
+
+func (s *SimpleQueryBuilder) GetTableName() string {
+	return s.TableName
+}
 
 func (s *SimpleQueryBuilder) GetDefaultSort() string {
 	if s.DefaultSort == "" {
@@ -331,6 +1882,599 @@ func (s *SimpleQueryBuilder) WithFilters(filterFunc func(*gorm.DB) *gorm.DB) *Si
 	return s
 }
 
+// WithMaxQueryDuration limits how long the count and data queries may each run
+func (s *SimpleQueryBuilder) WithMaxQueryDuration(d time.Duration) *SimpleQueryBuilder {
+	s.MaxQueryDuration = d
+	return s
+}
+
+// GetMaxQueryDuration returns the maximum duration allowed for each query
+func (s *SimpleQueryBuilder) GetMaxQueryDuration() time.Duration {
+	return s.MaxQueryDuration
+}
+
+// WithSortFieldValidator overrides the package-wide sort field validator for this builder
+func (s *SimpleQueryBuilder) WithSortFieldValidator(validator func(string) bool) *SimpleQueryBuilder {
+	s.SortFieldValidator = validator
+	return s
+}
+
+// GetSortFieldValidator returns the builder's sort field validator, or nil to use the package-wide one
+func (s *SimpleQueryBuilder) GetSortFieldValidator() func(string) bool {
+	return s.SortFieldValidator
+}
+
+// WithSmartSearch adds a predicate derived from the search term that is ORed with the
+// regular search, e.g. matching an exact ID when the term is numeric
+func (s *SimpleQueryBuilder) WithSmartSearch(smartSearch func(term string) (sql string, args []interface{}, ok bool)) *SimpleQueryBuilder {
+	s.SmartSearch = smartSearch
+	return s
+}
+
+// GetSmartSearch returns the builder's smart search function
+func (s *SimpleQueryBuilder) GetSmartSearch() func(term string) (sql string, args []interface{}, ok bool) {
+	return s.SmartSearch
+}
+
+// WithRelationCount selects the number of related rows of a has-one/has-many relation
+// under the given alias, e.g. WithRelationCount("Athletes", "athletes_count")
+func (s *SimpleQueryBuilder) WithRelationCount(relation string, alias string) *SimpleQueryBuilder {
+	s.RelationCounts = append(s.RelationCounts, RelationCount{Relation: relation, Alias: alias})
+	return s
+}
+
+// GetRelationCounts returns the relation counts selected by the builder
+func (s *SimpleQueryBuilder) GetRelationCounts() []RelationCount {
+	return s.RelationCounts
+}
+
+// WithWeightedSearch ranks search results by the summed weight of the fields that match
+// when no explicit sort is requested. The weighted fields are also used as the search
+// fields unless search fields were already set.
+func (s *SimpleQueryBuilder) WithWeightedSearch(weights map[string]int) *SimpleQueryBuilder {
+	s.SearchWeights = weights
+	if len(s.SearchFields) == 0 {
+		s.SearchFields = weightedFields(weights)
+	}
+	return s
+}
+
+// GetSearchWeights returns the builder's search field weights
+func (s *SimpleQueryBuilder) GetSearchWeights() map[string]int {
+	return s.SearchWeights
+}
+
+// WithCountSort registers a sort alias ordering by the number of related rows of a
+// has-one/has-many relation, e.g. WithCountSort("athletes_count", "Athletes") for
+// ?sort=athletes_count. The alias is sortable even with a sortable fields allowlist.
+func (s *SimpleQueryBuilder) WithCountSort(alias string, relation string) *SimpleQueryBuilder {
+	s.CountSorts = append(s.CountSorts, RelationCount{Relation: relation, Alias: alias})
+	return s
+}
+
+// GetCountSorts returns the relation count sorts registered on the builder
+func (s *SimpleQueryBuilder) GetCountSorts() []RelationCount {
+	return s.CountSorts
+}
+
+// WithSelectRaw selects a computed column, e.g. WithSelectRaw("CASE WHEN age < 23 THEN 'U-23' ELSE 'Senior' END", "age_group").
+// The expression is inserted verbatim, so it must never contain client input.
+func (s *SimpleQueryBuilder) WithSelectRaw(expr string, alias string) *SimpleQueryBuilder {
+	s.RawSelects = append(s.RawSelects, RawSelect{Expression: expr, Alias: alias})
+	return s
+}
+
+// GetRawSelects returns the builder's computed columns
+func (s *SimpleQueryBuilder) GetRawSelects() []RawSelect {
+	return s.RawSelects
+}
+
+// WithSortableFields restricts client sorting to the given fields; other fields fall back to the default sort
+func (s *SimpleQueryBuilder) WithSortableFields(fields ...string) *SimpleQueryBuilder {
+	s.SortableFields = make(map[string]bool, len(fields))
+	for _, field := range fields {
+		s.SortableFields[field] = true
+	}
+	return s
+}
+
+// GetSortableFields returns the builder's sortable fields allowlist
+func (s *SimpleQueryBuilder) GetSortableFields() map[string]bool {
+	return s.SortableFields
+}
+
+// WithForcedSort pins the sort to clause, such as "score desc, id asc" for a
+// leaderboard, ignoring the client's sort and order. It panics if the clause is not a
+// list of columns with optional asc/desc directions, as it is fixed at configuration;
+// an empty clause lets clients sort again.
+func (s *SimpleQueryBuilder) WithForcedSort(clause string) *SimpleQueryBuilder {
+	if clause == "" {
+		s.ForcedSort = ""
+		return s
+	}
+	if err := validateSortClause(clause); err != nil {
+		panic("pagination: WithForcedSort: " + err.Error())
+	}
+	s.ForcedSort = clause
+	return s
+}
+
+// GetForcedSort returns the sort clause clients can't override, if any
+func (s *SimpleQueryBuilder) GetForcedSort() string {
+	return s.ForcedSort
+}
+
+// WithQuotedIdentifiers quotes the sort and search columns the builder emits in the
+// dialect's style, for tables with reserved-word columns such as order or group.
+// Expressions are left as they are.
+func (s *SimpleQueryBuilder) WithQuotedIdentifiers() *SimpleQueryBuilder {
+	s.QuotedIdentifiers = true
+	return s
+}
+
+// GetQuotedIdentifiers reports whether the builder quotes the columns it emits
+func (s *SimpleQueryBuilder) GetQuotedIdentifiers() bool {
+	return s.QuotedIdentifiers
+}
+
+// WithCursorTimeLayout stores cursor timestamps as text in layout, such as SQLite's
+// "2006-01-02 15:04:05.999999999-07:00", so they are compared as the stored strings
+// rather than as driver time values that may be rounded
+func (s *SimpleQueryBuilder) WithCursorTimeLayout(layout string) *SimpleQueryBuilder {
+	s.CursorTimeLayout = layout
+	return s
+}
+
+// GetCursorTimeLayout returns the text layout of cursor timestamps, if any
+func (s *SimpleQueryBuilder) GetCursorTimeLayout() string {
+	return s.CursorTimeLayout
+}
+
+// WithIncludeThreshold skips includes when per_page exceeds n, to avoid preloading
+// thousands of related rows on large pages
+func (s *SimpleQueryBuilder) WithIncludeThreshold(n int) *SimpleQueryBuilder {
+	s.IncludeThreshold = n
+	return s
+}
+
+// GetIncludeThreshold returns the largest page size for which includes are loaded
+func (s *SimpleQueryBuilder) GetIncludeThreshold() int {
+	return s.IncludeThreshold
+}
+
+// WithDisabledIncludesPolicy guards against preloading whole relation sets when
+// is_disabled=true is combined with includes: DisabledIncludesSkip drops the includes,
+// reported as skipped, and DisabledIncludesReject fails the request with 400
+func (s *SimpleQueryBuilder) WithDisabledIncludesPolicy(policy DisabledIncludesPolicy) *SimpleQueryBuilder {
+	s.DisabledIncludes = policy
+	return s
+}
+
+// GetDisabledIncludesPolicy returns the policy for includes on disabled pagination
+func (s *SimpleQueryBuilder) GetDisabledIncludesPolicy() DisabledIncludesPolicy {
+	return s.DisabledIncludes
+}
+
+// WithModelTable sets the model like WithModel and takes the builder's table name from
+// it, resolved once through db's naming strategy, so it matches the table GORM uses for
+// the model, e.g. with singular or prefixed table names. The name is not re-resolved
+// for the database a query later runs on: querying another database, or a session with
+// a different naming strategy, still uses db's table name, so each naming strategy
+// needs a builder of its own. It panics if the model can't be parsed, as it is fixed
+// at configuration.
+func (s *SimpleQueryBuilder) WithModelTable(db *gorm.DB, model interface{}) *SimpleQueryBuilder {
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(model); err != nil {
+		panic("pagination: WithModelTable: " + err.Error())
+	}
+	s.Model = model
+	s.TableName = stmt.Table
+	return s
+}
+
+// WithModel sets the GORM model the builder's table holds, used to resolve relations
+func (s *SimpleQueryBuilder) WithModel(model interface{}) *SimpleQueryBuilder {
+	s.Model = model
+	return s
+}
+
+// WithRelationCountFilter keeps only records whose has-one/has-many relation has op n rows,
+// e.g. WithRelationCountFilter("Events", ">=", 3). It requires WithModel and applies to
+// both the count and data queries.
+func (s *SimpleQueryBuilder) WithRelationCountFilter(relation string, op string, n int) *SimpleQueryBuilder {
+	s.RelationCountFilters = append(s.RelationCountFilters, RelationCountFilter{Relation: relation, Operator: op, Count: n})
+	return s
+}
+
+// WithCountExpression sets what the count query counts: COUNT(*) (the default),
+// COUNT(1), COUNT(column), COUNT(DISTINCT column) or, e.g. for a composite primary key
+// across a join, COUNT(DISTINCT (a, b)). Any other form fails the query with
+// ErrInvalidCountExpression.
+func (s *SimpleQueryBuilder) WithCountExpression(expression string) *SimpleQueryBuilder {
+	s.CountExpression = expression
+	return s
+}
+
+// GetCountExpression returns the configured count expression
+func (s *SimpleQueryBuilder) GetCountExpression() string {
+	return s.CountExpression
+}
+
+// WithCastSearchFields searches numeric or boolean columns by casting them to text,
+// e.g. CAST(age AS TEXT) LIKE '%25%'. The fields are added to the search fields if
+// missing. Casting prevents index use, so only mark the columns that need it.
+func (s *SimpleQueryBuilder) WithCastSearchFields(fields ...string) *SimpleQueryBuilder {
+	if s.CastSearchFields == nil {
+		s.CastSearchFields = make(map[string]bool)
+	}
+	for _, field := range fields {
+		if !s.CastSearchFields[field] && !slices.Contains(s.SearchFields, field) {
+			s.SearchFields = append(s.SearchFields, field)
+		}
+		s.CastSearchFields[field] = true
+	}
+	return s
+}
+
+// GetCastSearchFields returns the search fields that are cast to text
+func (s *SimpleQueryBuilder) GetCastSearchFields() map[string]bool {
+	return s.CastSearchFields
+}
+
+// WithConcatSearchField searches the columns joined with spaces, e.g.
+// WithConcatSearchField("first_name", "last_name") so "budi santoso" matches a full
+// name, using CONCAT or || as the dialect requires
+func (s *SimpleQueryBuilder) WithConcatSearchField(fields ...string) *SimpleQueryBuilder {
+	s.ConcatSearchFields = append(s.ConcatSearchFields, fields)
+	return s
+}
+
+// GetConcatSearchFields returns the column groups searched joined with spaces
+func (s *SimpleQueryBuilder) GetConcatSearchFields() [][]string {
+	return s.ConcatSearchFields
+}
+
+// WithCountTable reads the total from the "total" column of the summary table row
+// matching key, e.g. WithCountTable("athlete_counts", func(p PaginationRequest)
+// map[string]interface{} { return map[string]interface{}{"search": p.Search} }).
+// When key returns nil or no row matches, the records are counted as usual.
+func (s *SimpleQueryBuilder) WithCountTable(table string, key func(pagination PaginationRequest) map[string]interface{}) *SimpleQueryBuilder {
+	s.CountTable = &CountTable{Table: table, Column: "total", Key: key}
+	return s
+}
+
+// GetCountTable returns the summary table totals are read from, if any
+func (s *SimpleQueryBuilder) GetCountTable() *CountTable {
+	return s.CountTable
+}
+
+// WithNextCursor adds the cursor after the page's last item to the offset metadata
+// whenever more items remain, so clients can switch to cursor navigation
+func (s *SimpleQueryBuilder) WithNextCursor() *SimpleQueryBuilder {
+	s.NextCursor = true
+	return s
+}
+
+// GetNextCursor reports whether responses carry a next cursor
+func (s *SimpleQueryBuilder) GetNextCursor() bool {
+	return s.NextCursor
+}
+
+// WithCountEstimator estimates totals with estimator instead of counting whenever it
+// can; responses then flag the total as approximate
+func (s *SimpleQueryBuilder) WithCountEstimator(estimator CountEstimator) *SimpleQueryBuilder {
+	s.CountEstimator = estimator
+	return s
+}
+
+// GetCountEstimator returns the builder's count estimator, if any
+func (s *SimpleQueryBuilder) GetCountEstimator() CountEstimator {
+	return s.CountEstimator
+}
+
+// WithIncludeFilterFields allows filtering the rows of an include by the given fields,
+// e.g. WithIncludeFilterFields("Athletes", "gender")
+func (s *SimpleQueryBuilder) WithIncludeFilterFields(include string, fields ...string) *SimpleQueryBuilder {
+	if s.IncludeFilterFields == nil {
+		s.IncludeFilterFields = make(map[string]map[string]bool)
+	}
+	if s.IncludeFilterFields[include] == nil {
+		s.IncludeFilterFields[include] = make(map[string]bool)
+	}
+	for _, field := range fields {
+		s.IncludeFilterFields[include][field] = true
+	}
+	return s
+}
+
+// GetIncludeFilterFields returns the fields each include can be filtered by
+func (s *SimpleQueryBuilder) GetIncludeFilterFields() map[string]map[string]bool {
+	return s.IncludeFilterFields
+}
+
+// WithIncludeFilters filters the rows loaded for includes, typically from
+// ParseIncludeFilters(ctx.Request.URL.Query()). Filters on fields not allowed by
+// WithIncludeFilterFields are ignored.
+func (s *SimpleQueryBuilder) WithIncludeFilters(filters ...IncludeFilter) *SimpleQueryBuilder {
+	s.IncludeFilters = append(s.IncludeFilters, filters...)
+	return s
+}
+
+// GetIncludeFilters returns the builder's include filters
+func (s *SimpleQueryBuilder) GetIncludeFilters() []IncludeFilter {
+	return s.IncludeFilters
+}
+
+// WithCountSource reports in the response's count_source how the total was obtained
+func (s *SimpleQueryBuilder) WithCountSource() *SimpleQueryBuilder {
+	s.CountSource = true
+	return s
+}
+
+// GetCountSourceEnabled reports whether responses carry the count source
+func (s *SimpleQueryBuilder) GetCountSourceEnabled() bool {
+	return s.CountSource
+}
+
+// WithOrderByIDs orders rows by the position of their column value in ids, such as the
+// client's "recently viewed" list, ignoring the client's sort and order; rows whose
+// value isn't listed come last, in the default sort. It doesn't filter rows, so pair it
+// with a condition such as column IN ids. It panics if the column is not a valid
+// identifier, as it is fixed at configuration; no IDs restore the usual sorting.
+func (s *SimpleQueryBuilder) WithOrderByIDs(column string, ids []interface{}) *SimpleQueryBuilder {
+	if !isValidIdentifier(column) {
+		panic("pagination: WithOrderByIDs: invalid column " + column)
+	}
+	s.OrderByIDsColumn = column
+	s.OrderByIDs = ids
+	return s
+}
+
+// GetOrderByIDs returns the column and IDs rows are ordered by
+func (s *SimpleQueryBuilder) GetOrderByIDs() (string, []interface{}) {
+	return s.OrderByIDsColumn, s.OrderByIDs
+}
+
+// WithNaturalOrder leaves rows in the database's natural order, e.g. that of a clustered
+// index, by emitting no ORDER BY unless the client requests a valid sort: the default
+// sort and search relevance ranking are suppressed. The database doesn't guarantee
+// natural order is stable, so pages may then overlap or skip rows; enabling it logs a
+// warning saying so. A forced sort still applies.
+func (s *SimpleQueryBuilder) WithNaturalOrder(natural bool) *SimpleQueryBuilder {
+	if natural {
+		log.Printf("pagination: natural order on %s, page boundaries are not guaranteed to be stable", s.TableName)
+	}
+	s.NaturalOrder = natural
+	return s
+}
+
+// GetNaturalOrder reports whether unsorted rows are left in natural order
+func (s *SimpleQueryBuilder) GetNaturalOrder() bool {
+	return s.NaturalOrder
+}
+
+// WithTokenizedSearch splits the search term on whitespace and matches rows containing
+// every word, each in any search field, so "budi jakarta" finds Budi from Jakarta. A
+// smart search still receives the whole term.
+func (s *SimpleQueryBuilder) WithTokenizedSearch(tokenized bool) *SimpleQueryBuilder {
+	s.TokenizedSearch = tokenized
+	return s
+}
+
+// GetTokenizedSearch returns whether search terms are split into words
+func (s *SimpleQueryBuilder) GetTokenizedSearch() bool {
+	return s.TokenizedSearch
+}
+
+// WithSelectableFields allows clients to select the given fields of a relation, or of
+// the base table for relation "", e.g. WithSelectableFields("Province", "name", "code").
+// A relation is only loaded through the field selection once it has selectable fields
+// and is an allowed include.
+func (s *SimpleQueryBuilder) WithSelectableFields(relation string, fields ...string) *SimpleQueryBuilder {
+	if s.SelectableFields == nil {
+		s.SelectableFields = make(map[string]map[string]bool)
+	}
+	if s.SelectableFields[relation] == nil {
+		s.SelectableFields[relation] = make(map[string]bool)
+	}
+	for _, field := range fields {
+		s.SelectableFields[relation][field] = true
+	}
+	return s
+}
+
+// GetSelectableFields returns the fields clients can select, by relation path
+func (s *SimpleQueryBuilder) GetSelectableFields() map[string]map[string]bool {
+	return s.SelectableFields
+}
+
+// WithFieldSelection sets the client's field selection, usually parsed from the fields
+// parameter with ParseFieldSelection. Fields and relations not allowed by
+// WithSelectableFields are ignored.
+func (s *SimpleQueryBuilder) WithFieldSelection(selection *FieldSelection) *SimpleQueryBuilder {
+	s.FieldSelection = selection
+	return s
+}
+
+// GetFieldSelection returns the client's field selection
+func (s *SimpleQueryBuilder) GetFieldSelection() *FieldSelection {
+	return s.FieldSelection
+}
+
+// WithStrictSort makes queries fail with ErrInvalidSortField, answered with 400 by the
+// response helpers, when the requested sort is invalid, not sortable or fails in the
+// database, instead of silently falling back to the default sort
+func (s *SimpleQueryBuilder) WithStrictSort(strict bool) *SimpleQueryBuilder {
+	s.StrictSort = strict
+	return s
+}
+
+// GetStrictSort returns whether disallowed sorts are rejected
+func (s *SimpleQueryBuilder) GetStrictSort() bool {
+	return s.StrictSort
+}
+
+// WithExplain returns the data query's plan in the response's query_plan, for debugging
+// missing indexes, once SetExplainEnabled allows it. analyze asks for EXPLAIN ANALYZE,
+// which runs the query again and is only honored under SetExplainAnalyzeAllowed.
+func (s *SimpleQueryBuilder) WithExplain(analyze bool) *SimpleQueryBuilder {
+	s.Explain = true
+	s.ExplainAnalyze = analyze
+	return s
+}
+
+// GetExplain returns whether the query plan is requested, and with ANALYZE
+func (s *SimpleQueryBuilder) GetExplain() (bool, bool) {
+	return s.Explain, s.ExplainAnalyze
+}
+
+// WithAggregates summarizes every row matching the request's filters and search in the
+// response's aggregates, e.g. WithAggregates(map[string]string{"total_height":
+// "SUM(height)"}). Expressions are SQL and must never come from the client; aliases
+// must be plain identifiers.
+func (s *SimpleQueryBuilder) WithAggregates(aggregates map[string]string) *SimpleQueryBuilder {
+	if s.AggregateExpressions == nil {
+		s.AggregateExpressions = make(map[string]string, len(aggregates))
+	}
+	for alias, expression := range aggregates {
+		s.AggregateExpressions[alias] = expression
+	}
+	return s
+}
+
+// GetAggregates returns the aggregate expressions by alias
+func (s *SimpleQueryBuilder) GetAggregates() map[string]string {
+	return s.AggregateExpressions
+}
+
+// WithDatasetVersion adds a version of the filtered rows to the response as
+// dataset_version, derived from MAX(timeColumn) and the row count, for clients polling
+// for changes. It panics if the column is not a valid identifier, as it is fixed at
+// configuration.
+func (s *SimpleQueryBuilder) WithDatasetVersion(timeColumn string) *SimpleQueryBuilder {
+	if !isValidIdentifier(timeColumn) {
+		panic("pagination: WithDatasetVersion: invalid column " + timeColumn)
+	}
+	s.DatasetVersionColumn = timeColumn
+	return s
+}
+
+// GetDatasetVersionColumn returns the modification time column versioning the rows
+func (s *SimpleQueryBuilder) GetDatasetVersionColumn() string {
+	return s.DatasetVersionColumn
+}
+
+// WithContextPredicate restricts the count and data queries by the SQL predicate and
+// arguments derived from the query's context, e.g. the rows the authenticated user may
+// see under application-enforced row-level security. It applies whatever else the
+// request filters, to totals as well, and precomputed count tables and count estimators
+// are skipped. An empty predicate matches no rows, so a predicate that fails to derive
+// one denies access rather than exposing every row.
+func (s *SimpleQueryBuilder) WithContextPredicate(predicate func(ctx context.Context) (string, []interface{})) *SimpleQueryBuilder {
+	s.ContextPredicate = predicate
+	return s
+}
+
+// GetContextPredicate returns the predicate derived from the query's context
+func (s *SimpleQueryBuilder) GetContextPredicate() func(ctx context.Context) (string, []interface{}) {
+	return s.ContextPredicate
+}
+
+// WithEffectiveSearch echoes the search term the query matched in the response as
+// effective_search, empty when the search was ignored, so clients can display what
+// their results are for
+func (s *SimpleQueryBuilder) WithEffectiveSearch() *SimpleQueryBuilder {
+	s.EchoEffectiveSearch = true
+	return s
+}
+
+// GetEffectiveSearchEnabled reports whether responses carry the effective search term
+func (s *SimpleQueryBuilder) GetEffectiveSearchEnabled() bool {
+	return s.EchoEffectiveSearch
+}
+
+// WithOffsetLimit echoes the offset and limit applied to the data query in the
+// response, after defaulting and page clamping, for debugging and virtualized lists
+func (s *SimpleQueryBuilder) WithOffsetLimit() *SimpleQueryBuilder {
+	s.OffsetLimit = true
+	return s
+}
+
+// GetOffsetLimitEnabled reports whether responses carry the applied offset and limit
+func (s *SimpleQueryBuilder) GetOffsetLimitEnabled() bool {
+	return s.OffsetLimit
+}
+
+// WithClampPage serves the last page instead of an empty one when the requested page
+// is past the end; the response then carries the requested page alongside
+func (s *SimpleQueryBuilder) WithClampPage() *SimpleQueryBuilder {
+	s.ClampPage = true
+	return s
+}
+
+// GetClampPage reports whether pages past the end are clamped to the last page
+func (s *SimpleQueryBuilder) GetClampPage() bool {
+	return s.ClampPage
+}
+
+// WithAlwaysFilter adds a condition, such as ("is_active", "=", true) on a public
+// listing, to every data and count query regardless of the client's filters and search.
+// The operator is one of the filter operators, such as "=", "in" or "is_null". It
+// panics if the column is not a valid identifier or the operator is unknown, as they
+// are fixed at configuration and a mistyped operator would silently compare equality.
+func (s *SimpleQueryBuilder) WithAlwaysFilter(column, operator string, value interface{}) *SimpleQueryBuilder {
+	if !isValidIdentifier(column) {
+		panic("pagination: WithAlwaysFilter: invalid column " + column)
+	}
+	if !isFilterOperator(operator) {
+		panic("pagination: WithAlwaysFilter: unknown operator " + operator)
+	}
+	s.AlwaysFilters = append(s.AlwaysFilters, FilterCondition{Field: column, Operator: operator, Value: value})
+	return s
+}
+
+// GetAlwaysFilters returns the conditions applied to every query
+func (s *SimpleQueryBuilder) GetAlwaysFilters() []FilterCondition {
+	return s.AlwaysFilters
+}
+
+// WithSearchOnlyFilter applies filterFunc to the count and data queries only when the
+// request has a search term, which it receives, e.g. to join a full-text index or add
+// an expensive condition that empty searches shouldn't pay for
+func (s *SimpleQueryBuilder) WithSearchOnlyFilter(filterFunc func(query *gorm.DB, term string) *gorm.DB) *SimpleQueryBuilder {
+	s.SearchOnlyFilter = filterFunc
+	return s
+}
+
+// GetSearchOnlyFilter returns the filter applied only when searching
+func (s *SimpleQueryBuilder) GetSearchOnlyFilter() func(query *gorm.DB, term string) *gorm.DB {
+	return s.SearchOnlyFilter
+}
+
+// WithRequireSearch makes an empty search term return an empty page with a total of 0
+// without querying, for search endpoints that list nothing until the user types
+func (s *SimpleQueryBuilder) WithRequireSearch(require bool) *SimpleQueryBuilder {
+	s.RequireSearch = require
+	return s
+}
+
+// GetRequireSearch reports whether a search term is required to return results
+func (s *SimpleQueryBuilder) GetRequireSearch() bool {
+	return s.RequireSearch
+}
+
+// WithWarnings reports ignored includes and sorts in the response's warnings instead
+// of altering the request silently
+func (s *SimpleQueryBuilder) WithWarnings() *SimpleQueryBuilder {
+	s.Warnings = true
+	return s
+}
+
+// GetWarningsEnabled reports whether response warnings are enabled
+func (s *SimpleQueryBuilder) GetWarningsEnabled() bool {
+	return s.Warnings
+}
+
 // GetSearchOperator returns the search operator based on the current dialect
 func (s *SimpleQueryBuilder) GetSearchOperator() string {
 	return getSearchOperator(s.Dialect)
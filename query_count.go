@@ -0,0 +1,81 @@
+package pagination
+
+import (
+	"context"
+	"log"
+	"sync"
+	"sync/atomic"
+
+	"gorm.io/gorm"
+)
+
+// queryCounterKey is the context key of the counter CountQueries increments
+type queryCounterKey struct{}
+
+const queryCounterCallback = "pagination:count_queries"
+
+var (
+	queryCountDebugMu sync.RWMutex
+	queryCountDebug   bool
+
+	queryCounterRegisterMu sync.Mutex
+)
+
+// SetQueryCountDebug enables a development-mode check that logs a warning when a
+// PaginatedQueryWithIncludable call issues more queries than the count, the data
+// query and one preload per include, which usually means an N+1 from hooks or
+// nested loads. It registers a GORM callback, so keep it off in production.
+func SetQueryCountDebug(enabled bool) {
+	queryCountDebugMu.Lock()
+	defer queryCountDebugMu.Unlock()
+
+	queryCountDebug = enabled
+}
+
+// isQueryCountDebug reports whether the N+1 check is enabled
+func isQueryCountDebug() bool {
+	queryCountDebugMu.RLock()
+	defer queryCountDebugMu.RUnlock()
+
+	return queryCountDebug
+}
+
+// CountQueries runs fn with a db handle counting the queries issued through it,
+// including preloads, and returns that count with fn's error
+func CountQueries(db *gorm.DB, fn func(db *gorm.DB) error) (int, error) {
+	if err := registerQueryCounter(db); err != nil {
+		return 0, err
+	}
+
+	parent := db.Statement.Context
+	if parent == nil {
+		parent = context.Background()
+	}
+
+	var count int64
+	err := fn(db.WithContext(context.WithValue(parent, queryCounterKey{}, &count)))
+	return int(atomic.LoadInt64(&count)), err
+}
+
+// registerQueryCounter registers the callback counting queries once per db
+func registerQueryCounter(db *gorm.DB) error {
+	queryCounterRegisterMu.Lock()
+	defer queryCounterRegisterMu.Unlock()
+
+	if db.Callback().Query().Get(queryCounterCallback) != nil {
+		return nil
+	}
+	return db.Callback().Query().After("gorm:query").Register(queryCounterCallback, func(tx *gorm.DB) {
+		if count, ok := tx.Statement.Context.Value(queryCounterKey{}).(*int64); ok {
+			atomic.AddInt64(count, 1)
+		}
+	})
+}
+
+// warnOnExcessQueries logs a warning when a paginated query issued more queries than its includes explain
+func warnOnExcessQueries(tableName string, includes []string, count int) {
+	if budget := len(includes) + 2; count > budget {
+		log.Printf("pagination: possible N+1 on %s: %d queries issued for %d includes (expected at most %d)",
+			tableName, count, len(includes), budget)
+	}
+}
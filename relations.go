@@ -0,0 +1,184 @@
+package pagination
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// RelationCount describes a has-one/has-many relation whose row count is selected alongside each record
+type RelationCount struct {
+	Relation string
+	Alias    string
+}
+
+// RelationCountProvider interface for query builders that select relation counts
+type RelationCountProvider interface {
+	GetRelationCounts() []RelationCount
+}
+
+// CountSortProvider interface for query builders that sort by relation counts under registered aliases
+type CountSortProvider interface {
+	GetCountSorts() []RelationCount
+}
+
+// schemaCache caches parsed model schemas used to resolve relations
+var schemaCache = &sync.Map{}
+
+// parseModelSchema parses the GORM schema of the result type T
+func parseModelSchema[T any](db *gorm.DB) (*schema.Schema, error) {
+	modelSchema, err := schema.Parse(new(T), schemaCache, db.NamingStrategy)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse model schema: %w", err)
+	}
+	return modelSchema, nil
+}
+
+// primaryKeyColumns returns the columns of the model's primary key, several for a
+// composite key
+func primaryKeyColumns(modelSchema *schema.Schema) []string {
+	columns := make([]string, len(modelSchema.PrimaryFields))
+	for i, field := range modelSchema.PrimaryFields {
+		columns[i] = field.DBName
+	}
+	return columns
+}
+
+// relationCountSubquery builds the correlated subquery counting the related rows of each parent row
+func relationCountSubquery(modelSchema *schema.Schema, tableName string, relationName string) (string, error) {
+	relation, ok := modelSchema.Relationships.Relations[relationName]
+	if !ok {
+		return "", fmt.Errorf("unknown relation %q", relationName)
+	}
+
+	if relation.Type != schema.HasMany && relation.Type != schema.HasOne {
+		return "", fmt.Errorf("relation %q must be has-one or has-many to be counted", relationName)
+	}
+
+	if relation.Polymorphic != nil {
+		return "", fmt.Errorf("polymorphic relation %q cannot be counted", relationName)
+	}
+
+	relatedTable := relation.FieldSchema.Table
+	conditions := make([]string, 0, len(relation.References))
+	for _, reference := range relation.References {
+		if reference.PrimaryKey == nil || reference.ForeignKey == nil {
+			continue
+		}
+		conditions = append(conditions,
+			relatedTable+"."+reference.ForeignKey.DBName+" = "+tableName+"."+reference.PrimaryKey.DBName)
+	}
+
+	if len(conditions) == 0 {
+		return "", fmt.Errorf("relation %q has no foreign key", relationName)
+	}
+
+	return "(SELECT COUNT(*) FROM " + relatedTable + " WHERE " + strings.Join(conditions, " AND ") + ")", nil
+}
+
+// relationCountColumns builds a counted subquery column for each of the builder's relation counts
+func relationCountColumns[T any](db *gorm.DB, builder QueryBuilder) ([]string, error) {
+	provider, ok := builder.(RelationCountProvider)
+	if !ok || len(provider.GetRelationCounts()) == 0 {
+		return nil, nil
+	}
+
+	modelSchema, err := parseModelSchema[T](db)
+	if err != nil {
+		return nil, err
+	}
+
+	columns := make([]string, 0, len(provider.GetRelationCounts()))
+	for _, relationCount := range provider.GetRelationCounts() {
+		if !isValidIdentifier(relationCount.Alias) {
+			return nil, fmt.Errorf("invalid relation count alias %q", relationCount.Alias)
+		}
+
+		subquery, err := relationCountSubquery(modelSchema, builder.GetTableName(), relationCount.Relation)
+		if err != nil {
+			return nil, err
+		}
+		columns = append(columns, subquery+" AS "+relationCount.Alias)
+	}
+
+	return columns, nil
+}
+
+// countSortExpression returns the relation count subquery registered under the
+// requested sort alias, if any
+func countSortExpression[T any](db *gorm.DB, builder QueryBuilder, sort string) (string, bool, error) {
+	provider, ok := builder.(CountSortProvider)
+	if !ok || sort == "" {
+		return "", false, nil
+	}
+
+	for _, countSort := range provider.GetCountSorts() {
+		if countSort.Alias != sort {
+			continue
+		}
+
+		modelSchema, err := parseModelSchema[T](db)
+		if err != nil {
+			return "", false, err
+		}
+
+		subquery, err := relationCountSubquery(modelSchema, builder.GetTableName(), countSort.Relation)
+		if err != nil {
+			return "", false, err
+		}
+		return subquery, true, nil
+	}
+
+	return "", false, nil
+}
+
+// isCountSort reports whether the sort is an alias registered by the builder's count sorts
+func isCountSort(builder interface{}, sort string) bool {
+	if provider, ok := builder.(CountSortProvider); ok {
+		for _, countSort := range provider.GetCountSorts() {
+			if countSort.Alias == sort {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// RelationCountFilter restricts records by the number of rows of a has-one/has-many relation
+type RelationCountFilter struct {
+	Relation string
+	Operator string
+	Count    int
+}
+
+// applyRelationCountFilter adds a WHERE comparing the relation's correlated row count
+func applyRelationCountFilter(query *gorm.DB, model interface{}, tableName string, filter RelationCountFilter) *gorm.DB {
+	if model == nil {
+		query.AddError(fmt.Errorf("relation count filter on %q requires a model, see WithModel", filter.Relation))
+		return query
+	}
+
+	switch filter.Operator {
+	case "=", "!=", "<>", ">", ">=", "<", "<=":
+	default:
+		query.AddError(fmt.Errorf("invalid relation count operator %q", filter.Operator))
+		return query
+	}
+
+	modelSchema, err := schema.Parse(model, schemaCache, query.NamingStrategy)
+	if err != nil {
+		query.AddError(fmt.Errorf("failed to parse model schema: %w", err))
+		return query
+	}
+
+	subquery, err := relationCountSubquery(modelSchema, tableName, filter.Relation)
+	if err != nil {
+		query.AddError(err)
+		return query
+	}
+
+	return query.Where(subquery+" "+filter.Operator+" ?", filter.Count)
+}
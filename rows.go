@@ -0,0 +1,96 @@
+package pagination
+
+import (
+	"context"
+	"database/sql"
+
+	"gorm.io/gorm"
+)
+
+// RowIterator yields the rows of a page one at a time instead of loading the whole slice.
+// Next closes the underlying rows once they are exhausted or an error occurs; call Close
+// when stopping early.
+type RowIterator[T any] struct {
+	db      *gorm.DB
+	rows    *sql.Rows
+	cancel  context.CancelFunc
+	current T
+	err     error
+	closed  bool
+}
+
+// PaginatedRows runs the count query and opens a row iterator over the current page.
+// Includes are not supported, as preloading needs the whole page in memory.
+func PaginatedRows[T any](
+	db *gorm.DB,
+	builder QueryBuilder,
+	pagination PaginationRequest,
+) (*RowIterator[T], int64, error) {
+	options := PaginatedQueryOptions{
+		Dialect: MySQL, // Default to MySQL for backward compatibility
+	}
+
+	totalCount, err := countRecords(db, builder, pagination, options, allScopes)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	dataDB, cancel := withQueryTimeout(db, builder)
+	dataQuery, err := buildDataQuery[T](dataDB, builder, pagination, options)
+	if err != nil {
+		cancel()
+		return nil, 0, err
+	}
+
+	rows, err := dataQuery.Rows()
+	if err != nil {
+		cancel()
+		return nil, 0, queryError(dataDB, "failed to fetch records", err)
+	}
+
+	return &RowIterator[T]{db: dataQuery, rows: rows, cancel: cancel}, totalCount, nil
+}
+
+// Next advances to the next row, returning false when the page is exhausted or on error
+func (it *RowIterator[T]) Next() bool {
+	if it.closed {
+		return false
+	}
+
+	if !it.rows.Next() {
+		it.err = it.rows.Err()
+		it.Close()
+		return false
+	}
+
+	var item T
+	if err := it.db.ScanRows(it.rows, &item); err != nil {
+		it.err = err
+		it.Close()
+		return false
+	}
+
+	it.current = item
+	return true
+}
+
+// Value returns the current row
+func (it *RowIterator[T]) Value() T {
+	return it.current
+}
+
+// Err returns the error that stopped the iteration, if any
+func (it *RowIterator[T]) Err() error {
+	return it.err
+}
+
+// Close releases the underlying rows; it is safe to call more than once
+func (it *RowIterator[T]) Close() error {
+	if it.closed {
+		return nil
+	}
+	it.closed = true
+	defer it.cancel()
+
+	return it.rows.Close()
+}
@@ -0,0 +1,218 @@
+package pagination
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// ImpossibleConditionsProvider interface for query builders that can tell their filters
+// match no record, so the count and data queries are skipped and an empty page returned
+type ImpossibleConditionsProvider interface {
+	HasImpossibleConditions() bool
+}
+
+// hasImpossibleConditions reports whether the builder's filters provably match nothing
+func hasImpossibleConditions(builder interface{}) bool {
+	provider, ok := builder.(ImpossibleConditionsProvider)
+	return ok && provider.HasImpossibleConditions()
+}
+
+// HasImpossibleConditions reports whether the filter's conditions provably match no
+// record: an IN with no values, IS NULL together with any other test of the field,
+// different equality values, or equality and range conditions on a numeric field that
+// contradict each other. Filters combined with OR are not analyzed, so the check only
+// ever errs towards running the query, which is also how an unregistered preset
+// reaches ApplyFilters and its error.
+func (d *DynamicFilter) HasImpossibleConditions() bool {
+	presetConditions, err := d.presetConditions()
+	if err != nil {
+		return false
+	}
+	conditions := append(append([]FilterCondition(nil), presetConditions...), d.Filters...)
+
+	fields := map[string][]FilterCondition{}
+	numeric := map[string]bool{}
+	for i, filter := range conditions {
+		if filter.Field == "" || (filter.Value == nil && filter.CompareField == "") {
+			continue
+		}
+		field, ok := d.modelField(filter.Field)
+		if !ok {
+			continue
+		}
+		if i > 0 && strings.EqualFold(filter.Logic, "OR") {
+			return false
+		}
+		// Column comparisons depend on each row, so they never contradict
+		if filter.CompareField != "" {
+			continue
+		}
+
+		fields[field.Name] = append(fields[field.Name], filter)
+		numeric[field.Name] = isNumericKind(field.Type)
+	}
+
+	for name, fieldConditions := range fields {
+		if contradicts(fieldConditions, numeric[name]) {
+			return true
+		}
+	}
+	return false
+}
+
+// valueBound is one end of the range of values a numeric field may take
+type valueBound struct {
+	value     float64
+	inclusive bool
+	set       bool
+}
+
+// contradicts reports whether the AND-ed conditions on a single field can't all hold
+func contradicts(conditions []FilterCondition, numeric bool) bool {
+	var equals, notEquals []interface{}
+	var lower, upper valueBound
+	isNull := false
+
+	for _, filter := range conditions {
+		operator := strings.ToUpper(filter.Operator)
+		switch operator {
+		case "IN":
+			if value := reflect.ValueOf(filter.Value); value.Kind() == reflect.Slice && value.Len() == 0 {
+				return true
+			}
+		case "IS_NULL":
+			isNull = true
+		case "=", "EQ", "EQUALS":
+			equals = append(equals, filter.Value)
+		case "!=", "<>", "NE", "NOT_EQUALS", "NE_OR_NULL":
+			notEquals = append(notEquals, filter.Value)
+		}
+
+		number, ok := toFloat(filter.Value)
+		if !ok || !numeric {
+			continue
+		}
+		// An equality is a range bounded inclusively on both sides by its value
+		if operator == "=" || operator == "EQ" || operator == "EQUALS" {
+			lower = tighterBound(lower, valueBound{number, true, true}, true)
+			upper = tighterBound(upper, valueBound{number, true, true}, false)
+		} else if isLower, inclusive, ok := rangeOperator(operator); ok {
+			if isLower {
+				lower = tighterBound(lower, valueBound{number, inclusive, true}, true)
+			} else {
+				upper = tighterBound(upper, valueBound{number, inclusive, true}, false)
+			}
+		}
+	}
+
+	// NULL fails every other comparison, except ne_or_null which matches NULL as well
+	for _, filter := range conditions {
+		if operator := strings.ToUpper(filter.Operator); isNull && operator != "IS_NULL" && operator != "NE_OR_NULL" {
+			return true
+		}
+	}
+
+	// Values equal ignoring case may match the same row under a case-insensitive collation
+	for i := 1; i < len(equals); i++ {
+		if !looselyEqual(equals[0], equals[i], numeric) {
+			return true
+		}
+	}
+	for _, equal := range equals {
+		for _, notEqual := range notEquals {
+			if fmt.Sprint(equal) == fmt.Sprint(notEqual) {
+				return true
+			}
+		}
+	}
+
+	if lower.set && upper.set {
+		return lower.value > upper.value || (lower.value == upper.value && !(lower.inclusive && upper.inclusive))
+	}
+	return false
+}
+
+// rangeOperator classifies a comparison operator as a lower or upper bound
+func rangeOperator(operator string) (isLower bool, inclusive bool, ok bool) {
+	switch operator {
+	case ">", "GT", "GREATER_THAN":
+		return true, false, true
+	case ">=", "GTE", "GREATER_THAN_EQUALS":
+		return true, true, true
+	case "<", "LT", "LESS_THAN":
+		return false, false, true
+	case "<=", "LTE", "LESS_THAN_EQUALS":
+		return false, true, true
+	default:
+		return false, false, false
+	}
+}
+
+// tighterBound returns the more restrictive of two lower (or upper) bounds
+func tighterBound(current valueBound, candidate valueBound, isLower bool) valueBound {
+	if !current.set {
+		return candidate
+	}
+	if candidate.value == current.value {
+		current.inclusive = current.inclusive && candidate.inclusive
+		return current
+	}
+	if (isLower && candidate.value > current.value) || (!isLower && candidate.value < current.value) {
+		return candidate
+	}
+	return current
+}
+
+// isBoolKind reports whether a model field holds bools
+func isBoolKind(fieldType reflect.Type) bool {
+	for fieldType.Kind() == reflect.Ptr {
+		fieldType = fieldType.Elem()
+	}
+	return fieldType.Kind() == reflect.Bool
+}
+
+// isNumericKind reports whether a model field holds numbers
+func isNumericKind(fieldType reflect.Type) bool {
+	for fieldType.Kind() == reflect.Ptr {
+		fieldType = fieldType.Elem()
+	}
+	switch fieldType.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	default:
+		return false
+	}
+}
+
+// toFloat converts a numeric filter value, or a string holding a number, to float64
+func toFloat(value interface{}) (float64, bool) {
+	switch v := reflect.ValueOf(value); v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), true
+	case reflect.String:
+		number, err := strconv.ParseFloat(strings.TrimSpace(v.String()), 64)
+		return number, err == nil
+	default:
+		return 0, false
+	}
+}
+
+// looselyEqual reports whether two equality values could match the same row
+func looselyEqual(a interface{}, b interface{}, numeric bool) bool {
+	if numeric {
+		aNumber, aOK := toFloat(a)
+		bNumber, bOK := toFloat(b)
+		if aOK && bOK {
+			return aNumber == bNumber
+		}
+	}
+	return strings.EqualFold(fmt.Sprint(a), fmt.Sprint(b))
+}
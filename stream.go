@@ -0,0 +1,65 @@
+package pagination
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
+
+// PageResult is a page emitted by PaginateChannel, or the error that ended it
+type PageResult[T any] struct {
+	Data       []T
+	Pagination PaginationResponse
+	Err        error
+}
+
+// PaginateChannel fetches pages one at a time in a goroutine, starting at the request's
+// page, and emits each on the returned channel until the last page, an error or the
+// context's cancellation. Each page is only fetched once the previous one is received,
+// so slow consumers apply backpressure. A query error is emitted as the final result;
+// on cancellation the goroutine stops without blocking, emitting the context's error
+// only to a consumer still receiving. The channel is always closed afterwards.
+func PaginateChannel[T any](ctx context.Context, db *gorm.DB, builder QueryBuilder, pagination PaginationRequest) <-chan PageResult[T] {
+	results := make(chan PageResult[T])
+
+	go func() {
+		defer close(results)
+
+		pagination := pagination.Normalized()
+		for {
+			if err := ctx.Err(); err != nil {
+				sendPageResult(ctx, results, PageResult[T]{Err: err})
+				return
+			}
+
+			data, paginationResponse, err := PaginateCore[T](db.WithContext(ctx), builder, pagination)
+			if err != nil {
+				sendPageResult(ctx, results, PageResult[T]{Err: err})
+				return
+			}
+
+			if !sendPageResult(ctx, results, PageResult[T]{Data: data, Pagination: paginationResponse}) {
+				return
+			}
+
+			// Stop on the last page, or on a short page if rows were deleted meanwhile
+			if pagination.IsDisabled || int64(paginationResponse.Page) >= paginationResponse.MaxPage || len(data) < pagination.PerPage {
+				return
+			}
+			pagination.Page = paginationResponse.Page + 1
+		}
+	}()
+
+	return results
+}
+
+// sendPageResult emits result unless the context is cancelled first, reporting whether
+// it was sent
+func sendPageResult[T any](ctx context.Context, results chan<- PageResult[T], result PageResult[T]) bool {
+	select {
+	case results <- result:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
@@ -0,0 +1,46 @@
+package pagination
+
+import (
+	"gorm.io/gorm"
+)
+
+// PaginateTableFunction paginates the rows of a set-returning expression that takes bind
+// parameters, such as the table-valued function call "get_rankings(?)" on PostgreSQL or
+// SQL Server, used as the FROM source of both the count and data queries. funcExpr is
+// SQL and must never come from the client; only args are bound. The request's sort is
+// applied when it passes the sort field validator, otherwise rows come in the order
+// the expression returns them. Sort columns failing it are dropped.
+func PaginateTableFunction[T any](
+	db *gorm.DB,
+	funcExpr string,
+	args []interface{},
+	pagination PaginationRequest,
+) ([]T, PaginationResponse, error) {
+	pagination = pagination.Normalized()
+	source := funcExpr + " AS function_rows"
+
+	var totalCount int64
+	if err := db.Table(source, args...).Count(&totalCount).Error; err != nil {
+		return nil, PaginationResponse{}, queryError(db, "failed to count records", err)
+	}
+
+	dataQuery := db.Table(source, args...)
+	for _, field := range pagination.SortFields() {
+		if validateSortField(nil, field.Field) {
+			dataQuery = dataQuery.Order(field.Field + " " + field.Direction)
+		}
+	}
+	if !pagination.IsDisabled {
+		if offset := pagination.GetOffset(); offset > 0 {
+			dataQuery = dataQuery.Offset(offset)
+		}
+		dataQuery = dataQuery.Limit(pagination.GetLimit())
+	}
+
+	var result []T
+	if err := dataQuery.Find(&result).Error; err != nil {
+		return nil, PaginationResponse{}, queryError(db, "failed to fetch records", err)
+	}
+
+	return emptyIfNil(result), CalculatePagination(pagination, totalCount), nil
+}